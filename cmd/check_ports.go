@@ -1,23 +1,31 @@
 package cmd
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"sort"
-	"strconv"
 	"strings"
 	"text/tabwriter"
 
 	"github.com/spf13/cobra"
+	"github.com/woliveiras/corsarr/internal/generator"
 	"github.com/woliveiras/corsarr/internal/i18n"
 	"github.com/woliveiras/corsarr/internal/services"
+	"github.com/woliveiras/corsarr/internal/textdiff"
 )
 
 var (
 	checkPortsOutputDir string
 	checkPortsSuggest   bool
+	checkPortsFix       bool
 )
 
 // checkPortsCmd represents the check-ports command
@@ -31,11 +39,13 @@ This command will:
 - Check if ports are available on the system
 - Suggest alternative ports if conflicts are detected
 - Show which process is using conflicting ports
+- Rewrite docker-compose.yml with the suggested ports (--fix)
 
 Example:
   corsarr check-ports
   corsarr check-ports --output /path/to/compose
-  corsarr check-ports --suggest`,
+  corsarr check-ports --suggest
+  corsarr check-ports --fix`,
 	Run: func(cmd *cobra.Command, args []string) {
 		t := GetTranslator()
 
@@ -51,15 +61,29 @@ func init() {
 
 	checkPortsCmd.Flags().StringVarP(&checkPortsOutputDir, "output", "o", ".", "Directory with docker-compose.yml")
 	checkPortsCmd.Flags().BoolVarP(&checkPortsSuggest, "suggest", "s", false, "Suggest alternative ports for conflicts")
+	checkPortsCmd.Flags().BoolVar(&checkPortsFix, "fix", false, "Rewrite docker-compose.yml to use the suggested ports")
 }
 
 type PortInfo struct {
-	Service   string
-	Port      int
-	Protocol  string
-	InUse     bool
-	Available bool
-	UsedBy    string
+	// ComposeService is the compose service publishing the port, which is
+	// gluetun for the applications behind it in VPN mode
+	ComposeService string
+	ServiceID      string
+	Service        string
+	ContainerPort  string
+	Port           int
+	Protocol       string
+	InUse          bool
+	Available      bool
+	UsedBy         string
+	// HeldByStack marks a port bound by the stack's own running containers,
+	// which is not a conflict
+	HeldByStack bool
+}
+
+// Conflict reports whether the port is taken by something outside the stack
+func (p PortInfo) Conflict() bool {
+	return p.InUse && !p.HeldByStack
 }
 
 func runPortCheck(t *i18n.I18n) error {
@@ -67,7 +91,7 @@ func runPortCheck(t *i18n.I18n) error {
 	fmt.Printf("📂 %s: %s\n\n", t.T("ports.directory"), checkPortsOutputDir)
 
 	// Load service registry
-	registry, err := services.NewRegistry()
+	registry, err := newServiceRegistry()
 	if err != nil {
 		return fmt.Errorf("%s: %w", t.T("errors.failed_to_load_services"), err)
 	}
@@ -78,42 +102,52 @@ func runPortCheck(t *i18n.I18n) error {
 		return fmt.Errorf("%s: %s", t.T("errors.compose_not_found"), composePath)
 	}
 
+	content, err := os.ReadFile(composePath)
+	if err != nil {
+		return fmt.Errorf("%s: %w", t.T("errors.failed_to_parse_compose"), err)
+	}
+	bindings, err := generator.ParseComposePorts(content)
+	if err != nil {
+		return fmt.Errorf("%s: %w", t.T("errors.failed_to_parse_compose"), err)
+	}
 	configuredServices, err := getConfiguredServices(composePath)
 	if err != nil {
 		return fmt.Errorf("%s: %w", t.T("errors.failed_to_parse_compose"), err)
 	}
 
-	// Collect all ports from configured services
+	// A running stack holds its own published ports through docker-proxy or
+	// rootlessport, so those ports are not conflicts
+	stackPorts := stackPublishedPorts(checkPortsOutputDir)
+
+	// Collect the host ports published in docker-compose.yml
 	var ports []PortInfo
 
-	for _, serviceName := range configuredServices {
-		service, err := registry.GetService(serviceName)
-		if err != nil || service == nil {
-			continue
-		}
+	for _, binding := range bindings {
+		owner := portOwner(registry, configuredServices, binding)
 
-		for _, portMapping := range service.Ports {
-			hostPort, err := strconv.Atoi(portMapping.Host)
-			if err != nil {
-				continue
-			}
-
-			info := PortInfo{
-				Service:  service.Name,
-				Port:     hostPort,
-				Protocol: portMapping.Protocol,
-			}
+		info := PortInfo{
+			ComposeService: binding.Service,
+			ServiceID:      owner.ID,
+			Service:        owner.Name,
+			ContainerPort:  binding.Container,
+			Port:           binding.Host,
+			Protocol:       binding.Protocol,
+		}
 
-			// Check if port is available
-			info.Available = isPortAvailable(hostPort, portMapping.Protocol)
-			info.InUse = !info.Available
+		// Check if port is available
+		info.Available = isPortAvailable(binding.Host, binding.Protocol)
+		info.InUse = !info.Available
 
-			if info.InUse {
-				info.UsedBy = getProcessUsingPort(hostPort)
+		if info.InUse {
+			info.HeldByStack = stackPorts[portKey(binding.Host, binding.Protocol)]
+			if info.HeldByStack {
+				info.UsedBy = binding.Service
+			} else {
+				info.UsedBy = getProcessUsingPort(binding.Host)
 			}
-
-			ports = append(ports, info)
 		}
+
+		ports = append(ports, info)
 	}
 
 	if len(ports) == 0 {
@@ -132,7 +166,7 @@ func runPortCheck(t *i18n.I18n) error {
 	// Count conflicts
 	conflicts := 0
 	for _, p := range ports {
-		if p.InUse {
+		if p.Conflict() {
 			conflicts++
 		}
 	}
@@ -148,13 +182,20 @@ func runPortCheck(t *i18n.I18n) error {
 	if conflicts > 0 {
 		fmt.Printf("❌ %s: %d\n", t.T("ports.in_use"), conflicts)
 
-		if checkPortsSuggest {
+		switch {
+		case checkPortsFix:
+			fmt.Println()
+			if err := fixPortConflicts(t, ports); err != nil {
+				return err
+			}
+		case checkPortsSuggest:
 			fmt.Println()
 			fmt.Printf("💡 %s:\n", t.T("ports.suggestions"))
 			suggestAlternativePorts(t, ports)
-		} else {
+		default:
 			fmt.Println()
 			fmt.Printf("💡 %s: corsarr check-ports --suggest\n", t.T("ports.suggest_hint"))
+			fmt.Printf("🔧 %s: corsarr check-ports --fix\n", t.T("ports.fix_hint"))
 		}
 	} else {
 		fmt.Printf("✅ %s\n", t.T("ports.no_conflicts"))
@@ -273,7 +314,10 @@ func displayPortStatus(t *i18n.I18n, ports []PortInfo) {
 		statusText := t.T("ports.available")
 		usedBy := "-"
 
-		if p.InUse {
+		if p.HeldByStack {
+			statusText = t.T("ports.held_by_stack")
+			usedBy = p.UsedBy
+		} else if p.InUse {
 			statusIcon = "❌"
 			statusText = t.T("ports.in_use")
 			usedBy = p.UsedBy
@@ -293,20 +337,194 @@ func displayPortStatus(t *i18n.I18n, ports []PortInfo) {
 
 func suggestAlternativePorts(t *i18n.I18n, ports []PortInfo) {
 	for _, p := range ports {
-		if !p.InUse {
+		if !p.Conflict() {
 			continue
 		}
 
 		// Find next available port
 		alternativePort := findNextAvailablePort(p.Port, p.Protocol)
 		if alternativePort > 0 {
-			fmt.Printf("   • %s (%d) → %s %d\n",
+			fmt.Printf("   • %s (%d) → %s %d (corsarr generate --port %s)\n",
 				p.Service,
 				p.Port,
 				t.T("ports.use_port"),
-				alternativePort)
+				alternativePort,
+				portOverrideFlag(p, alternativePort))
+		}
+	}
+}
+
+// portOwner returns the service a compose port belongs to. In VPN mode the
+// ports of the applications behind Gluetun are published by gluetun, so the
+// application is found by the container port it listens on.
+func portOwner(registry *services.Registry, configuredServices []string, binding generator.ComposePort) *services.Service {
+	if service, err := registry.GetService(binding.Service); err == nil && publishesContainerPort(service, binding) {
+		return service
+	}
+	for _, name := range configuredServices {
+		if name == binding.Service {
+			continue
+		}
+		if service, err := registry.GetService(name); err == nil && publishesContainerPort(service, binding) {
+			return service
+		}
+	}
+	if service, err := registry.GetService(binding.Service); err == nil {
+		return service
+	}
+	return &services.Service{ID: binding.Service, Name: binding.Service}
+}
+
+func publishesContainerPort(service *services.Service, binding generator.ComposePort) bool {
+	for _, mapping := range service.Ports {
+		if mapping.Container == binding.Container && mapping.Protocol == binding.Protocol {
+			return true
 		}
 	}
+	return false
+}
+
+// fixPortConflicts moves every conflicting host port to the next free port,
+// rewrites docker-compose.yml and prints what changed
+func fixPortConflicts(t *i18n.I18n, ports []PortInfo) error {
+	changes := planPortChanges(ports, isPortAvailable)
+	if len(changes) == 0 {
+		fmt.Printf("⚠️  %s\n", t.T("ports.fix_no_alternative"))
+		return nil
+	}
+
+	before, after, err := generator.FixComposePorts(checkPortsOutputDir, changes)
+	if err != nil {
+		return fmt.Errorf("%s: %w", t.T("ports.fix_failed"), err)
+	}
+
+	fmt.Printf("🔧 %s:\n", t.T("ports.fix_applied"))
+	for _, change := range changes {
+		fmt.Printf("   • %s %d/%s → %d\n", change.Service, change.From, change.Protocol, change.To)
+	}
+	fmt.Println()
+	fmt.Print(textdiff.Unified("docker-compose.yml", "docker-compose.yml", string(before), string(after)))
+	fmt.Println()
+	fmt.Printf("💾 %s\n", t.T("ports.fix_backup"))
+	fmt.Printf("💡 %s\n", t.T("ports.fix_restart"))
+	return nil
+}
+
+// planPortChanges picks a replacement for every conflicting host port. Ports
+// already published by the compose file or picked for another conflict are
+// skipped so the fix never introduces a new collision.
+func planPortChanges(ports []PortInfo, available func(port int, protocol string) bool) []generator.PortChange {
+	taken := make(map[string]bool)
+	for _, p := range ports {
+		taken[portKey(p.Port, p.Protocol)] = true
+	}
+
+	var changes []generator.PortChange
+	planned := make(map[string]bool)
+	for _, p := range ports {
+		binding := p.ComposeService + " " + portKey(p.Port, p.Protocol)
+		if !p.Conflict() || planned[binding] {
+			continue
+		}
+		planned[binding] = true
+		for candidate := p.Port + 1; candidate <= p.Port+100; candidate++ {
+			if taken[portKey(candidate, p.Protocol)] || !available(candidate, p.Protocol) {
+				continue
+			}
+			taken[portKey(candidate, p.Protocol)] = true
+			changes = append(changes, generator.PortChange{
+				Service:  p.ComposeService,
+				Protocol: p.Protocol,
+				From:     p.Port,
+				To:       candidate,
+			})
+			break
+		}
+	}
+	return changes
+}
+
+// composePublisher is one published port reported by docker compose ps
+type composePublisher struct {
+	PublishedPort int    `json:"PublishedPort"`
+	Protocol      string `json:"Protocol"`
+}
+
+// stackPublishedPorts returns the host ports published by the running
+// containers of the compose project in dir. It returns nil when the stack is
+// not running or Docker is unavailable.
+func stackPublishedPorts(dir string) map[string]bool {
+	cmd := exec.CommandContext(context.Background(), "docker", "compose", "-f", filepath.Join(dir, "docker-compose.yml"), "ps", "--format", "json")
+	output, err := cmd.Output()
+	if err != nil {
+		return nil
+	}
+	ports, err := parsePublishedPorts(output)
+	if err != nil {
+		return nil
+	}
+	return ports
+}
+
+// parsePublishedPorts reads the publishers from docker compose ps output,
+// which is a JSON array in older Compose releases and one object per line in
+// newer ones
+func parsePublishedPorts(output []byte) (map[string]bool, error) {
+	type psEntry struct {
+		Publishers []composePublisher `json:"Publishers"`
+	}
+
+	var entries []psEntry
+	decoder := json.NewDecoder(bytes.NewReader(output))
+	for {
+		var raw json.RawMessage
+		if err := decoder.Decode(&raw); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, err
+		}
+		if trimmed := bytes.TrimSpace(raw); len(trimmed) > 0 && trimmed[0] == '[' {
+			var batch []psEntry
+			if err := json.Unmarshal(raw, &batch); err != nil {
+				return nil, err
+			}
+			entries = append(entries, batch...)
+			continue
+		}
+		var entry psEntry
+		if err := json.Unmarshal(raw, &entry); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	ports := make(map[string]bool)
+	for _, entry := range entries {
+		for _, publisher := range entry.Publishers {
+			if publisher.PublishedPort == 0 {
+				continue
+			}
+			protocol := publisher.Protocol
+			if protocol == "" {
+				protocol = "tcp"
+			}
+			ports[portKey(publisher.PublishedPort, protocol)] = true
+		}
+	}
+	return ports, nil
+}
+
+func portKey(port int, protocol string) string {
+	return fmt.Sprintf("%d/%s", port, protocol)
+}
+
+// portOverrideFlag returns the --port value that applies a suggested port
+func portOverrideFlag(p PortInfo, port int) string {
+	if p.ContainerPort != "" {
+		return fmt.Sprintf("%s:%s=%d", p.ServiceID, p.ContainerPort, port)
+	}
+	return fmt.Sprintf("%s=%d", p.ServiceID, port)
 }
 
 func findNextAvailablePort(startPort int, protocol string) int {
@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/woliveiras/corsarr/internal/generator"
+)
+
+func TestPlanPortChangesSkipsPublishedAndBusyPorts(t *testing.T) {
+	ports := []PortInfo{
+		{ComposeService: "gluetun", Port: 8989, Protocol: "tcp", InUse: true},
+		{ComposeService: "gluetun", Port: 8990, Protocol: "tcp"},
+		{ComposeService: "gluetun", Port: 6881, Protocol: "udp", InUse: true},
+		{ComposeService: "gluetun", Port: 6881, Protocol: "udp", InUse: true},
+		{ComposeService: "jellyfin", Port: 8096, Protocol: "tcp"},
+	}
+	busy := map[string]bool{"8991/tcp": true}
+	available := func(port int, protocol string) bool {
+		return !busy[portKey(port, protocol)]
+	}
+
+	got := planPortChanges(ports, available)
+	want := []generator.PortChange{
+		{Service: "gluetun", Protocol: "tcp", From: 8989, To: 8992},
+		{Service: "gluetun", Protocol: "udp", From: 6881, To: 6882},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("planPortChanges() = %+v, want %+v", got, want)
+	}
+}
+
+func TestPortOverrideFlag(t *testing.T) {
+	if got := portOverrideFlag(PortInfo{ServiceID: "sonarr"}, 18989); got != "sonarr=18989" {
+		t.Errorf("portOverrideFlag() = %q", got)
+	}
+	if got := portOverrideFlag(PortInfo{ServiceID: "qbittorrent", ContainerPort: "6881"}, 16881); got != "qbittorrent:6881=16881" {
+		t.Errorf("portOverrideFlag() = %q", got)
+	}
+}
+
+func TestPlanPortChangesSkipsPortsHeldByTheStack(t *testing.T) {
+	ports := []PortInfo{
+		{ComposeService: "sonarr", Port: 8989, Protocol: "tcp", InUse: true, HeldByStack: true},
+		{ComposeService: "radarr", Port: 7878, Protocol: "tcp", InUse: true},
+	}
+	available := func(port int, protocol string) bool { return true }
+
+	got := planPortChanges(ports, available)
+	want := []generator.PortChange{{Service: "radarr", Protocol: "tcp", From: 7878, To: 7879}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("planPortChanges() = %+v, want %+v", got, want)
+	}
+}
+
+func TestParsePublishedPorts(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+	}{
+		{"JSON array", `[{"Name":"sonarr","Publishers":[{"URL":"0.0.0.0","TargetPort":8989,"PublishedPort":8989,"Protocol":"tcp"}]},
+{"Name":"gluetun","Publishers":[{"URL":"0.0.0.0","TargetPort":6881,"PublishedPort":6881,"Protocol":"udp"},{"URL":"","TargetPort":8000,"PublishedPort":0,"Protocol":"tcp"}]}]`},
+		{"JSON lines", `{"Name":"sonarr","Publishers":[{"URL":"0.0.0.0","TargetPort":8989,"PublishedPort":8989,"Protocol":"tcp"}]}
+{"Name":"gluetun","Publishers":[{"URL":"0.0.0.0","TargetPort":6881,"PublishedPort":6881,"Protocol":"udp"},{"URL":"","TargetPort":8000,"PublishedPort":0,"Protocol":"tcp"}]}
+`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parsePublishedPorts([]byte(tt.output))
+			if err != nil {
+				t.Fatalf("parsePublishedPorts() error = %v", err)
+			}
+			want := map[string]bool{"8989/tcp": true, "6881/udp": true}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("parsePublishedPorts() = %v, want %v", got, want)
+			}
+		})
+	}
+}
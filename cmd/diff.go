@@ -0,0 +1,226 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/woliveiras/corsarr/internal/generator"
+	"github.com/woliveiras/corsarr/internal/i18n"
+	"github.com/woliveiras/corsarr/internal/profile"
+	"github.com/woliveiras/corsarr/internal/services"
+	"github.com/woliveiras/corsarr/internal/textdiff"
+)
+
+// diffCmd represents the diff command
+var diffCmd = &cobra.Command{
+	Use:   "diff",
+	Short: "Show what regenerating would change",
+	Long: `Render the docker-compose.yml and .env for a profile or for the generate
+flags and compare them with the files in the output directory.
+
+The command lists the services added or removed, the ports, volumes and
+environment variables that change, and prints a unified diff. Secret values
+are masked.
+
+Exit status is 0 when the files are up to date, 1 when they differ and 2
+when the comparison fails, so scripts can run it before regenerating.
+
+Example:
+  corsarr diff --profile my-setup
+  corsarr diff --services radarr,sonarr --arr-path /srv/media/ --output /srv/stack`,
+	Run: func(cmd *cobra.Command, args []string) {
+		t := GetTranslator()
+
+		changed, err := runDiff(t)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ %s: %v\n", t.T("diff.failed"), err)
+			os.Exit(2)
+		}
+		if changed {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(diffCmd)
+
+	diffCmd.Flags().StringVarP(&profileName, "profile", "p", "", "Compare the files a saved profile would generate")
+	diffCmd.Flags().StringVarP(&outputDir, "output", "o", ".", "Directory with docker-compose.yml and .env")
+	diffCmd.Flags().StringVar(&servicesList, "services", "", "Comma-separated list of services (e.g., 'radarr,sonarr,prowlarr')")
+	diffCmd.Flags().BoolVar(&useVPN, "vpn", false, "Enable VPN mode (Gluetun)")
+	diffCmd.Flags().StringVar(&arrPath, "arr-path", "", "Base path for media library")
+	diffCmd.Flags().StringVar(&timezone, "timezone", "", "Timezone (e.g., 'America/Sao_Paulo')")
+	diffCmd.Flags().StringVar(&puid, "puid", "", "User ID for file permissions")
+	diffCmd.Flags().StringVar(&pgid, "pgid", "", "Group ID for file permissions")
+	diffCmd.Flags().StringVar(&umask, "umask", "002", "File creation mask")
+	diffCmd.Flags().StringVar(&projectName, "project-name", "corsarr", "Docker Compose project name")
+	addVPNFlags(diffCmd)
+	diffCmd.Flags().BoolVar(&secretsMode, "secrets", false, "Compare as if VPN credentials were kept in secret files")
+	diffCmd.Flags().StringArrayVar(&portFlags, "port", nil, "Override a host port: service=port or service:container=port (repeatable)")
+	diffCmd.Flags().StringVar(&hwaccelFlag, "hwaccel", "", "Hardware transcoding for Jellyfin and FileFlows: vaapi, qsv, nvidia or none")
+	diffCmd.Flags().StringVar(&proxyRouting, "proxy-routing", generator.ProxyRoutingHost, "Reverse proxy routes: host (sonarr.<domain>) or path (/sonarr)")
+	diffCmd.Flags().StringVar(&proxyDomain, "proxy-domain", "local", "Domain used by host-based reverse proxy routes")
+	diffCmd.Flags().BoolVar(&publishPorts, "publish-ports", false, "Keep publishing web interface ports when a reverse proxy is selected")
+}
+
+// runDiff renders the compose and .env files and compares them with the
+// ones in the output directory. It reports whether anything would change.
+func runDiff(t *i18n.I18n) (bool, error) {
+	if err := generator.ValidateProxyRouting(proxyRouting); err != nil {
+		return false, err
+	}
+
+	var loadedProfile *profile.Profile
+	switch {
+	case profileName != "":
+		var err error
+		loadedProfile, err = profile.ResolveProfile(profileName)
+		if err != nil {
+			return false, fmt.Errorf("failed to load profile: %w", err)
+		}
+		fmt.Println(t.T("logs.profile_loaded", map[string]interface{}{"profile": loadedProfile.Name}))
+		if outputDir == "." && loadedProfile.OutputDir != "" {
+			outputDir = loadedProfile.OutputDir
+		}
+	case servicesList == "":
+		return false, fmt.Errorf("%s", t.T("diff.requires_input"))
+	}
+
+	registry, err := newServiceRegistry()
+	if err != nil {
+		return false, fmt.Errorf("failed to create registry: %w", err)
+	}
+	hostPortOverrides, err = resolvePortOverrides(registry, loadedProfile)
+	if err != nil {
+		return false, err
+	}
+	vpnRouting, err = resolveVPNRoutes(registry, loadedProfile)
+	if err != nil {
+		return false, err
+	}
+	resourceSettings, err = resolveResources(registry, loadedProfile)
+	if err != nil {
+		return false, err
+	}
+
+	hwaccelValue := hwaccelFlag
+	vpnEnabled := useVPN
+	var selectedIDs []string
+	var envConfig *generator.EnvConfig
+	if loadedProfile != nil {
+		hwaccelValue = valueOr(hwaccelValue, loadedProfile.HWAccel)
+		vpnEnabled = loadedProfile.VPN.Enabled
+		selectedIDs = loadedProfile.Services
+		envConfig = generator.NewDefaultEnvConfig()
+		if len(loadedProfile.Environment) > 0 {
+			envConfig = envConfigFromProfile(loadedProfile, vpnEnabled)
+		}
+	} else {
+		selectedIDs = strings.Split(servicesList, ",")
+		for i := range selectedIDs {
+			selectedIDs[i] = strings.TrimSpace(selectedIDs[i])
+		}
+		envConfig, err = envConfigFromFlags(vpnEnabled)
+		if err != nil {
+			return false, err
+		}
+	}
+	hwaccel, err = services.ParseHWAccel(hwaccelValue)
+	if err != nil {
+		return false, err
+	}
+	selectedIDs = dedupeServiceIDs(selectedIDs)
+	if len(selectedIDs) == 0 {
+		return false, fmt.Errorf("%s", t.T("errors.no_services_selected"))
+	}
+	envConfig.SecretsMode = secretsMode || (loadedProfile != nil && loadedProfile.Secrets)
+	if vpnEnabled {
+		if err := applyOutboundSubnets(envConfig, loadedProfile); err != nil {
+			return false, err
+		}
+	}
+
+	composeGen := generator.NewComposeGenerator(registry, outputDir)
+	composeGen.SetOptions(composeOptions(envConfig))
+	composeContent, err := composeGen.Preview(selectedIDs, vpnEnabled)
+	if err != nil {
+		return false, fmt.Errorf("compose preview failed: %w", err)
+	}
+	envContent, err := generator.NewEnvGenerator(outputDir).Preview(envConfig)
+	if err != nil {
+		return false, fmt.Errorf("env preview failed: %w", err)
+	}
+
+	fmt.Println(t.T("diff.comparing", map[string]interface{}{"directory": outputDir}))
+
+	currentCompose, err := readOutputFile(t, "docker-compose.yml")
+	if err != nil {
+		return false, err
+	}
+	composeChanges, err := generator.DiffCompose([]byte(currentCompose), []byte(composeContent))
+	if err != nil {
+		return false, err
+	}
+	currentEnv, err := readOutputFile(t, ".env")
+	if err != nil {
+		return false, err
+	}
+	envChanges := generator.DiffEnv(currentEnv, envContent)
+
+	changedFiles := 0
+	if printFileDiff(t, "docker-compose.yml", currentCompose, composeContent, composeChanges) {
+		changedFiles++
+	}
+	if printFileDiff(t, ".env", currentEnv, envContent, envChanges) {
+		changedFiles++
+	}
+
+	fmt.Println()
+	if changedFiles == 0 {
+		fmt.Println(t.T("diff.summary_unchanged", map[string]interface{}{"directory": outputDir}))
+		return false, nil
+	}
+	fmt.Println(t.T("diff.summary_changed", map[string]interface{}{"count": changedFiles, "directory": outputDir}))
+	return true, nil
+}
+
+// readOutputFile returns a file of the output directory, or an empty string
+// when it was not generated yet
+func readOutputFile(t *i18n.I18n, name string) (string, error) {
+	content, err := os.ReadFile(filepath.Join(outputDir, name))
+	if errors.Is(err, os.ErrNotExist) {
+		fmt.Printf("📄 %s\n", t.T("diff.file_missing", map[string]interface{}{"file": name}))
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	return string(content), nil
+}
+
+// printFileDiff prints the semantic changes of a file followed by the
+// unified diff of its masked text. It reports whether the file differs.
+func printFileDiff(t *i18n.I18n, name, before, after string, changes []generator.Change) bool {
+	unified := textdiff.Unified(name, name, generator.MaskSecrets(before), generator.MaskSecrets(after))
+
+	fmt.Println()
+	fmt.Println(t.T("diff.changes_title", map[string]interface{}{"file": name}))
+	fmt.Println("───────────────────────────────────────────────────────")
+	if len(changes) == 0 && before == after {
+		fmt.Println(t.T("diff.no_changes"))
+		return false
+	}
+	for _, change := range changes {
+		fmt.Printf("   %s\n", change)
+	}
+	if unified != "" {
+		fmt.Println()
+		fmt.Print(unified)
+	}
+	return true
+}
@@ -5,11 +5,13 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/spf13/cobra"
 	"github.com/woliveiras/corsarr/internal/generator"
 	"github.com/woliveiras/corsarr/internal/i18n"
+	"github.com/woliveiras/corsarr/internal/localnetwork"
 	"github.com/woliveiras/corsarr/internal/profile"
 	"github.com/woliveiras/corsarr/internal/prompts"
 	"github.com/woliveiras/corsarr/internal/services"
@@ -25,6 +27,23 @@ var (
 	dryRun          bool
 	saveProfile     bool
 	saveProfileName string
+	outputFormat    string
+	proxyRouting    string
+	proxyDomain     string
+	publishPorts    bool
+	portFlags       []string
+	// hostPortOverrides merges profile ports with --port flags
+	hostPortOverrides services.PortOverrides
+	hwaccelFlag       string
+	// hwaccel is the hardware transcoding backend from --hwaccel, the profile or the prompt
+	hwaccel services.HWAccel
+	// resourceSettings holds the profile memory, CPU and logging settings
+	resourceSettings *services.ResourceSettings
+	// secretsMode writes VPN credentials under secrets/ instead of .env
+	secretsMode   bool
+	vpnRouteFlags []string
+	// vpnRouting merges profile VPN routes with --vpn-route flags
+	vpnRouting services.VPNRoutes
 	// Non-interactive mode flags
 	servicesList string
 	configFile   string
@@ -38,6 +57,16 @@ var (
 	vpnType      string
 	vpnUser      string
 	vpnPassword  string
+	// vpnSettings holds the provider specific Gluetun variables of the
+	// --vpn-* flags, keyed by variable name
+	vpnSettings       = make(map[string]*string)
+	vpnPortForwarding bool
+	// outboundSubnets is the LAN subnet setting of --vpn-outbound-subnets or
+	// the profile; outboundSubnetsDetected tells whether the subnets written
+	// to .env were detected on this machine
+	outboundSubnetsFlag     string
+	outboundSubnets         string
+	outboundSubnetsDetected bool
 )
 
 // generateCmd represents the generate command
@@ -74,6 +103,13 @@ func init() {
 	generateCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show what would be generated without creating files")
 	generateCmd.Flags().BoolVar(&saveProfile, "save-profile", false, "Save configuration as a profile after generation")
 	generateCmd.Flags().StringVar(&saveProfileName, "save-as", "", "Profile name when using --save-profile")
+	generateCmd.Flags().StringVar(&outputFormat, "format", generator.FormatCompose, "Output format: compose, k8s (Kubernetes manifests) or quadlet (Podman systemd units)")
+	generateCmd.Flags().StringVar(&proxyRouting, "proxy-routing", generator.ProxyRoutingHost, "Reverse proxy routes: host (sonarr.<domain>) or path (/sonarr)")
+	generateCmd.Flags().StringVar(&proxyDomain, "proxy-domain", "local", "Domain used by host-based reverse proxy routes")
+	generateCmd.Flags().StringArrayVar(&portFlags, "port", nil, "Override a host port: service=port or service:container=port (repeatable)")
+	generateCmd.Flags().BoolVar(&publishPorts, "publish-ports", false, "Keep publishing web interface ports when a reverse proxy is selected")
+	generateCmd.Flags().BoolVar(&secretsMode, "secrets", false, "Write VPN credentials to files under secrets/ and mount them as Compose secrets instead of writing them to .env")
+	generateCmd.Flags().StringVar(&hwaccelFlag, "hwaccel", "", "Hardware transcoding for Jellyfin and FileFlows: vaapi, qsv, nvidia or none")
 
 	// Non-interactive mode configuration
 	generateCmd.Flags().StringVar(&configFile, "config", "", "Load configuration from YAML/JSON file")
@@ -86,16 +122,69 @@ func init() {
 	generateCmd.Flags().StringVar(&projectName, "project-name", "corsarr", "Docker Compose project name")
 
 	// VPN configuration for non-interactive mode
-	generateCmd.Flags().StringVar(&vpnProvider, "vpn-provider", "", "VPN provider (nordvpn, protonvpn, etc.)")
-	generateCmd.Flags().StringVar(&vpnType, "vpn-type", "wireguard", "VPN type (wireguard or openvpn)")
-	generateCmd.Flags().StringVar(&vpnUser, "vpn-user", "", "VPN username (for OpenVPN)")
-	generateCmd.Flags().StringVar(&vpnPassword, "vpn-password", "", "VPN password or WireGuard private key")
+	addVPNFlags(generateCmd)
+}
+
+// vpnSettingFlags maps the provider specific --vpn-* flags onto the Gluetun
+// variables they set
+var vpnSettingFlags = []struct {
+	flag     string
+	variable string
+	usage    string
+}{
+	{"vpn-addresses", "WIREGUARD_ADDRESSES", "WireGuard interface addresses (e.g., 10.64.222.21/32)"},
+	{"vpn-public-key", "WIREGUARD_PUBLIC_KEY", "WireGuard server public key (custom provider)"},
+	{"vpn-preshared-key", "WIREGUARD_PRESHARED_KEY", "WireGuard preshared key (AirVPN)"},
+	{"vpn-endpoint-ip", "WIREGUARD_ENDPOINT_IP", "WireGuard server IP address (custom provider)"},
+	{"vpn-endpoint-port", "WIREGUARD_ENDPOINT_PORT", "WireGuard server port (custom provider)"},
+	{"vpn-custom-config", "OPENVPN_CUSTOM_CONFIG", "Path of the .ovpn file inside the Gluetun container (custom provider)"},
+	{"vpn-countries", "SERVER_COUNTRIES", "Comma-separated server countries"},
+	{"vpn-cities", "SERVER_CITIES", "Comma-separated server cities"},
+	{"vpn-hostnames", "SERVER_HOSTNAMES", "Comma-separated server hostnames"},
+	{"vpn-regions", "SERVER_REGIONS", "Comma-separated server regions (Private Internet Access)"},
+}
+
+// addVPNFlags registers the flags that configure Gluetun without prompts
+func addVPNFlags(cmd *cobra.Command) {
+	flags := cmd.Flags()
+	flags.StringVar(&vpnProvider, "vpn-provider", "", "VPN provider: "+strings.Join(vpnProviderIDs(), ", ")+" or another Gluetun provider")
+	flags.StringVar(&vpnType, "vpn-type", "wireguard", "VPN type (wireguard or openvpn)")
+	flags.StringVar(&vpnUser, "vpn-user", "", "VPN username (for OpenVPN)")
+	flags.StringVar(&vpnPassword, "vpn-password", "", "VPN password or WireGuard private key")
+	for _, setting := range vpnSettingFlags {
+		if vpnSettings[setting.variable] == nil {
+			vpnSettings[setting.variable] = new(string)
+		}
+		flags.StringVar(vpnSettings[setting.variable], setting.flag, "", setting.usage)
+	}
+	flags.BoolVar(&vpnPortForwarding, "vpn-port-forwarding", false, "Ask the VPN provider for a forwarded port (ProtonVPN, Private Internet Access)")
+	flags.StringVar(&outboundSubnetsFlag, "vpn-outbound-subnets", "", "LAN subnets reachable outside the tunnel: comma-separated CIDRs, auto (detect them, the default) or none")
+	flags.StringArrayVar(&vpnRouteFlags, "vpn-route", nil, "Route a service or category around the tunnel in VPN mode: name=bridge or name=tunnel, * for the rest (repeatable)")
+}
+
+// vpnProviderIDs lists the providers of the catalog
+func vpnProviderIDs() []string {
+	var ids []string
+	for _, provider := range generator.VPNProviders() {
+		ids = append(ids, strconv.Quote(provider.ID))
+	}
+	return ids
 }
 
 func runGenerate(t *i18n.I18n) error {
 	var loadedProfile *profile.Profile
 	var err error
 
+	switch outputFormat {
+	case generator.FormatCompose, generator.FormatKubernetes, generator.FormatQuadlet:
+	default:
+		return fmt.Errorf("unsupported output format %q (use %s, %s or %s)", outputFormat,
+			generator.FormatCompose, generator.FormatKubernetes, generator.FormatQuadlet)
+	}
+	if err := generator.ValidateProxyRouting(proxyRouting); err != nil {
+		return err
+	}
+
 	// Step 0a: Load from config file if specified
 	if configFile != "" {
 		fmt.Println(t.T("logs.loading_configuration", map[string]interface{}{"source": configFile}))
@@ -117,7 +206,7 @@ func runGenerate(t *i18n.I18n) error {
 	// Step 0b: Load profile if specified (overrides config file)
 	if profileName != "" {
 		fmt.Println(t.T("logs.loading_profile", map[string]interface{}{"profile": profileName}))
-		loadedProfile, err = profile.LoadProfile(profileName)
+		loadedProfile, err = profile.ResolveProfile(profileName)
 		if err != nil {
 			return fmt.Errorf("failed to load profile: %w", err)
 		}
@@ -143,11 +232,26 @@ func runGenerate(t *i18n.I18n) error {
 	}
 
 	// Step 1: Initialize service registry
-	registry, err := services.NewRegistry()
+	registry, err := newServiceRegistry()
 	if err != nil {
 		return fmt.Errorf("failed to create registry: %w", err)
 	}
 
+	// Step 1.5: Resolve host port overrides and VPN routes (flags take
+	// precedence over the profile)
+	hostPortOverrides, err = resolvePortOverrides(registry, loadedProfile)
+	if err != nil {
+		return err
+	}
+	vpnRouting, err = resolveVPNRoutes(registry, loadedProfile)
+	if err != nil {
+		return err
+	}
+	resourceSettings, err = resolveResources(registry, loadedProfile)
+	if err != nil {
+		return err
+	}
+
 	// Step 2: Determine VPN setting
 	vpnEnabled := useVPN
 	if loadedProfile != nil {
@@ -193,60 +297,23 @@ func runGenerate(t *i18n.I18n) error {
 
 	fmt.Printf("\n✅ %d %s\n\n", len(selectedIDs), t.T("messages.services_selected"))
 
+	// Step 3.5: Hardware transcoding (the flag takes precedence over the profile)
+	hwaccel, err = resolveHWAccel(t, registry, selectedIDs, loadedProfile)
+	if err != nil {
+		return err
+	}
+
 	// Step 4: Configure environment
 	var envConfig *generator.EnvConfig
 	if loadedProfile != nil && len(loadedProfile.Environment) > 0 {
-		// Use environment from profile
-		envConfig = &generator.EnvConfig{
-			ComposeProjectName: loadedProfile.Environment["COMPOSE_PROJECT_NAME"],
-			ARRPath:            loadedProfile.Environment["ARRPATH"],
-			Timezone:           loadedProfile.Environment["TZ"],
-			PUID:               loadedProfile.Environment["PUID"],
-			PGID:               loadedProfile.Environment["PGID"],
-			UMASK:              loadedProfile.Environment["UMASK"],
-		}
-
-		// Apply VPN config if present
-		if vpnEnabled && loadedProfile.VPN.Enabled {
-			envConfig.VPNConfig = &generator.VPNConfig{
-				ServiceProvider:     loadedProfile.VPN.Provider,
-				Type:                "wireguard",
-				WireguardPrivateKey: loadedProfile.VPN.Password,
-				WireguardAddresses:  "",
-				WireguardPublicKey:  "",
-				PortForwarding:      "off",
-				DNSAddress:          "1.1.1.1",
-			}
-		}
-
+		envConfig = envConfigFromProfile(loadedProfile, vpnEnabled)
 		fmt.Println(t.T("logs.environment_from_profile"))
 	} else if noInteractive {
 		// Non-interactive: use flags
-		envConfig = &generator.EnvConfig{
-			ComposeProjectName: projectName,
-			ARRPath:            arrPath,
-			Timezone:           timezone,
-			PUID:               puid,
-			PGID:               pgid,
-			UMASK:              umask,
-		}
-
-		// VPN config from flags
-		if vpnEnabled {
-			if vpnProvider == "" {
-				return fmt.Errorf("non-interactive VPN mode requires --vpn-provider")
-			}
-			envConfig.VPNConfig = &generator.VPNConfig{
-				ServiceProvider:     vpnProvider,
-				Type:                vpnType,
-				WireguardPrivateKey: vpnPassword,
-				WireguardAddresses:  "",
-				WireguardPublicKey:  "",
-				PortForwarding:      "off",
-				DNSAddress:          "1.1.1.1",
-			}
+		envConfig, err = envConfigFromFlags(vpnEnabled)
+		if err != nil {
+			return err
 		}
-
 		fmt.Println(t.T("logs.environment_from_flags"))
 	} else {
 		envConfig, err = prompts.ConfigureEnvironment(t, vpnEnabled)
@@ -272,6 +339,20 @@ func runGenerate(t *i18n.I18n) error {
 		}
 	}
 
+	// Step 4.6: Secrets mode (the flag or the profile)
+	envConfig.SecretsMode = secretsMode || (loadedProfile != nil && loadedProfile.Secrets)
+	if envConfig.SecretsMode && outputFormat == generator.FormatKubernetes {
+		return fmt.Errorf("--secrets is not supported with --format %s; the manifests already keep VPN credentials in a Secret", generator.FormatKubernetes)
+	}
+	if vpnEnabled && envConfig.VPNConfig != nil {
+		if err := applyOutboundSubnets(envConfig, loadedProfile); err != nil {
+			return err
+		}
+		if err := envConfig.VPNConfig.Validate(envConfig.SecretsMode); err != nil {
+			return err
+		}
+	}
+
 	// Add Gluetun to services if VPN is enabled
 	if vpnEnabled {
 		hasGluetun := false
@@ -352,12 +433,22 @@ func validateConfiguration(registry *services.Registry, serviceIDs []string, bas
 		result.AddError("config", fmt.Sprintf("Failed to create validation config: %v", err), validator.SeverityCritical)
 		return result
 	}
+	// Kubernetes manifests and Quadlet units do not run on the local Docker engine
+	config.SkipDockerCheck = outputFormat != generator.FormatCompose
+	config.PortOverrides = hostPortOverrides
+	config.HWAccel = hwaccel
 
 	result := validator.ValidateAll(config)
 
 	if err := generator.ValidateNetworkConfiguration(config.Services, vpnEnabled); err != nil {
 		result.AddError("network", err.Error(), validator.SeverityError)
 	}
+	if proxyRouting == generator.ProxyRoutingPath {
+		if ids := generator.HostRoutedServices(config.Services); len(ids) > 0 {
+			result.AddError("proxy_routing", fmt.Sprintf("%s cannot be served below a path; the Caddyfile routes them by host name instead",
+				strings.Join(ids, ", ")), validator.SeverityWarning)
+		}
+	}
 
 	return result
 }
@@ -389,8 +480,57 @@ func previewGeneration(t *i18n.I18n, registry *services.Registry, selectedIDs []
 	fmt.Println(t.T("logs.preview_dry_run_header"))
 	fmt.Println("═══════════════════════════════════════════════════════")
 
+	if err := printResourceSummary(t, registry, selectedIDs, envConfig, vpnEnabled); err != nil {
+		return fmt.Errorf("resource preview failed: %w", err)
+	}
+	if vpnEnabled {
+		if err := printVPNRouteSummary(t, registry, selectedIDs, envConfig); err != nil {
+			return fmt.Errorf("VPN route preview failed: %w", err)
+		}
+		printOutboundSubnets(t, envConfig)
+	}
+
+	if outputFormat == generator.FormatKubernetes {
+		manifestGen := generator.NewKubernetesGenerator(registry, outputDir)
+		manifestGen.SetOptions(composeOptions(envConfig))
+		manifestPreview, err := manifestGen.Preview(selectedIDs, vpnEnabled, envConfig)
+		if err != nil {
+			return fmt.Errorf("kubernetes preview failed: %w", err)
+		}
+
+		fmt.Println()
+		fmt.Println(t.T("logs.preview_kubernetes_title"))
+		fmt.Println("───────────────────────────────────────────────────────")
+		fmt.Println(manifestPreview)
+		fmt.Println("───────────────────────────────────────────────────────")
+
+		fmt.Println()
+		fmt.Println(t.T("logs.preview_complete"))
+		return nil
+	}
+
+	if outputFormat == generator.FormatQuadlet {
+		quadletGen := generator.NewQuadletGenerator(registry, outputDir)
+		quadletGen.SetOptions(composeOptions(envConfig))
+		quadletPreview, err := quadletGen.Preview(selectedIDs, vpnEnabled, envConfig)
+		if err != nil {
+			return fmt.Errorf("quadlet preview failed: %w", err)
+		}
+
+		fmt.Println()
+		fmt.Println(t.T("logs.preview_quadlet_title"))
+		fmt.Println("───────────────────────────────────────────────────────")
+		fmt.Println(quadletPreview)
+		fmt.Println("───────────────────────────────────────────────────────")
+
+		fmt.Println()
+		fmt.Println(t.T("logs.preview_complete"))
+		return nil
+	}
+
 	// Preview docker-compose.yml
 	composeGen := generator.NewComposeGenerator(registry, outputDir)
+	composeGen.SetOptions(composeOptions(envConfig))
 
 	composePreview, err := composeGen.Preview(selectedIDs, vpnEnabled)
 	if err != nil {
@@ -403,6 +543,19 @@ func previewGeneration(t *i18n.I18n, registry *services.Registry, selectedIDs []
 	fmt.Println(composePreview)
 	fmt.Println("───────────────────────────────────────────────────────")
 
+	// Preview Caddyfile when a reverse proxy is selected
+	proxyPreview, err := composeGen.ProxyConfig(selectedIDs, vpnEnabled)
+	if err != nil {
+		return fmt.Errorf("proxy preview failed: %w", err)
+	}
+	if proxyPreview != "" {
+		fmt.Println()
+		fmt.Println(t.T("logs.preview_proxy_title"))
+		fmt.Println("───────────────────────────────────────────────────────")
+		fmt.Println(proxyPreview)
+		fmt.Println("───────────────────────────────────────────────────────")
+	}
+
 	// Preview .env
 	envGen := generator.NewEnvGenerator(outputDir)
 	envPreview, err := envGen.Preview(envConfig)
@@ -436,8 +589,16 @@ func generateFiles(t *i18n.I18n, registry *services.Registry, selectedIDs []stri
 	fmt.Println(t.T("logs.generating_files"))
 	fmt.Println("═══════════════════════════════════════════════════════")
 
+	switch outputFormat {
+	case generator.FormatKubernetes:
+		return generateKubernetesManifests(t, registry, selectedIDs, envConfig, vpnEnabled)
+	case generator.FormatQuadlet:
+		return generateQuadletUnits(t, registry, selectedIDs, envConfig, vpnEnabled)
+	}
+
 	// Generate docker-compose.yml
 	composeGen := generator.NewComposeGenerator(registry, outputDir)
+	composeGen.SetOptions(composeOptions(envConfig))
 
 	if vpnEnabled {
 		fmt.Println(t.T("logs.vpn_mode_status"))
@@ -450,6 +611,7 @@ func generateFiles(t *i18n.I18n, registry *services.Registry, selectedIDs []stri
 	}
 	composePath := filepath.Join(outputDir, "docker-compose.yml")
 	fmt.Println(t.T("messages.file_created", map[string]interface{}{"path": composePath}))
+	printProxyConfigCreated(t, registry, selectedIDs)
 
 	// Generate .env
 	envGen := generator.NewEnvGenerator(outputDir)
@@ -458,6 +620,7 @@ func generateFiles(t *i18n.I18n, registry *services.Registry, selectedIDs []stri
 	}
 	envPath := filepath.Join(outputDir, ".env")
 	fmt.Println(t.T("messages.file_created", map[string]interface{}{"path": envPath}))
+	printSecretsCreated(t, envConfig)
 
 	// Success message
 	fmt.Println("\n" + "═══════════════════════════════════════════════════════")
@@ -474,6 +637,320 @@ func generateFiles(t *i18n.I18n, registry *services.Registry, selectedIDs []stri
 	return nil
 }
 
+// generateKubernetesManifests writes kubernetes.yaml instead of the Compose
+// files; the .env values are carried by its ConfigMap and Secret.
+func generateKubernetesManifests(t *i18n.I18n, registry *services.Registry, selectedIDs []string, envConfig *generator.EnvConfig, vpnEnabled bool) error {
+	fmt.Println(t.T("logs.kubernetes_mode_status"))
+	if vpnEnabled {
+		fmt.Println(t.T("logs.vpn_mode_status"))
+	}
+
+	manifestGen := generator.NewKubernetesGenerator(registry, outputDir)
+	manifestGen.SetOptions(composeOptions(envConfig))
+	if err := manifestGen.Generate(selectedIDs, vpnEnabled, envConfig, true); err != nil {
+		return fmt.Errorf("failed to generate kubernetes.yaml: %w", err)
+	}
+	fmt.Println(t.T("messages.file_created", map[string]interface{}{"path": manifestGen.OutputPath()}))
+
+	fmt.Println("\n" + "═══════════════════════════════════════════════════════")
+	fmt.Println("🎉", t.T("messages.generation_complete"))
+	fmt.Println("═══════════════════════════════════════════════════════")
+	fmt.Println(t.T("logs.output_directory", map[string]interface{}{"directory": outputDir}))
+	fmt.Println()
+	fmt.Println(t.T("logs.next_steps"))
+	fmt.Println(t.T("logs.next_step_review_k8s"))
+	fmt.Println(t.T("logs.next_step_apply_k8s", map[string]interface{}{"path": manifestGen.OutputPath()}))
+	fmt.Println()
+
+	return nil
+}
+
+// generateQuadletUnits writes Podman Quadlet units and the .env they read
+func generateQuadletUnits(t *i18n.I18n, registry *services.Registry, selectedIDs []string, envConfig *generator.EnvConfig, vpnEnabled bool) error {
+	fmt.Println(t.T("logs.quadlet_mode_status"))
+	if vpnEnabled {
+		fmt.Println(t.T("logs.vpn_mode_status"))
+	}
+
+	quadletGen := generator.NewQuadletGenerator(registry, outputDir)
+	quadletGen.SetOptions(composeOptions(envConfig))
+	units, err := quadletGen.Generate(selectedIDs, vpnEnabled, envConfig, true)
+	if err != nil {
+		return fmt.Errorf("failed to generate Quadlet units: %w", err)
+	}
+
+	var serviceUnits []string
+	for _, unit := range units {
+		fmt.Println(t.T("messages.file_created", map[string]interface{}{"path": filepath.Join(outputDir, unit.Filename)}))
+		if name, ok := strings.CutSuffix(unit.Filename, ".container"); ok {
+			serviceUnits = append(serviceUnits, name)
+		}
+	}
+	fmt.Println(t.T("messages.file_created", map[string]interface{}{"path": filepath.Join(outputDir, ".env")}))
+	printSecretsCreated(t, envConfig)
+	printProxyConfigCreated(t, registry, selectedIDs)
+
+	fmt.Println("\n" + "═══════════════════════════════════════════════════════")
+	fmt.Println("🎉", t.T("messages.generation_complete"))
+	fmt.Println("═══════════════════════════════════════════════════════")
+	fmt.Println(t.T("logs.output_directory", map[string]interface{}{"directory": outputDir}))
+	fmt.Println()
+	fmt.Println(t.T("logs.next_steps"))
+	fmt.Println(t.T("logs.next_step_quadlet_location"))
+	for _, secret := range envConfig.Secrets() {
+		// Podman reads secrets from its own store rather than from files
+		fmt.Println(t.T("logs.next_step_quadlet_secret", map[string]interface{}{
+			"name": secret.Name,
+			"path": filepath.Join(outputDir, generator.SecretsDir, secret.Name),
+		}))
+	}
+	fmt.Println(t.T("logs.next_step_quadlet_start", map[string]interface{}{"units": strings.Join(serviceUnits, " ")}))
+	fmt.Println()
+
+	return nil
+}
+
+// composeOptions builds the rendering options from the generate flags and
+// the secrets of the environment configuration
+func composeOptions(envConfig *generator.EnvConfig) generator.ComposeOptions {
+	return generator.ComposeOptions{
+		ProxyRouting:    proxyRouting,
+		ProxyDomain:     proxyDomain,
+		PublishAppPorts: publishPorts,
+		PortOverrides:   hostPortOverrides,
+		VPNRoutes:       vpnRouting,
+		HWAccel:         hwaccel,
+		HWAccelGroups:   hwaccelGroups(),
+		Resources:       resourceSettings,
+		Secrets:         envConfig.SecretNames(),
+		VPNEnv:          envConfig.VPNVariableNames(),
+	}
+}
+
+// resolveResources validates the profile resource settings
+func resolveResources(registry *services.Registry, loadedProfile *profile.Profile) (*services.ResourceSettings, error) {
+	if loadedProfile == nil || loadedProfile.Resources == nil {
+		return nil, nil
+	}
+	if err := loadedProfile.Resources.Validate(registry); err != nil {
+		return nil, err
+	}
+	return loadedProfile.Resources, nil
+}
+
+// envConfigFromProfile builds the .env settings stored in a profile
+func envConfigFromProfile(p *profile.Profile, vpnEnabled bool) *generator.EnvConfig {
+	envConfig := &generator.EnvConfig{
+		ComposeProjectName: p.Environment["COMPOSE_PROJECT_NAME"],
+		ARRPath:            p.Environment["ARRPATH"],
+		Timezone:           p.Environment["TZ"],
+		PUID:               p.Environment["PUID"],
+		PGID:               p.Environment["PGID"],
+		UMASK:              p.Environment["UMASK"],
+	}
+
+	// Apply VPN config if present
+	if vpnEnabled && p.VPN.Enabled {
+		envConfig.VPNConfig = &generator.VPNConfig{
+			ServiceProvider:       p.VPN.Provider,
+			Type:                  valueOr(p.VPN.Type, "wireguard"),
+			WireguardPrivateKey:   p.VPN.WireguardPrivateKey,
+			WireguardPublicKey:    p.VPN.WireguardPublicKey,
+			WireguardPresharedKey: p.VPN.WireguardPresharedKey,
+			WireguardAddresses:    p.VPN.WireguardAddresses,
+			WireguardEndpointIP:   p.VPN.EndpointIP,
+			WireguardEndpointPort: p.VPN.EndpointPort,
+			OpenVPNUser:           p.VPN.Username,
+			OpenVPNPassword:       p.VPN.OpenVPNPassword,
+			OpenVPNCustomConfig:   p.VPN.OpenVPNCustomConfig,
+			ServerCountries:       p.VPN.Country,
+			ServerCities:          p.VPN.City,
+			ServerHostnames:       p.VPN.Hostnames,
+			ServerRegions:         p.VPN.Regions,
+			PortForwarding:        onOff(p.VPN.PortForwarding),
+			DNSAddress:            "1.1.1.1",
+		}
+	}
+	envConfig.SecretsMode = p.Secrets
+	return envConfig
+}
+
+// envConfigFromFlags builds the .env settings from the non-interactive flags
+func envConfigFromFlags(vpnEnabled bool) (*generator.EnvConfig, error) {
+	envConfig := &generator.EnvConfig{
+		ComposeProjectName: projectName,
+		ARRPath:            arrPath,
+		Timezone:           timezone,
+		PUID:               puid,
+		PGID:               pgid,
+		UMASK:              umask,
+	}
+
+	// VPN config from flags
+	if vpnEnabled {
+		if vpnProvider == "" {
+			return nil, fmt.Errorf("non-interactive VPN mode requires --vpn-provider")
+		}
+		envConfig.VPNConfig = &generator.VPNConfig{
+			ServiceProvider: vpnProvider,
+			Type:            vpnType,
+			PortForwarding:  onOff(vpnPortForwarding),
+			DNSAddress:      "1.1.1.1",
+		}
+		setVPNCredentials(envConfig.VPNConfig, vpnUser, vpnPassword)
+		for variable, value := range vpnSettings {
+			envConfig.VPNConfig.SetValue(variable, *value)
+		}
+	}
+	return envConfig, nil
+}
+
+// applyOutboundSubnets sets the LAN subnets Gluetun lets past the tunnel from
+// --vpn-outbound-subnets, the profile, or the interfaces of this machine
+func applyOutboundSubnets(envConfig *generator.EnvConfig, loadedProfile *profile.Profile) error {
+	outboundSubnets = outboundSubnetsFlag
+	if outboundSubnets == "" && loadedProfile != nil {
+		outboundSubnets = loadedProfile.VPN.OutboundSubnets
+	}
+	if envConfig.VPNConfig == nil {
+		return nil
+	}
+	subnets, detected, err := generator.ResolveOutboundSubnets(outboundSubnets, localnetwork.NewDiscoverer().IPv4Subnets)
+	if err != nil {
+		return err
+	}
+	envConfig.VPNConfig.FirewallOutboundSubnets = subnets
+	outboundSubnetsDetected = detected
+	return nil
+}
+
+// onOff renders a switch as the on/off value Gluetun expects
+func onOff(enabled bool) string {
+	if enabled {
+		return "on"
+	}
+	return "off"
+}
+
+// setVPNCredentials stores the password as the OpenVPN password or, for
+// WireGuard, as the private key
+func setVPNCredentials(config *generator.VPNConfig, user, password string) {
+	if config.Type == "openvpn" {
+		config.OpenVPNUser = user
+		config.OpenVPNPassword = password
+		return
+	}
+	config.WireguardPrivateKey = password
+}
+
+// hwaccelGroups returns the host groups owning the render devices, which
+// VA-API and Quick Sync containers need to open them
+func hwaccelGroups() []string {
+	if !hwaccel.UsesRenderDevices() {
+		return nil
+	}
+	return validator.RenderDeviceGroups()
+}
+
+// resolveHWAccel picks the hardware transcoding backend from --hwaccel, the
+// profile, or a prompt when a selected service can transcode
+func resolveHWAccel(t *i18n.I18n, registry *services.Registry, selectedIDs []string, loadedProfile *profile.Profile) (services.HWAccel, error) {
+	value := hwaccelFlag
+	if value == "" && loadedProfile != nil {
+		value = loadedProfile.HWAccel
+	}
+	if value != "" {
+		mode, err := services.ParseHWAccel(value)
+		if err != nil {
+			return "", err
+		}
+		if mode.Enabled() {
+			fmt.Println(t.T("logs.hwaccel_selected", map[string]interface{}{"mode": mode}))
+		}
+		return mode, nil
+	}
+
+	if noInteractive || dryRun || loadedProfile != nil {
+		return "", nil
+	}
+	for _, id := range selectedIDs {
+		if svc, err := registry.GetService(id); err == nil && svc.HardwareAcceleration {
+			mode, err := prompts.AskHWAccel(t)
+			if err != nil {
+				return "", fmt.Errorf("hardware acceleration selection failed: %w", err)
+			}
+			return mode, nil
+		}
+	}
+	return "", nil
+}
+
+// resolvePortOverrides merges the profile host ports with the --port flags
+// and checks them against the registry
+func resolvePortOverrides(registry *services.Registry, loadedProfile *profile.Profile) (services.PortOverrides, error) {
+	overrides := make(services.PortOverrides)
+	if loadedProfile != nil {
+		for key, port := range loadedProfile.Ports {
+			overrides[key] = port
+		}
+	}
+	flagOverrides, err := services.ParsePortOverrides(portFlags)
+	if err != nil {
+		return nil, err
+	}
+	for key, port := range flagOverrides {
+		overrides[key] = port
+	}
+	if err := overrides.Validate(registry); err != nil {
+		return nil, err
+	}
+	return overrides, nil
+}
+
+// resolveVPNRoutes merges the profile VPN routes with the --vpn-route flags
+// and checks them against the registry
+func resolveVPNRoutes(registry *services.Registry, loadedProfile *profile.Profile) (services.VPNRoutes, error) {
+	routes := make(services.VPNRoutes)
+	if loadedProfile != nil {
+		for key, route := range loadedProfile.VPNRoutes {
+			routes[key] = route
+		}
+	}
+	flagRoutes, err := services.ParseVPNRoutes(vpnRouteFlags)
+	if err != nil {
+		return nil, err
+	}
+	for key, route := range flagRoutes {
+		routes[key] = route
+	}
+	if err := routes.Validate(registry); err != nil {
+		return nil, err
+	}
+	return routes, nil
+}
+
+// printProxyConfigCreated reports the Caddyfile written for a selected reverse proxy
+func printProxyConfigCreated(t *i18n.I18n, registry *services.Registry, selectedIDs []string) {
+	for _, id := range selectedIDs {
+		if svc, err := registry.GetService(id); err == nil && svc.Category == services.CategoryProxy {
+			fmt.Println(t.T("messages.file_created", map[string]interface{}{"path": filepath.Join(outputDir, generator.ProxyConfigFile)}))
+			return
+		}
+	}
+}
+
+// printSecretsCreated lists the secret files written in secrets mode
+func printSecretsCreated(t *i18n.I18n, envConfig *generator.EnvConfig) {
+	for _, secret := range envConfig.Secrets() {
+		path := filepath.Join(outputDir, generator.SecretsDir, secret.Name)
+		if secret.Value == "" {
+			fmt.Println(t.T("logs.secret_kept", map[string]interface{}{"path": path}))
+			continue
+		}
+		fmt.Println(t.T("messages.file_created", map[string]interface{}{"path": path}))
+	}
+}
+
 // saveGeneratedProfile saves the current configuration as a profile
 func saveGeneratedProfile(t *i18n.I18n, selectedIDs []string, envConfig *generator.EnvConfig, vpnEnabled bool) error {
 	var name string
@@ -503,15 +980,50 @@ func saveGeneratedProfile(t *i18n.I18n, selectedIDs []string, envConfig *generat
 		}
 	}
 
+	// Overwriting an encrypted profile keeps it encrypted under its passphrase
+	var existing *profile.Profile
+	if profile.IsProfileEncrypted(name) {
+		var err error
+		if existing, err = profile.LoadProfile(name); err != nil {
+			return fmt.Errorf("failed to load profile: %w", err)
+		}
+	}
+
 	// Create profile
 	p := profile.NewProfile(name)
+	if existing != nil {
+		p.KeepEncryption(existing)
+	}
 	p.Services = selectedIDs
 	p.VPN.Enabled = vpnEnabled
 
 	if vpnEnabled && envConfig.VPNConfig != nil {
-		p.VPN.Provider = envConfig.VPNConfig.ServiceProvider
-		p.VPN.Password = envConfig.VPNConfig.WireguardPrivateKey
+		vpn := envConfig.VPNConfig
+		p.VPN.Provider = vpn.ServiceProvider
+		p.VPN.Type = vpn.Type
+		p.VPN.Username = vpn.OpenVPNUser
+		p.VPN.WireguardPublicKey = vpn.WireguardPublicKey
+		p.VPN.WireguardAddresses = vpn.WireguardAddresses
+		p.VPN.EndpointIP = vpn.WireguardEndpointIP
+		p.VPN.EndpointPort = vpn.WireguardEndpointPort
+		p.VPN.OpenVPNCustomConfig = vpn.OpenVPNCustomConfig
+		p.VPN.Country = vpn.ServerCountries
+		p.VPN.City = vpn.ServerCities
+		p.VPN.Hostnames = vpn.ServerHostnames
+		p.VPN.Regions = vpn.ServerRegions
+		p.VPN.PortForwarding = vpn.PortForwarding == "on"
+		// Detected subnets are detected again on the next run
+		if !outboundSubnetsDetected {
+			p.VPN.OutboundSubnets = valueOr(vpn.FirewallOutboundSubnets, generator.OutboundSubnetsNone)
+		}
+		// In secrets mode the credentials only live in the secrets/ files
+		if !envConfig.SecretsMode {
+			p.VPN.WireguardPrivateKey = vpn.WireguardPrivateKey
+			p.VPN.WireguardPresharedKey = vpn.WireguardPresharedKey
+			p.VPN.OpenVPNPassword = vpn.OpenVPNPassword
+		}
 	}
+	p.Secrets = envConfig.SecretsMode
 
 	// Save environment variables
 	p.Environment = map[string]string{
@@ -524,6 +1036,16 @@ func saveGeneratedProfile(t *i18n.I18n, selectedIDs []string, envConfig *generat
 	}
 
 	p.OutputDir = outputDir
+	if len(hostPortOverrides) > 0 {
+		p.Ports = hostPortOverrides
+	}
+	if vpnEnabled && len(vpnRouting) > 0 {
+		p.VPNRoutes = vpnRouting
+	}
+	if hwaccel != "" {
+		p.HWAccel = string(hwaccel)
+	}
+	p.Resources = resourceSettings
 
 	// Prompt for description
 	if saveProfileName == "" {
@@ -573,7 +1095,8 @@ func validateNonInteractiveMode(loadedProfile *profile.Profile) error {
 	if useVPN && vpnProvider == "" {
 		missing = append(missing, "--vpn-provider")
 	}
-	if useVPN && vpnPassword == "" {
+	// Secrets mode can reuse the files written by a previous run
+	if useVPN && vpnPassword == "" && !secretsMode {
 		missing = append(missing, "--vpn-password")
 	}
 
@@ -605,7 +1128,8 @@ func loadConfigFile(path string) (*profile.Profile, error) {
 		}
 	}
 
-	return &p, nil
+	// Config files can extend saved profiles too
+	return profile.Resolve(&p)
 }
 
 // createServiceDirectories creates all necessary directories for service volumes
@@ -620,6 +1144,11 @@ func createServiceDirectories(t *i18n.I18n, registry *services.Registry, service
 		}
 
 		for _, volume := range service.Volumes {
+			// Mounts outside ${ARRPATH}, like the generated Caddyfile, are not directories
+			if !strings.HasPrefix(volume.Host, "${ARRPATH}") {
+				continue
+			}
+
 			// Replace ${ARRPATH} with actual path
 			hostPath := strings.ReplaceAll(volume.Host, "${ARRPATH}", arrPath)
 
@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/woliveiras/corsarr/internal/generator"
+	"github.com/woliveiras/corsarr/internal/i18n"
+	"github.com/woliveiras/corsarr/internal/profile"
+	"gopkg.in/yaml.v3"
+)
+
+func TestSaveGeneratedProfile_KeepsEncryption(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv(profile.PassphraseEnv, "correct horse")
+
+	existing := profile.NewProfile("media")
+	existing.Services = []string{"sonarr"}
+	plaintext, err := yaml.Marshal(existing)
+	if err != nil {
+		t.Fatalf("marshal profile: %v", err)
+	}
+	sealed, err := profile.EncryptProfileData(plaintext, "correct horse")
+	if err != nil {
+		t.Fatalf("encrypt profile: %v", err)
+	}
+	profileDir, err := profile.GetProfileDir()
+	if err != nil {
+		t.Fatalf("profile directory: %v", err)
+	}
+	profilePath := filepath.Join(profileDir, "media.yaml")
+	if err := os.MkdirAll(profileDir, 0700); err != nil {
+		t.Fatalf("create profile directory: %v", err)
+	}
+	if err := os.WriteFile(profilePath, sealed, 0600); err != nil {
+		t.Fatalf("write profile: %v", err)
+	}
+
+	// Confirm the overwrite prompt
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("create stdin pipe: %v", err)
+	}
+	if _, err := writer.WriteString("y\n"); err != nil {
+		t.Fatalf("write stdin: %v", err)
+	}
+	writer.Close()
+	stdin := os.Stdin
+	os.Stdin = reader
+	saveProfileName = "media"
+	t.Cleanup(func() {
+		os.Stdin = stdin
+		saveProfileName = ""
+		reader.Close()
+	})
+
+	translator, err := i18n.New("en")
+	if err != nil {
+		t.Fatalf("load translations: %v", err)
+	}
+	if err := saveGeneratedProfile(translator, []string{"radarr"}, generator.NewDefaultEnvConfig(), false); err != nil {
+		t.Fatalf("save profile: %v", err)
+	}
+
+	data, err := os.ReadFile(profilePath)
+	if err != nil {
+		t.Fatalf("read profile: %v", err)
+	}
+	if !profile.IsEncrypted(data) {
+		t.Fatalf("Expected the overwritten profile to stay encrypted:\n%s", data)
+	}
+	saved, err := profile.LoadProfile("media")
+	if err != nil {
+		t.Fatalf("load profile: %v", err)
+	}
+	if !reflect.DeepEqual(saved.Services, []string{"radarr"}) {
+		t.Errorf("Expected the new services to be saved, got %v", saved.Services)
+	}
+}
@@ -0,0 +1,178 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/woliveiras/corsarr/internal/generator"
+	"github.com/woliveiras/corsarr/internal/i18n"
+	"github.com/woliveiras/corsarr/internal/profile"
+)
+
+var (
+	importComposeName    string
+	importComposeEnvFile string
+	importComposeForce   bool
+)
+
+// importComposeCmd represents the import-compose command
+var importComposeCmd = &cobra.Command{
+	Use:   "import-compose [file]",
+	Short: "Create a profile from an existing docker-compose.yml",
+	Long: `Read a docker-compose.yml written by hand or by another tool and save it as
+a Corsarr profile.
+
+Services are matched onto the Corsarr catalog by image and then by name.
+ARRPATH, TZ, PUID, PGID, UMASK and the VPN settings are read from the .env
+next to the file and from the service environments, VPN mode is detected from
+"network_mode: service:gluetun", and changed host ports become port overrides.
+Services that match nothing are reported and left out of the profile.
+
+Example:
+  corsarr import-compose /srv/stack/docker-compose.yml
+  corsarr import-compose docker-compose.yml --name home --env stack.env`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		t := GetTranslator()
+
+		if err := runImportCompose(t, args[0]); err != nil {
+			fmt.Fprintf(os.Stderr, "❌ %s: %v\n", t.T("import_compose.failed"), err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(importComposeCmd)
+
+	importComposeCmd.Flags().StringVarP(&importComposeName, "name", "n", "", "Profile name (defaults to the name of the compose file directory)")
+	importComposeCmd.Flags().StringVar(&importComposeEnvFile, "env", "", "Environment file (defaults to .env next to the compose file)")
+	importComposeCmd.Flags().BoolVarP(&importComposeForce, "force", "f", false, "Overwrite existing profile")
+}
+
+// runImportCompose maps a compose file onto a profile and saves it
+func runImportCompose(t *i18n.I18n, composePath string) error {
+	absPath, err := filepath.Abs(composePath)
+	if err != nil {
+		return err
+	}
+	content, err := os.ReadFile(absPath)
+	if err != nil {
+		return fmt.Errorf("%s: %w", t.T("errors.failed_to_parse_compose"), err)
+	}
+
+	envPath := importComposeEnvFile
+	if envPath == "" {
+		envPath = filepath.Join(filepath.Dir(absPath), ".env")
+	}
+	env := make(map[string]string)
+	envContent, err := os.ReadFile(envPath)
+	switch {
+	case err == nil:
+		env = generator.ParseEnvFile(string(envContent))
+	case errors.Is(err, os.ErrNotExist) && importComposeEnvFile == "":
+		fmt.Println(t.T("import_compose.env_missing", map[string]interface{}{"path": envPath}))
+	default:
+		return fmt.Errorf("failed to read %s: %w", envPath, err)
+	}
+
+	name := importComposeName
+	if name == "" {
+		name = filepath.Base(filepath.Dir(absPath))
+	}
+	if profile.ProfileExists(name) && !importComposeForce {
+		return fmt.Errorf("%s", t.T("profile.already_exists"))
+	}
+
+	registry, err := newServiceRegistry()
+	if err != nil {
+		return fmt.Errorf("failed to create registry: %w", err)
+	}
+
+	fmt.Println(t.T("import_compose.reading", map[string]interface{}{"path": absPath}))
+	result, err := profile.ImportCompose(registry, name, content, env)
+	if err != nil {
+		return err
+	}
+	if len(result.Matches) == 0 {
+		return fmt.Errorf("%s", t.T("import_compose.nothing_mapped"))
+	}
+
+	printComposeImport(t, result)
+
+	p := result.Profile
+	p.Description = t.T("import_compose.profile_description", map[string]interface{}{"path": absPath})
+	p.OutputDir = filepath.Dir(absPath)
+	if err := profile.SaveProfile(p); err != nil {
+		return fmt.Errorf("%s: %w", t.T("profile.save_failed"), err)
+	}
+
+	fmt.Println()
+	fmt.Println(t.T("import_compose.saved", map[string]interface{}{"profile": p.Name}))
+	fmt.Println(t.T("import_compose.next_step", map[string]interface{}{"profile": p.Name}))
+	return nil
+}
+
+// printComposeImport lists the mapped and unmapped services and the settings
+// carried over
+func printComposeImport(t *i18n.I18n, result *profile.ComposeImport) {
+	fmt.Println()
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	_, _ = fmt.Fprintf(w, "%s\t%s\t%s\n",
+		t.T("import_compose.compose_service"),
+		t.T("import_compose.corsarr_service"),
+		t.T("import_compose.matched_by"))
+	_, _ = fmt.Fprintf(w, "%s\t%s\t%s\n",
+		strings.Repeat("-", 15),
+		strings.Repeat("-", 15),
+		strings.Repeat("-", 10))
+	for _, match := range result.Matches {
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\n", match.ComposeService, match.ServiceID, match.MatchedBy)
+	}
+	_ = w.Flush()
+
+	if len(result.Unmapped) > 0 {
+		fmt.Println()
+		fmt.Println(t.T("import_compose.unmapped_title"))
+		for _, unmapped := range result.Unmapped {
+			if unmapped.DuplicateOf != "" {
+				fmt.Printf("   • %s\n", t.T("import_compose.unmapped_duplicate", map[string]interface{}{
+					"service":   unmapped.ComposeService,
+					"duplicate": unmapped.DuplicateOf,
+				}))
+				continue
+			}
+			fmt.Printf("   • %s (%s)\n", unmapped.ComposeService, valueOr(unmapped.Image, "-"))
+		}
+		fmt.Println(t.T("import_compose.unmapped_hint"))
+	}
+
+	p := result.Profile
+	fmt.Println()
+	if p.VPN.Enabled {
+		fmt.Println(t.T("import_compose.vpn_detected", map[string]interface{}{"provider": valueOr(p.VPN.Provider, "-")}))
+	}
+	if len(p.VPNRoutes) > 0 {
+		bridged := make([]string, 0, len(p.VPNRoutes))
+		for id := range p.VPNRoutes {
+			bridged = append(bridged, id)
+		}
+		sort.Strings(bridged)
+		fmt.Println(t.T("import_compose.vpn_routes_kept", map[string]interface{}{"services": strings.Join(bridged, ", ")}))
+	}
+	if len(p.Ports) > 0 {
+		fmt.Println(t.T("import_compose.ports_kept", map[string]interface{}{"count": len(p.Ports)}))
+	}
+	if len(result.Defaults) > 0 {
+		fmt.Println(t.T("import_compose.defaults_used", map[string]interface{}{"keys": strings.Join(result.Defaults, ", ")}))
+	}
+	if p.HasSecrets() {
+		fmt.Println(t.T("import_compose.secrets_stored"))
+	}
+}
@@ -2,8 +2,15 @@ package cmd
 
 import (
 	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
 
 	"github.com/spf13/cobra"
+	"github.com/woliveiras/corsarr/internal/generator"
+	"github.com/woliveiras/corsarr/internal/i18n"
+	"github.com/woliveiras/corsarr/internal/profile"
+	"github.com/woliveiras/corsarr/internal/services"
 )
 
 // previewCmd represents the preview command
@@ -16,12 +23,14 @@ based on your current configuration or a saved profile.
 This is useful to verify your configuration before actually generating the files.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		t := GetTranslator()
-		
+
 		fmt.Println(t.T("commands.preview.long"))
 		fmt.Println()
-		
-		// TODO: Implement preview logic
-		fmt.Println("⚠️  Preview logic not yet implemented")
+
+		if err := runPreview(t); err != nil {
+			fmt.Fprintf(os.Stderr, "❌ %s: %v\n", t.T("errors.preview_failed"), err)
+			os.Exit(1)
+		}
 	},
 }
 
@@ -31,3 +40,176 @@ func init() {
 	// Flags for preview command
 	previewCmd.Flags().StringVarP(&profileName, "profile", "p", "", "Preview using a saved profile")
 }
+
+// runPreview renders the files a saved profile would generate
+func runPreview(t *i18n.I18n) error {
+	if profileName == "" {
+		return fmt.Errorf("%s", t.T("errors.preview_requires_profile"))
+	}
+
+	loadedProfile, err := profile.ResolveProfile(profileName)
+	if err != nil {
+		return fmt.Errorf("failed to load profile: %w", err)
+	}
+	fmt.Println(t.T("logs.profile_loaded", map[string]interface{}{"profile": loadedProfile.Name}))
+	if loadedProfile.OutputDir != "" {
+		outputDir = loadedProfile.OutputDir
+	}
+
+	registry, err := newServiceRegistry()
+	if err != nil {
+		return fmt.Errorf("failed to create registry: %w", err)
+	}
+	hostPortOverrides, err = resolvePortOverrides(registry, loadedProfile)
+	if err != nil {
+		return err
+	}
+	vpnRouting, err = resolveVPNRoutes(registry, loadedProfile)
+	if err != nil {
+		return err
+	}
+	resourceSettings, err = resolveResources(registry, loadedProfile)
+	if err != nil {
+		return err
+	}
+	hwaccel, err = services.ParseHWAccel(loadedProfile.HWAccel)
+	if err != nil {
+		return err
+	}
+
+	selectedIDs := dedupeServiceIDs(loadedProfile.Services)
+	if len(selectedIDs) == 0 {
+		return fmt.Errorf("%s", t.T("errors.no_services_selected"))
+	}
+	vpnEnabled := loadedProfile.VPN.Enabled
+
+	envConfig := generator.NewDefaultEnvConfig()
+	if len(loadedProfile.Environment) > 0 {
+		envConfig = envConfigFromProfile(loadedProfile, vpnEnabled)
+	}
+	if vpnEnabled {
+		if err := applyOutboundSubnets(envConfig, loadedProfile); err != nil {
+			return err
+		}
+	}
+
+	return previewGeneration(t, registry, selectedIDs, envConfig, vpnEnabled)
+}
+
+// printResourceSummary lists the memory, CPU and logging settings that each
+// rendered service receives
+func printResourceSummary(t *i18n.I18n, registry *services.Registry, selectedIDs []string, envConfig *generator.EnvConfig, vpnEnabled bool) error {
+	composeGen := generator.NewComposeGenerator(registry, outputDir)
+	composeGen.SetOptions(composeOptions(envConfig))
+	selected, err := composeGen.SelectedServices(selectedIDs, vpnEnabled)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println()
+	fmt.Println(t.T("logs.preview_resources_title"))
+	fmt.Println("───────────────────────────────────────────────────────")
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\n",
+		t.T("resources.service"),
+		t.T("resources.memory"),
+		t.T("resources.cpus"),
+		t.T("resources.logging"))
+	_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\n",
+		strings.Repeat("-", 15),
+		strings.Repeat("-", 8),
+		strings.Repeat("-", 6),
+		strings.Repeat("-", 25))
+
+	unset := t.T("resources.unset")
+	for _, svc := range selected {
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\n",
+			svc.ContainerName,
+			valueOr(svc.Resources.MemLimit, unset),
+			valueOr(svc.Resources.CPUs, unset),
+			describeLogging(svc.Resources.Logging, unset))
+	}
+	return w.Flush()
+}
+
+// printVPNRouteSummary lists which services share Gluetun's network and the
+// address other services use to reach each of them
+func printVPNRouteSummary(t *i18n.I18n, registry *services.Registry, selectedIDs []string, envConfig *generator.EnvConfig) error {
+	composeGen := generator.NewComposeGenerator(registry, outputDir)
+	composeGen.SetOptions(composeOptions(envConfig))
+	selected, err := composeGen.SelectedServices(selectedIDs, true)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println()
+	fmt.Println(t.T("logs.preview_vpn_routes_title"))
+	fmt.Println("───────────────────────────────────────────────────────")
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\n",
+		t.T("vpn.service"),
+		t.T("vpn.route"),
+		t.T("vpn.from_tunnel"),
+		t.T("vpn.from_bridge"))
+	_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\n",
+		strings.Repeat("-", 15),
+		strings.Repeat("-", 10),
+		strings.Repeat("-", 20),
+		strings.Repeat("-", 20))
+	for _, address := range generator.ServiceAddresses(selected) {
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\n",
+			address.ServiceID,
+			t.T("vpn.routing_"+address.Route),
+			address.Tunneled,
+			address.Bridged)
+	}
+	return w.Flush()
+}
+
+// printOutboundSubnets lists the LAN subnets the services behind Gluetun
+// reach outside the tunnel
+func printOutboundSubnets(t *i18n.I18n, envConfig *generator.EnvConfig) {
+	if envConfig.VPNConfig == nil {
+		return
+	}
+	fmt.Println()
+	subnets := envConfig.VPNConfig.FirewallOutboundSubnets
+	if subnets == "" {
+		fmt.Println(t.T("vpn.outbound_subnets_none"))
+		return
+	}
+	key := "vpn.outbound_subnets_configured"
+	if outboundSubnetsDetected {
+		key = "vpn.outbound_subnets_detected"
+	}
+	fmt.Println(t.T(key))
+	for _, subnet := range strings.Split(subnets, ",") {
+		fmt.Printf("   • %s\n", subnet)
+	}
+}
+
+// describeLogging summarizes a log configuration as "json-file (10m x 3)"
+func describeLogging(logging *services.LoggingConfig, unset string) string {
+	if logging == nil || *logging == (services.LoggingConfig{}) {
+		return unset
+	}
+	description := valueOr(logging.Driver, "json-file")
+	var rotation []string
+	if logging.MaxSize != "" {
+		rotation = append(rotation, logging.MaxSize)
+	}
+	if logging.MaxFile != "" {
+		rotation = append(rotation, logging.MaxFile)
+	}
+	if len(rotation) > 0 {
+		description += " (" + strings.Join(rotation, " x ") + ")"
+	}
+	return description
+}
+
+func valueOr(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
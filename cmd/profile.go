@@ -9,14 +9,17 @@ import (
 	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/woliveiras/corsarr/internal/i18n"
 	"github.com/woliveiras/corsarr/internal/profile"
+	"github.com/woliveiras/corsarr/internal/prompts"
+	"gopkg.in/yaml.v3"
 )
 
 // profileCmd represents the profile command
 var profileCmd = &cobra.Command{
 	Use:   "profile",
 	Short: "Manage configuration profiles",
-	Long:  "Save, load, list, delete, export and import configuration profiles",
+	Long:  "Save, load, show, list, delete, export and import configuration profiles",
 }
 
 var profileSaveCmd = &cobra.Command{
@@ -85,6 +88,33 @@ var profileLoadCmd = &cobra.Command{
 	},
 }
 
+var profileShowCmd = &cobra.Command{
+	Use:   "show [name]",
+	Short: "Show a profile as YAML",
+	Long:  "Print a saved profile. With --resolved, print the effective profile used by generate after merging the profiles it extends",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		t := GetTranslator()
+		name := args[0]
+
+		load := profile.LoadProfile
+		if resolved, _ := cmd.Flags().GetBool("resolved"); resolved {
+			load = profile.ResolveProfile
+		}
+		p, err := load(name)
+		if err != nil {
+			return fmt.Errorf("%s: %w", t.T("profile.load_failed"), err)
+		}
+
+		data, err := yaml.Marshal(p.MaskSecrets(maskedSecret))
+		if err != nil {
+			return fmt.Errorf("failed to marshal profile: %w", err)
+		}
+		fmt.Print(string(data))
+		return nil
+	},
+}
+
 var profileListCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List all saved profiles",
@@ -120,6 +150,9 @@ var profileListCmd = &cobra.Command{
 			updatedAt := p.UpdatedAt.Format("2006-01-02 15:04")
 			servicesCount := fmt.Sprintf("%d", len(p.Services))
 			description := p.Description
+			if p.Encrypted {
+				updatedAt, servicesCount, description = "-", "-", t.T("profile.encrypted")
+			}
 			if len(description) > 30 {
 				description = description[:27] + "..."
 			}
@@ -165,7 +198,7 @@ var profileDeleteCmd = &cobra.Command{
 var profileExportCmd = &cobra.Command{
 	Use:   "export [name] [output-file]",
 	Short: "Export a profile to a file",
-	Long:  "Export a configuration profile to a JSON file for sharing or backup",
+	Long:  "Export a configuration profile to a JSON file for sharing or backup, optionally encrypted with a passphrase",
 	Args:  cobra.ExactArgs(2),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		t := GetTranslator()
@@ -177,12 +210,30 @@ var profileExportCmd = &cobra.Command{
 			outputPath += ".json"
 		}
 
-		if err := profile.ExportProfile(name, outputPath); err != nil {
+		// Load once: an encrypted profile asks for its passphrase on every load
+		p, err := profile.LoadProfile(name)
+		if err != nil {
+			return fmt.Errorf("%s: %w", t.T("profile.export_failed"), err)
+		}
+
+		includeSecrets, _ := cmd.Flags().GetBool("include-secrets")
+		options := profile.ExportOptions{IncludeSecrets: includeSecrets}
+		if encrypt, _ := cmd.Flags().GetBool("encrypt"); encrypt {
+			passphrase, err := newProfilePassphrase(t)
+			if err != nil {
+				return fmt.Errorf("%s: %w", t.T("profile.export_failed"), err)
+			}
+			options.Passphrase = passphrase
+		}
+		if err := p.Export(outputPath, options); err != nil {
 			return fmt.Errorf("%s: %w", t.T("profile.export_failed"), err)
 		}
 
 		absPath, _ := filepath.Abs(outputPath)
 		fmt.Printf("✅ %s: %s\n", t.T("profile.exported_successfully"), absPath)
+		if p.HasSecrets() && !includeSecrets {
+			fmt.Println(t.T("profile.export_secrets_omitted"))
+		}
 		return nil
 	},
 }
@@ -190,7 +241,7 @@ var profileExportCmd = &cobra.Command{
 var profileImportCmd = &cobra.Command{
 	Use:   "import [file]",
 	Short: "Import a profile from a file",
-	Long:  "Import a configuration profile from a JSON or YAML file",
+	Long:  "Import a configuration profile from a JSON or YAML file. Encrypted exports prompt for their passphrase and are stored encrypted.",
 	Args:  cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		t := GetTranslator()
@@ -219,16 +270,42 @@ var profileImportCmd = &cobra.Command{
 		}
 
 		fmt.Printf("✅ %s: %s\n", t.T("profile.imported_successfully"), p.Name)
+		if p.Encrypted() {
+			fmt.Println(t.T("profile.stored_encrypted"))
+		}
 		return nil
 	},
 }
 
+// maskedSecret replaces credentials in printed profiles
+const maskedSecret = "********"
+
+// profilePassphrase supplies the passphrase of an encrypted profile from the
+// environment or a prompt
+func profilePassphrase(source string) (string, error) {
+	if passphrase := os.Getenv(profile.PassphraseEnv); passphrase != "" {
+		return passphrase, nil
+	}
+	t := GetTranslator()
+	return prompts.AskPassphrase(t, t.T("prompts.passphrase_profile", map[string]interface{}{"profile": source}), false)
+}
+
+// newProfilePassphrase asks for the passphrase that encrypts an export
+func newProfilePassphrase(t *i18n.I18n) (string, error) {
+	if passphrase := os.Getenv(profile.PassphraseEnv); passphrase != "" {
+		return passphrase, nil
+	}
+	return prompts.AskPassphrase(t, t.T("prompts.passphrase_new"), true)
+}
+
 func init() {
 	rootCmd.AddCommand(profileCmd)
+	profile.SetPassphraseProvider(profilePassphrase)
 
 	// Add subcommands
 	profileCmd.AddCommand(profileSaveCmd)
 	profileCmd.AddCommand(profileLoadCmd)
+	profileCmd.AddCommand(profileShowCmd)
 	profileCmd.AddCommand(profileListCmd)
 	profileCmd.AddCommand(profileDeleteCmd)
 	profileCmd.AddCommand(profileExportCmd)
@@ -238,9 +315,16 @@ func init() {
 	profileSaveCmd.Flags().StringP("description", "d", "", "Profile description")
 	profileSaveCmd.Flags().BoolP("force", "f", false, "Overwrite existing profile")
 
+	// Flags for show command
+	profileShowCmd.Flags().Bool("resolved", false, "Merge the profiles listed in extends, as generate does")
+
 	// Flags for delete command
 	profileDeleteCmd.Flags().BoolP("force", "f", false, "Skip confirmation prompt")
 
+	// Flags for export command
+	profileExportCmd.Flags().Bool("include-secrets", false, "Include the VPN password or private key in the exported file")
+	profileExportCmd.Flags().Bool("encrypt", false, "Encrypt the exported file with a passphrase (or $"+profile.PassphraseEnv+")")
+
 	// Flags for import command
 	profileImportCmd.Flags().StringP("name", "n", "", "Override profile name")
 	profileImportCmd.Flags().BoolP("force", "f", false, "Overwrite existing profile")
@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/woliveiras/corsarr/internal/i18n"
+	"github.com/woliveiras/corsarr/internal/profile"
+	"gopkg.in/yaml.v3"
+)
+
+func TestProfileExport_AsksForThePassphraseOnce(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	existing := profile.NewProfile("media")
+	existing.Services = []string{"sonarr"}
+	plaintext, err := yaml.Marshal(existing)
+	if err != nil {
+		t.Fatalf("marshal profile: %v", err)
+	}
+	sealed, err := profile.EncryptProfileData(plaintext, "correct horse")
+	if err != nil {
+		t.Fatalf("encrypt profile: %v", err)
+	}
+	profileDir, err := profile.GetProfileDir()
+	if err != nil {
+		t.Fatalf("profile directory: %v", err)
+	}
+	if err := os.MkdirAll(profileDir, 0700); err != nil {
+		t.Fatalf("create profile directory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(profileDir, "media.yaml"), sealed, 0600); err != nil {
+		t.Fatalf("write profile: %v", err)
+	}
+
+	english, err := i18n.New("en")
+	if err != nil {
+		t.Fatalf("create translator: %v", err)
+	}
+	previous := translator
+	translator = english
+	t.Cleanup(func() { translator = previous })
+
+	requests := 0
+	profile.SetPassphraseProvider(func(string) (string, error) {
+		requests++
+		return "correct horse", nil
+	})
+	t.Cleanup(func() { profile.SetPassphraseProvider(profilePassphrase) })
+
+	exportPath := filepath.Join(t.TempDir(), "media.json")
+	if err := profileExportCmd.RunE(profileExportCmd, []string{"media", exportPath}); err != nil {
+		t.Fatalf("export profile: %v", err)
+	}
+	if requests != 1 {
+		t.Errorf("Expected one passphrase request, got %d", requests)
+	}
+	if _, err := os.Stat(exportPath); err != nil {
+		t.Errorf("Expected the export file: %v", err)
+	}
+}
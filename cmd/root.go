@@ -7,11 +7,15 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/woliveiras/corsarr/internal/buildinfo"
 	"github.com/woliveiras/corsarr/internal/i18n"
+	"github.com/woliveiras/corsarr/internal/services"
 )
 
 var (
 	translator *i18n.I18n
 	language   string
+	// User-defined service definitions
+	servicesDir             string
+	overrideBuiltinServices bool
 )
 
 // rootCmd represents the base command
@@ -62,6 +66,25 @@ func Execute() {
 func init() {
 	// Global flags
 	rootCmd.PersistentFlags().StringVarP(&language, "language", "l", "", "Language (en, es, pt-BR, it)")
+	rootCmd.PersistentFlags().StringVar(&servicesDir, "services-dir", "", "Directory with user-defined service definitions (default ~/.corsarr/services.d)")
+	rootCmd.PersistentFlags().BoolVar(&overrideBuiltinServices, "override-builtin-services", false, "Allow user-defined services to replace built-in services with the same ID")
+}
+
+// newServiceRegistry builds the service registry including user-defined services
+func newServiceRegistry() (*services.Registry, error) {
+	dir := servicesDir
+	if dir == "" {
+		defaultDir, err := services.GetUserServicesDir()
+		if err != nil {
+			return nil, err
+		}
+		dir = defaultDir
+	}
+
+	return services.NewRegistryWithOptions(services.RegistryOptions{
+		UserServicesDir: dir,
+		AllowOverrides:  overrideBuiltinServices,
+	})
 }
 
 // GetTranslator returns the current translator instance
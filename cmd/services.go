@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/woliveiras/corsarr/internal/i18n"
+	"github.com/woliveiras/corsarr/internal/services"
+)
+
+// servicesCmd groups commands that work with service definitions
+var servicesCmd = &cobra.Command{
+	Use:   "services",
+	Short: "Inspect service definitions",
+	Long:  `Inspect the built-in and user-defined service definitions.`,
+}
+
+// servicesLintCmd validates service definition files
+var servicesLintCmd = &cobra.Command{
+	Use:   "lint [path]",
+	Short: "Validate service definition files",
+	Long: `Validate service definition files and report every problem found.
+
+Without a path, the built-in definitions and the user services directory
+are checked. With a path, the given file or every *.yaml/*.yml file in the
+given directory is checked, resolving dependencies against the built-in
+services.
+
+The command reports unknown keys, invalid categories, unknown dependencies,
+dependency cycles, malformed port and volume mappings, web_ui ports that
+match no declared port, and missing bridge networks.
+
+Example:
+  corsarr services lint
+  corsarr services lint ~/.corsarr/services.d/custom.yaml`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		t := GetTranslator()
+
+		issues, err := runServicesLint(args)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ %s: %v\n", t.T("services_lint.failed"), err)
+			os.Exit(1)
+		}
+
+		printLintIssues(t, issues)
+		if len(issues) > 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(servicesCmd)
+	servicesCmd.AddCommand(servicesLintCmd)
+}
+
+func runServicesLint(args []string) ([]services.LintIssue, error) {
+	builtins, err := services.NewRegistry()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load built-in services: %w", err)
+	}
+
+	if len(args) == 1 {
+		return services.LintPath(args[0], builtins)
+	}
+
+	issues, err := services.LintBuiltinServices()
+	if err != nil {
+		return nil, err
+	}
+
+	dir := servicesDir
+	if dir == "" {
+		if dir, err = services.GetUserServicesDir(); err != nil {
+			return nil, err
+		}
+	}
+	if _, statErr := os.Stat(dir); statErr == nil {
+		userIssues, err := services.LintPath(dir, builtins)
+		if err != nil {
+			return nil, err
+		}
+		issues = append(issues, userIssues...)
+	}
+
+	return issues, nil
+}
+
+func printLintIssues(t *i18n.I18n, issues []services.LintIssue) {
+	if len(issues) == 0 {
+		fmt.Println(t.T("services_lint.no_issues"))
+		return
+	}
+
+	for _, issue := range issues {
+		fmt.Printf("   • %s\n", issue)
+	}
+	fmt.Println()
+	fmt.Println(t.T("services_lint.issues_found", map[string]interface{}{"count": len(issues)}))
+}
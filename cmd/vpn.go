@@ -0,0 +1,356 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/woliveiras/corsarr/internal/credentials"
+	"github.com/woliveiras/corsarr/internal/generator"
+	"github.com/woliveiras/corsarr/internal/gluetun"
+	"github.com/woliveiras/corsarr/internal/i18n"
+	"github.com/woliveiras/corsarr/internal/prompts"
+	"github.com/woliveiras/corsarr/internal/provisioning"
+	"github.com/woliveiras/corsarr/internal/runtime"
+	"github.com/woliveiras/corsarr/internal/services"
+)
+
+// qBittorrentPasswordEnv supplies the qBittorrent password without a prompt
+const qBittorrentPasswordEnv = "CORSARR_QBITTORRENT_PASSWORD"
+
+var (
+	vpnOutputDir        string
+	vpnGluetunContainer string
+	vpnGluetunAPIKey    string
+	syncPortQBitURL     string
+	syncPortQBitUser    string
+	syncPortWatch       bool
+	syncPortInterval    time.Duration
+	verifyIPEndpoint    string
+)
+
+// vpnCmd groups commands that work with the running Gluetun tunnel
+var vpnCmd = &cobra.Command{
+	Use:   "vpn",
+	Short: "Work with the running VPN tunnel",
+	Long:  `Work with the Gluetun tunnel of a running stack generated with --vpn.`,
+}
+
+// vpnSyncPortCmd pushes the forwarded VPN port into qBittorrent
+var vpnSyncPortCmd = &cobra.Command{
+	Use:   "sync-port",
+	Short: "Set qBittorrent's listen port to the port forwarded by the VPN",
+	Long: `Read the port the VPN provider forwards through Gluetun and set it as
+qBittorrent's listen port, so peers can reach torrents behind the tunnel.
+
+The forwarded port is read from the Gluetun control server inside the gluetun
+container, so the control server does not need to be published. Port
+forwarding must be enabled with VPN_PORT_FORWARDING=on, which only some
+providers support.
+
+qBittorrent is reached on the web UI port published in docker-compose.yml.
+The password is read from CORSARR_QBITTORRENT_PASSWORD or asked for. With
+--watch the command keeps running and updates qBittorrent whenever the
+provider hands out a new port.
+
+Example:
+  corsarr vpn sync-port --output /srv/stack
+  corsarr vpn sync-port --watch --interval 5m`,
+	Run: func(cmd *cobra.Command, args []string) {
+		t := GetTranslator()
+
+		if err := runSyncPort(t); err != nil {
+			fmt.Fprintf(os.Stderr, "❌ %s: %v\n", t.T("vpn.sync_failed"), err)
+			os.Exit(1)
+		}
+	},
+}
+
+// vpnVerifyCmd checks that routed traffic really leaves through the tunnel
+var vpnVerifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Verify that traffic leaves through the VPN tunnel",
+	Long: `Check the Gluetun tunnel of a running stack:
+
+- the tunnel reports itself as running
+- its public IP differs from the public IP of this machine
+- every service behind Gluetun in docker-compose.yml shares the network
+  namespace of the running gluetun container
+- no download client reaches the internet outside the tunnel
+
+The public IP of this machine is looked up with --ip-endpoint, which must
+answer with the address as plain text or as {"ip": "..."} JSON. The command
+exits with status 1 when any check fails.
+
+Example:
+  corsarr vpn verify --output /srv/stack
+  corsarr vpn verify --ip-endpoint https://ifconfig.me/ip`,
+	Run: func(cmd *cobra.Command, args []string) {
+		t := GetTranslator()
+
+		ok, err := runVPNVerify(t)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ %s: %v\n", t.T("vpn.verify_failed"), err)
+			os.Exit(1)
+		}
+		if !ok {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(vpnCmd)
+	vpnCmd.AddCommand(vpnSyncPortCmd)
+	vpnCmd.AddCommand(vpnVerifyCmd)
+
+	vpnCmd.PersistentFlags().StringVarP(&vpnOutputDir, "output", "o", ".", "Directory with docker-compose.yml and .env")
+	vpnCmd.PersistentFlags().StringVar(&vpnGluetunContainer, "container", gluetun.DefaultContainer, "Name of the Gluetun container")
+	vpnCmd.PersistentFlags().StringVar(&vpnGluetunAPIKey, "api-key", "", "API key of the Gluetun control server, when authentication is configured")
+
+	vpnSyncPortCmd.Flags().StringVar(&syncPortQBitURL, "qbittorrent-url", "", "qBittorrent web UI on this machine (defaults to the port published in docker-compose.yml)")
+	vpnSyncPortCmd.Flags().StringVar(&syncPortQBitUser, "qbittorrent-user", "admin", "qBittorrent web UI username")
+	vpnSyncPortCmd.Flags().BoolVarP(&syncPortWatch, "watch", "w", false, "Keep running and follow port changes")
+	vpnSyncPortCmd.Flags().DurationVar(&syncPortInterval, "interval", time.Minute, "How often to check the forwarded port with --watch")
+
+	vpnVerifyCmd.Flags().StringVar(&verifyIPEndpoint, "ip-endpoint", "https://api.ipify.org", "Service that returns the public IP of this machine")
+}
+
+func runSyncPort(t *i18n.I18n) error {
+	if syncPortInterval < time.Second {
+		return fmt.Errorf("--interval must be at least 1s")
+	}
+	if env, err := os.ReadFile(filepath.Join(vpnOutputDir, ".env")); err == nil {
+		if generator.ParseEnvFile(string(env))["VPN_PORT_FORWARDING"] != "on" {
+			fmt.Println(t.T("vpn.port_forwarding_off"))
+		}
+	}
+
+	qbitURL := syncPortQBitURL
+	if qbitURL == "" {
+		qbitURL = qBittorrentWebURL(vpnOutputDir)
+	}
+	password := os.Getenv(qBittorrentPasswordEnv)
+	if password == "" {
+		var err error
+		password, err = prompts.AskPassphrase(t, t.T("vpn.qbittorrent_password", map[string]interface{}{"user": syncPortQBitUser}), false)
+		if err != nil {
+			return err
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	qbit := provisioning.NewQBittorrentClient(staticEndpoint(qbitURL))
+	sync := gluetun.NewPortSync(
+		gluetun.NewClient(runtime.OSCommandRunner{}, vpnGluetunContainer, vpnGluetunAPIKey),
+		func(ctx context.Context, port int) error {
+			session, err := qbit.Login(ctx, syncPortQBitUser, credentials.NewSecret(password))
+			if err != nil {
+				return err
+			}
+			return qbit.SetListenPort(ctx, session, port)
+		},
+	)
+
+	fmt.Println(t.T("vpn.sync_target", map[string]interface{}{"container": vpnGluetunContainer, "url": qbitURL}))
+	if !syncPortWatch {
+		result := sync.Once(ctx)
+		if result.Err != nil {
+			return result.Err
+		}
+		printSyncResult(t, result)
+		return nil
+	}
+
+	fmt.Println(t.T("vpn.watching", map[string]interface{}{"interval": syncPortInterval}))
+	err := sync.Watch(ctx, syncPortInterval, func(result gluetun.SyncResult) {
+		fmt.Printf("[%s] ", time.Now().Format("15:04:05"))
+		printSyncResult(t, result)
+	})
+	if errors.Is(err, context.Canceled) {
+		return nil
+	}
+	return err
+}
+
+func printSyncResult(t *i18n.I18n, result gluetun.SyncResult) {
+	switch {
+	case errors.Is(result.Err, gluetun.ErrNoForwardedPort):
+		fmt.Println(t.T("vpn.no_forwarded_port"))
+	case result.Err != nil:
+		fmt.Printf("⚠️  %v\n", result.Err)
+	case result.Changed:
+		fmt.Println(t.T("vpn.port_updated", map[string]interface{}{"port": result.Port}))
+	default:
+		fmt.Println(t.T("vpn.port_unchanged", map[string]interface{}{"port": result.Port}))
+	}
+}
+
+// qBittorrentWebURL finds the host port that publishes qBittorrent's web UI,
+// on qBittorrent itself or on Gluetun in VPN mode
+func qBittorrentWebURL(dir string) string {
+	container := "8081"
+	if registry, err := newServiceRegistry(); err == nil {
+		if svc, err := registry.GetService("qbittorrent"); err == nil && svc.WebUI != nil {
+			container = svc.WebUI.Port
+		}
+	}
+	host := container
+	if content, err := os.ReadFile(filepath.Join(dir, "docker-compose.yml")); err == nil {
+		bindings, _ := generator.ParseComposePorts(content)
+		for _, binding := range bindings {
+			if (binding.Service == "qbittorrent" || binding.Service == gluetun.DefaultContainer) && binding.Container == container {
+				host = strconv.Itoa(binding.Host)
+				break
+			}
+		}
+	}
+	return "http://127.0.0.1:" + host
+}
+
+// staticEndpoint resolves every application to the same URL
+type staticEndpoint string
+
+func (e staticEndpoint) ResolveApplicationURL(string) (string, error) {
+	return string(e), nil
+}
+
+// runVPNVerify checks the tunnel and the routed containers and prints a
+// report. It reports whether every check passed.
+func runVPNVerify(t *i18n.I18n) (bool, error) {
+	docker := inspectDockerAvailability()
+	if !docker.installed {
+		return false, fmt.Errorf("%s", t.T("errors.docker_not_found"))
+	}
+	if !docker.available {
+		message := t.T("errors.docker_unavailable")
+		if docker.detail != "" {
+			return false, fmt.Errorf("%s: %s", message, docker.detail)
+		}
+		return false, fmt.Errorf("%s", message)
+	}
+	routes, err := vpnRoutes(t, vpnOutputDir)
+	if err != nil {
+		return false, err
+	}
+
+	fmt.Println(t.T("vpn.verifying", map[string]interface{}{"directory": vpnOutputDir}))
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+	runner := runtime.OSCommandRunner{}
+	verifier := gluetun.NewVerifier(
+		runner,
+		gluetun.NewClient(runner, vpnGluetunContainer, vpnGluetunAPIKey),
+		gluetun.PublicIPLookup(verifyIPEndpoint),
+	)
+	verification := verifier.Verify(ctx, routes)
+
+	fmt.Println()
+	fmt.Printf("   %s: %s\n", t.T("vpn.tunnel_status"), valueOr(verification.Status, "-"))
+	fmt.Printf("   %s: %s\n", t.T("vpn.tunnel_ip"), valueOr(verification.TunnelIP, "-"))
+	fmt.Printf("   %s: %s\n", t.T("vpn.host_ip"), valueOr(verification.HostIP, "-"))
+	fmt.Println()
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	_, _ = fmt.Fprintf(w, "%s\t%s\t%s\n", t.T("vpn.service"), t.T("vpn.network"), t.T("vpn.route"))
+	_, _ = fmt.Fprintf(w, "%s\t%s\t%s\n", strings.Repeat("-", 15), strings.Repeat("-", 20), strings.Repeat("-", 15))
+	for _, route := range verification.Routes {
+		status := t.T("vpn.route_direct")
+		switch {
+		case route.Err != nil:
+			status = t.T("vpn.route_not_running")
+		case route.Tunneled:
+			status = t.T("vpn.route_tunnel")
+		case route.Download || route.ExpectVPN:
+			status = t.T("vpn.route_leak")
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\n", route.Service, valueOr(shortNetworkMode(route.NetworkMode), "-"), status)
+	}
+	_ = w.Flush()
+
+	problems := verification.Problems()
+	fmt.Println()
+	if len(problems) == 0 {
+		fmt.Println(t.T("vpn.verified"))
+		return true, nil
+	}
+	for _, problem := range problems {
+		key := "vpn.problem_" + problem.Kind
+		if problem.Kind == gluetun.ProblemUnverified && problem.Service == "" {
+			key = "vpn.problem_check_failed"
+		}
+		fmt.Printf("   ✗ %s\n", t.T(key, map[string]interface{}{
+			"service": problem.Service,
+			"detail":  problem.Detail,
+		}))
+	}
+	fmt.Println()
+	fmt.Println(t.T("vpn.not_verified", map[string]interface{}{"count": len(problems)}))
+	return false, nil
+}
+
+// vpnRoutes lists the services of a generated compose file with whether
+// they are download clients and whether the file puts them behind Gluetun
+func vpnRoutes(t *i18n.I18n, dir string) ([]gluetun.Route, error) {
+	composePath := filepath.Join(dir, "docker-compose.yml")
+	content, err := os.ReadFile(composePath)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", t.T("errors.compose_not_found"), composePath)
+	}
+	summaries, err := generator.SummarizeCompose(content)
+	if err != nil {
+		return nil, err
+	}
+	registry, err := newServiceRegistry()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create registry: %w", err)
+	}
+
+	var gluetunService string
+	for name, summary := range summaries {
+		if name == gluetun.DefaultContainer || summary.ContainerName == vpnGluetunContainer {
+			gluetunService = name
+		}
+	}
+	if gluetunService == "" {
+		return nil, fmt.Errorf("%s", t.T("vpn.no_gluetun", map[string]interface{}{"file": composePath}))
+	}
+
+	var routes []gluetun.Route
+	for name, summary := range summaries {
+		if name == gluetunService {
+			continue
+		}
+		route := gluetun.Route{
+			Service:   name,
+			Container: valueOr(summary.ContainerName, name),
+			ExpectVPN: summary.NetworkMode == "service:"+gluetunService || summary.NetworkMode == "container:"+vpnGluetunContainer,
+		}
+		if svc, err := registry.GetService(name); err == nil {
+			route.Download = svc.Category == services.CategoryDownload
+		}
+		routes = append(routes, route)
+	}
+	sort.Slice(routes, func(i, j int) bool { return routes[i].Service < routes[j].Service })
+	return routes, nil
+}
+
+// shortNetworkMode shortens container:<id> to the 12 characters Docker shows
+func shortNetworkMode(mode string) string {
+	if id, ok := strings.CutPrefix(mode, "container:"); ok && len(id) > 12 {
+		return "container:" + id[:12]
+	}
+	return mode
+}
@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/woliveiras/corsarr/internal/gluetun"
+	"github.com/woliveiras/corsarr/internal/i18n"
+)
+
+func TestVPNRoutes(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	dir := t.TempDir()
+	compose := `services:
+  gluetun:
+    image: qmcgaw/gluetun:latest
+    container_name: gluetun
+  qbittorrent:
+    image: lscr.io/linuxserver/qbittorrent:latest
+    container_name: qbittorrent
+    network_mode: service:gluetun
+  sabnzbd:
+    image: lscr.io/linuxserver/sabnzbd:latest
+    container_name: sabnzbd
+    networks: [media]
+  jellyfin:
+    image: lscr.io/linuxserver/jellyfin:latest
+    networks: [media]
+`
+	if err := os.WriteFile(filepath.Join(dir, "docker-compose.yml"), []byte(compose), 0644); err != nil {
+		t.Fatalf("write compose file: %v", err)
+	}
+
+	translator, err := i18n.New("en")
+	if err != nil {
+		t.Fatalf("load translations: %v", err)
+	}
+
+	routes, err := vpnRoutes(translator, dir)
+	if err != nil {
+		t.Fatalf("read routes: %v", err)
+	}
+	want := []gluetun.Route{
+		{Service: "jellyfin", Container: "jellyfin"},
+		{Service: "qbittorrent", Container: "qbittorrent", Download: true, ExpectVPN: true},
+		{Service: "sabnzbd", Container: "sabnzbd", Download: true},
+	}
+	if !reflect.DeepEqual(routes, want) {
+		t.Errorf("unexpected routes\nwant: %+v\n got: %+v", want, routes)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "docker-compose.yml"), []byte("services:\n  radarr:\n    image: linuxserver/radarr\n"), 0644); err != nil {
+		t.Fatalf("write compose file: %v", err)
+	}
+	if _, err := vpnRoutes(translator, dir); err == nil {
+		t.Error("expected a compose file without Gluetun to be rejected")
+	}
+}
@@ -911,6 +911,7 @@ const symbols: Record<string, string> = {
   prowlarr: 'Pr',
   qbittorrent: 'qB',
   radarr: 'Ra',
+  readarr: 'Rd',
   sonarr: 'So',
 };
 
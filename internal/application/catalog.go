@@ -7,6 +7,7 @@ import (
 	"sort"
 	"strconv"
 
+	runtimecatalog "github.com/woliveiras/corsarr/internal/catalog"
 	"github.com/woliveiras/corsarr/internal/i18n"
 	"github.com/woliveiras/corsarr/internal/services"
 )
@@ -115,7 +116,9 @@ func newCatalog(registry *services.Registry, translator *i18n.I18n) *Catalog {
 	byID := make(map[string]ApplicationSummary)
 
 	for _, service := range registry.GetAllServices() {
-		if service.WebUI == nil {
+		// Applications without a pinned image, such as Readarr, cannot be
+		// installed by the runtime catalog and stay CLI-only.
+		if service.WebUI == nil || runtimecatalog.IsUnpinned(service.ID) {
 			continue
 		}
 
@@ -33,6 +33,11 @@ func TestCatalogListsOnlyApplicationsWithWebInterfaces(t *testing.T) {
 			t.Fatalf("did not expect infrastructure service %q in desktop catalog", infrastructureID)
 		}
 	}
+	for _, unpinnedID := range []string{"nzbget", "readarr", "sabnzbd"} {
+		if _, ok := findApplication(applications, unpinnedID); ok {
+			t.Fatalf("did not expect unpinned application %q in desktop catalog", unpinnedID)
+		}
+	}
 	fileflows, ok := findApplication(applications, "fileflows")
 	if !ok || fileflows.AutomatedSetup {
 		t.Fatal("expected FileFlows lifecycle access without one-click automated setup")
@@ -18,7 +18,7 @@ type ServiceAccess struct {
 	store credentials.Store
 }
 
-var managedARRApplications = []string{"lidarr", "prowlarr", "radarr", "sonarr"}
+var managedARRApplications = []string{"lidarr", "prowlarr", "radarr", "readarr", "sonarr"}
 
 func NewServiceAccess(store credentials.Store) *ServiceAccess {
 	return &ServiceAccess{store: store}
@@ -58,7 +58,7 @@ func TestServiceAccessReportsArrCredentialsWithoutRevealingThem(t *testing.T) {
 	if err != nil {
 		t.Fatalf("get Arr access statuses: %v", err)
 	}
-	if len(statuses) != 4 {
+	if len(statuses) != 5 {
 		t.Fatalf("expected every supported Arr status, got %#v", statuses)
 	}
 	available := map[string]bool{}
@@ -33,7 +33,7 @@ func TestRuntimeCatalogRemotePlatformContract(t *testing.T) {
 	}
 
 	for _, service := range registry.GetAllServices() {
-		if service.WebUI == nil {
+		if service.WebUI == nil || IsUnpinned(service.ID) {
 			continue
 		}
 		t.Run(service.ID, func(t *testing.T) {
@@ -50,6 +50,15 @@ type approvedImage struct {
 	sourceURL           string
 }
 
+// unpinnedApplications ship as CLI service definitions but stay out of the
+// desktop runtime catalog until their OCI index digests are verified by
+// TestRuntimeCatalogRemotePlatformContract and added to approvedImages.
+var unpinnedApplications = map[string]struct{}{
+	"nzbget":  {},
+	"readarr": {},
+	"sabnzbd": {},
+}
+
 var approvedImages = map[string]approvedImage{
 	"qbittorrent": {
 		repository: "lscr.io/linuxserver/qbittorrent", digest: "sha256:b6ab43fe86039e5bdd3cc0b59b946414fcff0c8183e93636e6cb438fdac45028",
@@ -106,7 +115,7 @@ var approvedImages = map[string]approvedImage{
 func NewRuntimeCatalog(registry *services.Registry) (*RuntimeCatalog, error) {
 	manifests := make(map[string]RuntimeManifest)
 	for _, service := range registry.GetAllServices() {
-		if service.WebUI == nil {
+		if service.WebUI == nil || IsUnpinned(service.ID) {
 			continue
 		}
 		approved, exists := approvedImages[service.ID]
@@ -132,6 +141,13 @@ func NewRuntimeCatalog(registry *services.Registry) (*RuntimeCatalog, error) {
 	return &RuntimeCatalog{manifests: manifests}, nil
 }
 
+// IsUnpinned reports whether an application is left out of the runtime
+// catalog because its image digest has not been pinned yet.
+func IsUnpinned(applicationID string) bool {
+	_, unpinned := unpinnedApplications[applicationID]
+	return unpinned
+}
+
 func (c *RuntimeCatalog) ApprovedImage(applicationID string) (string, error) {
 	manifest, exists := c.manifests[applicationID]
 	if !exists {
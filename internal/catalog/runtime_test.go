@@ -25,6 +25,12 @@ func TestRuntimeCatalogCoversEveryDesktopApplicationWithImmutableSpec(t *testing
 			continue
 		}
 		t.Run(service.ID, func(t *testing.T) {
+			if IsUnpinned(service.ID) {
+				if _, err := catalog.ApprovedImage(service.ID); err == nil {
+					t.Fatal("expected unpinned application to be rejected")
+				}
+				return
+			}
 			spec, resolveErr := catalog.Resolve(service.ID, root, RuntimeOptions{
 				Timezone: "Europe/Madrid",
 				PUID:     1000,
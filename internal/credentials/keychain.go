@@ -30,6 +30,7 @@ const (
 	KeyLidarrPassword        Key = "lidarr-password"
 	KeyProwlarrPassword      Key = "prowlarr-password"
 	KeyRadarrPassword        Key = "radarr-password"
+	KeyReadarrPassword       Key = "readarr-password"
 	KeySonarrPassword        Key = "sonarr-password"
 )
 
@@ -41,6 +42,7 @@ var keychainAccounts = map[Key]string{
 	KeyLidarrPassword:        "lidarr",
 	KeyProwlarrPassword:      "prowlarr",
 	KeyRadarrPassword:        "radarr",
+	KeyReadarrPassword:       "readarr",
 	KeySonarrPassword:        "sonarr",
 }
 
@@ -48,6 +50,7 @@ var arrPasswordKeys = map[string]Key{
 	"lidarr":   KeyLidarrPassword,
 	"prowlarr": KeyProwlarrPassword,
 	"radarr":   KeyRadarrPassword,
+	"readarr":  KeyReadarrPassword,
 	"sonarr":   KeySonarrPassword,
 }
 
@@ -127,7 +127,8 @@ func TestDarwinKeychainRejectsUnknownKeyWithoutCommand(t *testing.T) {
 func TestARRPasswordKeyAllowsOnlyManagedApplications(t *testing.T) {
 	want := map[string]Key{
 		"lidarr": KeyLidarrPassword, "prowlarr": KeyProwlarrPassword,
-		"radarr": KeyRadarrPassword, "sonarr": KeySonarrPassword,
+		"radarr": KeyRadarrPassword, "readarr": KeyReadarrPassword,
+		"sonarr": KeySonarrPassword,
 	}
 	for applicationID, expected := range want {
 		key, err := ARRPasswordKey(applicationID)
@@ -9,11 +9,40 @@ import (
 	"github.com/woliveiras/corsarr/internal/services"
 )
 
+// ComposeOptions tunes how the selected services are rendered
+type ComposeOptions struct {
+	// ProxyRouting is ProxyRoutingHost (sonarr.local) or ProxyRoutingPath (/sonarr)
+	ProxyRouting string
+	// ProxyDomain is the domain suffix used by host-based routes
+	ProxyDomain string
+	// PublishAppPorts keeps the web interface ports published on the host
+	// when a reverse proxy is selected
+	PublishAppPorts bool
+	// PortOverrides remaps published host ports per service
+	PortOverrides services.PortOverrides
+	// HWAccel passes GPU devices to services with hardware_acceleration
+	HWAccel services.HWAccel
+	// HWAccelGroups are the host group IDs owning the render devices
+	HWAccelGroups []string
+	// Resources sets memory, CPU and logging defaults and per-service overrides
+	Resources *services.ResourceSettings
+	// Secrets are the names of the secrets written under secrets/ instead of
+	// .env, as returned by EnvConfig.SecretNames
+	Secrets []string
+	// VPNEnv are the variables Gluetun reads for the configured provider, as
+	// returned by EnvConfig.VPNVariableNames
+	VPNEnv []string
+	// VPNRoutes chooses per service or category whether a service shares
+	// Gluetun's network namespace or joins its bridge networks in VPN mode
+	VPNRoutes services.VPNRoutes
+}
+
 // ComposeGenerator handles docker-compose.yml generation
 type ComposeGenerator struct {
 	registry *services.Registry
 	strategy ComposeStrategy
 	outputDir string
+	options   ComposeOptions
 }
 
 // NewComposeGenerator creates a new compose generator
@@ -29,6 +58,12 @@ func (g *ComposeGenerator) SetStrategy(vpnMode bool) {
 	g.strategy = NewComposeStrategy(vpnMode)
 }
 
+// SetOptions sets the rendering options such as reverse proxy routing and
+// host port overrides
+func (g *ComposeGenerator) SetOptions(options ComposeOptions) {
+	g.options = options
+}
+
 // Generate creates a docker-compose.yml file based on selected services
 func (g *ComposeGenerator) Generate(serviceIDs []string, vpnMode bool, backup bool) error {
 	// Set strategy based on VPN mode
@@ -64,7 +99,8 @@ func (g *ComposeGenerator) Generate(serviceIDs []string, vpnMode bool, backup bo
 		return fmt.Errorf("failed to write file: %w", err)
 	}
 
-	return nil
+	// Write the reverse proxy routes mounted by the proxy container
+	return writeProxyConfig(g.outputDir, selectedServices, vpnMode, g.options, backup)
 }
 
 // prepareServices loads services and adds Gluetun if needed
@@ -96,9 +132,22 @@ func (g *ComposeGenerator) prepareServices(serviceIDs []string, vpnMode bool) ([
 			// Prepend Gluetun to the list
 			selectedServices = append([]*services.Service{gluetun}, selectedServices...)
 		}
+		selectedServices = g.options.VPNRoutes.ApplyAll(selectedServices)
 	}
 
-	return selectedServices, nil
+	selectedServices = g.options.PortOverrides.ApplyAll(selectedServices)
+	selectedServices = g.options.HWAccel.ApplyAll(selectedServices, g.options.HWAccelGroups)
+	selectedServices = g.options.Resources.ApplyAll(selectedServices)
+	selectedServices = applyVPNEnv(selectedServices, g.options.VPNEnv)
+	selectedServices = applySecrets(selectedServices, g.options.Secrets)
+
+	return applyProxyOptions(selectedServices, g.options), nil
+}
+
+// SelectedServices returns the services that would be rendered, with gluetun
+// added in VPN mode and every option applied
+func (g *ComposeGenerator) SelectedServices(serviceIDs []string, vpnMode bool) ([]*services.Service, error) {
+	return g.prepareServices(serviceIDs, vpnMode)
 }
 
 // validateServices validates service dependencies
@@ -119,7 +168,12 @@ func (g *ComposeGenerator) validateServices(selectedServices []*services.Service
 
 // backupExistingFile creates a backup of the existing docker-compose.yml
 func (g *ComposeGenerator) backupExistingFile() error {
-	sourcePath := filepath.Join(g.outputDir, "docker-compose.yml")
+	return backupFile(g.outputDir, "docker-compose.yml", 0644)
+}
+
+// backupFile copies outputDir/filename to a timestamped backup next to it
+func backupFile(outputDir, filename string, perm os.FileMode) error {
+	sourcePath := filepath.Join(outputDir, filename)
 	
 	// Check if file exists
 	if _, err := os.Stat(sourcePath); os.IsNotExist(err) {
@@ -128,7 +182,7 @@ func (g *ComposeGenerator) backupExistingFile() error {
 
 	// Create backup filename with timestamp
 	timestamp := time.Now().Format("20060102_150405")
-	backupPath := filepath.Join(g.outputDir, fmt.Sprintf("docker-compose.yml.backup.%s", timestamp))
+	backupPath := filepath.Join(outputDir, fmt.Sprintf("%s.backup.%s", filename, timestamp))
 
 	// Read original file
 	content, err := os.ReadFile(sourcePath)
@@ -137,7 +191,7 @@ func (g *ComposeGenerator) backupExistingFile() error {
 	}
 
 	// Write backup
-	if err := os.WriteFile(backupPath, content, 0644); err != nil {
+	if err := os.WriteFile(backupPath, content, perm); err != nil {
 		return fmt.Errorf("failed to write backup: %w", err)
 	}
 
@@ -158,3 +212,13 @@ func (g *ComposeGenerator) Preview(serviceIDs []string, vpnMode bool) (string, e
 	// Generate using strategy
 	return g.strategy.GenerateCompose(selectedServices)
 }
+
+// ProxyConfig renders the reverse proxy configuration for the selected
+// services, or an empty string when no reverse proxy is selected
+func (g *ComposeGenerator) ProxyConfig(serviceIDs []string, vpnMode bool) (string, error) {
+	selectedServices, err := g.prepareServices(serviceIDs, vpnMode)
+	if err != nil {
+		return "", err
+	}
+	return RenderProxyConfig(selectedServices, vpnMode, g.options)
+}
@@ -0,0 +1,334 @@
+package generator
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/woliveiras/corsarr/internal/services"
+	"gopkg.in/yaml.v3"
+)
+
+// ComposeFile is the typed model of a generated docker-compose.yml. It is
+// marshalled with yaml.v3, so values are quoted and escaped by the encoder
+// rather than by hand.
+type ComposeFile struct {
+	Services ComposeServices           `yaml:"services"`
+	Networks map[string]ComposeNetwork `yaml:"networks,omitempty"`
+	Secrets  map[string]ComposeSecret  `yaml:"secrets,omitempty"`
+}
+
+// ComposeServices keeps services in generation order, with Gluetun first in
+// VPN mode, instead of the sorted order yaml.v3 uses for maps
+type ComposeServices []*ComposeService
+
+// ComposeService is a single entry under services:
+type ComposeService struct {
+	Name          string              `yaml:"-"`
+	Image         string              `yaml:"image"`
+	ContainerName string              `yaml:"container_name"`
+	Init          bool                `yaml:"init,omitempty"`
+	Hostname      string              `yaml:"hostname,omitempty"`
+	NetworkMode   QuotedString        `yaml:"network_mode,omitempty"`
+	DependsOn     ComposeDependsOn    `yaml:"depends_on,omitempty"`
+	Networks      []string            `yaml:"networks,omitempty"`
+	Ports         []QuotedString      `yaml:"ports,omitempty"`
+	Volumes       []string            `yaml:"volumes,omitempty"`
+	Environment   []string            `yaml:"environment,omitempty"`
+	Devices       []string            `yaml:"devices,omitempty"`
+	GroupAdd      []string            `yaml:"group_add,omitempty"`
+	CapAdd        []string            `yaml:"cap_add,omitempty"`
+	Deploy        *ComposeDeploy      `yaml:"deploy,omitempty"`
+	MemLimit      string              `yaml:"mem_limit,omitempty"`
+	CPUs          string              `yaml:"cpus,omitempty"`
+	Logging       *ComposeLogging     `yaml:"logging,omitempty"`
+	Secrets       []string            `yaml:"secrets,omitempty"`
+	Restart       string              `yaml:"restart,omitempty"`
+	Healthcheck   *ComposeHealthcheck `yaml:"healthcheck,omitempty"`
+}
+
+// Start conditions of a depends_on entry
+const (
+	ConditionServiceStarted = "service_started"
+	ConditionServiceHealthy = "service_healthy"
+)
+
+// ComposeDependsOn keeps depends_on entries in dependency order
+type ComposeDependsOn []ComposeDependency
+
+// ComposeDependency is a depends_on entry in the long syntax
+type ComposeDependency struct {
+	Service   string
+	Condition string
+}
+
+// ComposeHealthcheck is the healthcheck block of a service
+type ComposeHealthcheck struct {
+	Test        []string `yaml:"test,flow"`
+	Interval    string   `yaml:"interval,omitempty"`
+	Timeout     string   `yaml:"timeout,omitempty"`
+	Retries     int      `yaml:"retries,omitempty"`
+	StartPeriod string   `yaml:"start_period,omitempty"`
+}
+
+// ComposeDeploy holds the deploy section, used for GPU reservations
+type ComposeDeploy struct {
+	Resources ComposeResources `yaml:"resources"`
+}
+
+// ComposeResources holds resource reservations
+type ComposeResources struct {
+	Reservations ComposeReservations `yaml:"reservations"`
+}
+
+// ComposeReservations holds reserved devices
+type ComposeReservations struct {
+	Devices []ComposeDeviceRequest `yaml:"devices"`
+}
+
+// ComposeDeviceRequest reserves devices from a runtime driver such as nvidia
+type ComposeDeviceRequest struct {
+	Driver       string   `yaml:"driver"`
+	Count        string   `yaml:"count"`
+	Capabilities []string `yaml:"capabilities,flow"`
+}
+
+// ComposeLogging selects the log driver of a service and its options, such
+// as max-size and max-file for json-file rotation
+type ComposeLogging struct {
+	Driver  string            `yaml:"driver,omitempty"`
+	Options map[string]string `yaml:"options,omitempty"`
+}
+
+// ComposeNetwork is a top-level network definition
+type ComposeNetwork struct {
+	Driver string `yaml:"driver,omitempty"`
+}
+
+// ComposeSecret is a top-level secret read from a file next to the compose
+// file
+type ComposeSecret struct {
+	File string `yaml:"file"`
+}
+
+// QuotedString is always written as a double-quoted scalar. Port mappings
+// such as "22:22" would otherwise be read as base 60 numbers by YAML 1.1
+// parsers.
+type QuotedString string
+
+// MarshalYAML implements yaml.Marshaler
+func (s QuotedString) MarshalYAML() (interface{}, error) {
+	return &yaml.Node{Kind: yaml.ScalarNode, Style: yaml.DoubleQuotedStyle, Value: string(s)}, nil
+}
+
+// MarshalYAML implements yaml.Marshaler, emitting services as an ordered
+// mapping keyed by service name
+func (s ComposeServices) MarshalYAML() (interface{}, error) {
+	node := &yaml.Node{Kind: yaml.MappingNode}
+	for _, svc := range s {
+		value := &yaml.Node{}
+		if err := value.Encode(svc); err != nil {
+			return nil, fmt.Errorf("failed to encode service %s: %w", svc.Name, err)
+		}
+		node.Content = append(node.Content,
+			&yaml.Node{Kind: yaml.ScalarNode, Value: svc.Name},
+			value,
+		)
+	}
+	return node, nil
+}
+
+// MarshalYAML implements yaml.Marshaler, emitting the long syntax so each
+// dependency carries its start condition
+func (d ComposeDependsOn) MarshalYAML() (interface{}, error) {
+	node := &yaml.Node{Kind: yaml.MappingNode}
+	for _, dependency := range d {
+		node.Content = append(node.Content,
+			&yaml.Node{Kind: yaml.ScalarNode, Value: dependency.Service},
+			&yaml.Node{Kind: yaml.MappingNode, Content: []*yaml.Node{
+				{Kind: yaml.ScalarNode, Value: "condition"},
+				{Kind: yaml.ScalarNode, Value: dependency.Condition},
+			}},
+		)
+	}
+	return node, nil
+}
+
+// Has reports whether the service is already listed
+func (d ComposeDependsOn) Has(service string) bool {
+	for _, dependency := range d {
+		if dependency.Service == service {
+			return true
+		}
+	}
+	return false
+}
+
+// Service returns the service with the given name, or nil
+func (f *ComposeFile) Service(name string) *ComposeService {
+	for _, svc := range f.Services {
+		if svc.Name == name {
+			return svc
+		}
+	}
+	return nil
+}
+
+// Marshal renders the compose file with two-space indentation. The output
+// only depends on the model, so the same selection always produces the same
+// bytes.
+func (f *ComposeFile) Marshal() (string, error) {
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(f); err != nil {
+		return "", fmt.Errorf("failed to marshal compose file: %w", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return "", fmt.Errorf("failed to marshal compose file: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// newComposeService maps the fields shared by both networking modes
+func newComposeService(svc *services.Service) *ComposeService {
+	composeService := &ComposeService{
+		Name:          svc.ContainerName,
+		Image:         svc.Image,
+		ContainerName: svc.ContainerName,
+		Init:          svc.Init,
+		Environment:   append([]string(nil), svc.Environment...),
+		Devices:       append([]string(nil), svc.Devices...),
+		GroupAdd:      append([]string(nil), svc.GroupAdd...),
+		CapAdd:        append([]string(nil), svc.CapAdd...),
+		MemLimit:      svc.Resources.MemLimit,
+		CPUs:          svc.Resources.CPUs,
+		Logging:       composeLogging(svc.Resources.Logging),
+		Secrets:       append([]string(nil), svc.Secrets...),
+		Restart:       svc.Restart,
+		Healthcheck:   composeHealthcheck(svc),
+	}
+	if svc.GPU != nil {
+		composeService.Deploy = &ComposeDeploy{Resources: ComposeResources{Reservations: ComposeReservations{
+			Devices: []ComposeDeviceRequest{{
+				Driver:       svc.GPU.Driver,
+				Count:        svc.GPU.Count,
+				Capabilities: append([]string(nil), svc.GPU.Capabilities...),
+			}},
+		}}}
+	}
+	for _, volume := range svc.Volumes {
+		composeService.Volumes = append(composeService.Volumes, composeVolume(volume))
+	}
+	return composeService
+}
+
+// composeLogging converts the log settings, omitting empty blocks
+func composeLogging(logging *services.LoggingConfig) *ComposeLogging {
+	if logging == nil || *logging == (services.LoggingConfig{}) {
+		return nil
+	}
+	composeLogging := &ComposeLogging{Driver: logging.Driver}
+	for option, value := range map[string]string{"max-size": logging.MaxSize, "max-file": logging.MaxFile} {
+		if value == "" {
+			continue
+		}
+		if composeLogging.Options == nil {
+			composeLogging.Options = make(map[string]string)
+		}
+		composeLogging.Options[option] = value
+	}
+	return composeLogging
+}
+
+// Healthcheck timings used when a service definition leaves them unset
+const (
+	defaultHealthcheckInterval = "30s"
+	defaultHealthcheckTimeout  = "10s"
+	defaultHealthcheckRetries  = 3
+)
+
+// composeHealthcheck builds the healthcheck block, filling in default timings
+func composeHealthcheck(svc *services.Service) *ComposeHealthcheck {
+	test := svc.HealthcheckTest()
+	if len(test) == 0 {
+		return nil
+	}
+	healthcheck := &ComposeHealthcheck{
+		Test:        test,
+		Interval:    svc.Healthcheck.Interval,
+		Timeout:     svc.Healthcheck.Timeout,
+		Retries:     svc.Healthcheck.Retries,
+		StartPeriod: svc.Healthcheck.StartPeriod,
+	}
+	if healthcheck.Interval == "" {
+		healthcheck.Interval = defaultHealthcheckInterval
+	}
+	if healthcheck.Timeout == "" {
+		healthcheck.Timeout = defaultHealthcheckTimeout
+	}
+	if healthcheck.Retries == 0 {
+		healthcheck.Retries = defaultHealthcheckRetries
+	}
+	return healthcheck
+}
+
+// composeDependsOn renders the selected dependencies of a service. Services
+// with a healthcheck must be healthy before their dependents start; the
+// others only need to be started. Dependencies outside the selection are
+// left to the dependency validator.
+func composeDependsOn(svc *services.Service, names map[string]string, selected map[string]*services.Service) ComposeDependsOn {
+	var dependsOn ComposeDependsOn
+	for _, id := range svc.Dependencies {
+		dependency, ok := selected[id]
+		if !ok || dependsOn.Has(names[id]) {
+			continue
+		}
+		dependsOn = append(dependsOn, ComposeDependency{Service: names[id], Condition: dependencyCondition(dependency)})
+	}
+	return dependsOn
+}
+
+// dependencyCondition waits for health when the dependency reports it
+func dependencyCondition(svc *services.Service) string {
+	if len(svc.HealthcheckTest()) > 0 {
+		return ConditionServiceHealthy
+	}
+	return ConditionServiceStarted
+}
+
+// composePorts converts port mappings to the short "host:container[/udp]" syntax
+func composePorts(ports []services.PortMapping) []QuotedString {
+	var published []QuotedString
+	for _, port := range ports {
+		spec := port.Host + ":" + port.Container
+		if port.Protocol != "" && port.Protocol != "tcp" {
+			spec += "/" + port.Protocol
+		}
+		published = append(published, QuotedString(spec))
+	}
+	return published
+}
+
+// composeVolume converts a volume mapping to the short "host:container[:ro]" syntax
+func composeVolume(volume services.VolumeMapping) string {
+	spec := volume.Host + ":" + volume.Container
+	if volume.ReadOnly {
+		spec += ":ro"
+	}
+	return spec
+}
+
+// bridgeNetworks declares every network referenced by the services. yaml.v3
+// sorts map keys, so the output order is stable.
+func bridgeNetworks(composeServices ComposeServices) map[string]ComposeNetwork {
+	var networks map[string]ComposeNetwork
+	for _, svc := range composeServices {
+		for _, network := range svc.Networks {
+			if networks == nil {
+				networks = make(map[string]ComposeNetwork)
+			}
+			networks[network] = ComposeNetwork{Driver: "bridge"}
+		}
+	}
+	return networks
+}
@@ -0,0 +1,345 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/woliveiras/corsarr/internal/services"
+	"gopkg.in/yaml.v3"
+)
+
+type decodedCompose struct {
+	Services map[string]struct {
+		NetworkMode string `yaml:"network_mode"`
+		DependsOn   map[string]struct {
+			Condition string `yaml:"condition"`
+		} `yaml:"depends_on"`
+		Ports       []string `yaml:"ports"`
+		Environment []string `yaml:"environment"`
+		Networks    []string `yaml:"networks"`
+		Healthcheck *struct {
+			Test        []string `yaml:"test"`
+			Interval    string   `yaml:"interval"`
+			Timeout     string   `yaml:"timeout"`
+			Retries     int      `yaml:"retries"`
+			StartPeriod string   `yaml:"start_period"`
+		} `yaml:"healthcheck"`
+	} `yaml:"services"`
+	Networks map[string]map[string]string `yaml:"networks"`
+}
+
+func decodeCompose(t *testing.T, content string) decodedCompose {
+	t.Helper()
+	var compose decodedCompose
+	if err := yaml.Unmarshal([]byte(content), &compose); err != nil {
+		t.Fatalf("Generated compose is not valid YAML: %v\n%s", err, content)
+	}
+	return compose
+}
+
+func TestComposeFile_MarshalIsDeterministic(t *testing.T) {
+	registry, err := services.NewRegistry()
+	if err != nil {
+		t.Fatalf("Failed to create registry: %v", err)
+	}
+	generator := NewComposeGenerator(registry, t.TempDir())
+	ids := []string{"qbittorrent", "prowlarr", "radarr", "sonarr", "jellyfin"}
+
+	for _, vpnMode := range []bool{false, true} {
+		first, err := generator.Preview(ids, vpnMode)
+		if err != nil {
+			t.Fatalf("Failed to preview: %v", err)
+		}
+		for i := 0; i < 5; i++ {
+			again, err := generator.Preview(ids, vpnMode)
+			if err != nil {
+				t.Fatalf("Failed to preview: %v", err)
+			}
+			if again != first {
+				t.Fatalf("Expected identical output across runs (vpn=%v)", vpnMode)
+			}
+		}
+	}
+}
+
+func TestComposeFile_ServiceOrderAndStructure(t *testing.T) {
+	registry, err := services.NewRegistry()
+	if err != nil {
+		t.Fatalf("Failed to create registry: %v", err)
+	}
+	selected, err := NewComposeGenerator(registry, t.TempDir()).prepareServices([]string{"sonarr", "qbittorrent"}, true)
+	if err != nil {
+		t.Fatalf("Failed to prepare services: %v", err)
+	}
+
+	compose, err := (&VPNModeStrategy{}).BuildCompose(selected)
+	if err != nil {
+		t.Fatalf("Failed to build compose: %v", err)
+	}
+	var names []string
+	for _, svc := range compose.Services {
+		names = append(names, svc.Name)
+	}
+	if strings.Join(names, ",") != "gluetun,sonarr,qbittorrent" {
+		t.Errorf("Expected selection order with gluetun first, got %v", names)
+	}
+	if sonarr := compose.Service("sonarr"); sonarr == nil || sonarr.NetworkMode != "service:gluetun" || len(sonarr.Ports) != 0 {
+		t.Errorf("Expected Sonarr to share the gluetun network without ports, got %+v", sonarr)
+	}
+
+	content, err := compose.Marshal()
+	if err != nil {
+		t.Fatalf("Failed to marshal: %v", err)
+	}
+	if strings.Index(content, "  gluetun:") > strings.Index(content, "  sonarr:") {
+		t.Errorf("Expected gluetun to be written first:\n%s", content)
+	}
+	decoded := decodeCompose(t, content)
+	if ports := decoded.Services["gluetun"].Ports; !contains(ports, "8989:8989") || !contains(ports, "6881:6881/udp") {
+		t.Errorf("Expected gluetun to publish the routed ports, got %v", ports)
+	}
+	if deps := decoded.Services["qbittorrent"].DependsOn; len(deps) != 1 || deps["gluetun"].Condition != ConditionServiceHealthy {
+		t.Errorf("Expected qBittorrent to wait for a healthy gluetun, got %v", deps)
+	}
+	if decoded.Networks != nil {
+		t.Errorf("Did not expect bridge networks in VPN mode, got %v", decoded.Networks)
+	}
+}
+
+func TestComposeFile_EscapesValues(t *testing.T) {
+	compose, err := (&BridgeModeStrategy{}).BuildCompose([]*services.Service{{
+		ID:            "custom",
+		ContainerName: "custom",
+		Image:         "example/custom:latest",
+		Restart:       "unless-stopped",
+		Environment:   []string{`PASSWORD=p@ss: "word" #1`, "EMPTY="},
+		Ports:         []services.PortMapping{{Host: "22", Container: "22", Protocol: "tcp"}},
+		Network: services.NetworkConfig{BridgeMode: services.BridgeModeConfig{
+			Hostname: "custom",
+			Networks: []string{"media"},
+		}},
+	}})
+	if err != nil {
+		t.Fatalf("Failed to build compose: %v", err)
+	}
+	content, err := compose.Marshal()
+	if err != nil {
+		t.Fatalf("Failed to marshal: %v", err)
+	}
+
+	if !strings.Contains(content, `- "22:22"`) {
+		t.Errorf("Expected ports to be double-quoted:\n%s", content)
+	}
+	decoded := decodeCompose(t, content)
+	env := decoded.Services["custom"].Environment
+	if len(env) != 2 || env[0] != `PASSWORD=p@ss: "word" #1` || env[1] != "EMPTY=" {
+		t.Errorf("Expected environment values to round-trip, got %q", env)
+	}
+	if decoded.Networks["media"]["driver"] != "bridge" {
+		t.Errorf("Expected the media bridge network, got %v", decoded.Networks)
+	}
+}
+
+func TestComposeFile_Healthchecks(t *testing.T) {
+	registry, err := services.NewRegistry()
+	if err != nil {
+		t.Fatalf("Failed to create registry: %v", err)
+	}
+	var ids []string
+	for _, svc := range registry.GetAllServices() {
+		if svc.Category != services.CategoryVPN {
+			ids = append(ids, svc.ID)
+		}
+	}
+
+	for _, vpnMode := range []bool{false, true} {
+		content, err := NewComposeGenerator(registry, t.TempDir()).Preview(ids, vpnMode)
+		if err != nil {
+			t.Fatalf("Failed to preview: %v", err)
+		}
+		decoded := decodeCompose(t, content)
+		for name, svc := range decoded.Services {
+			healthcheck := svc.Healthcheck
+			if healthcheck == nil || len(healthcheck.Test) < 2 {
+				t.Errorf("Expected a healthcheck for %s (vpn=%v)", name, vpnMode)
+				continue
+			}
+			if strings.Contains(strings.Join(healthcheck.Test, " "), "localhost:/") {
+				t.Errorf("Expected a port in the probe for %s, got %q", name, healthcheck.Test)
+			}
+			if healthcheck.Interval == "" || healthcheck.Timeout == "" || healthcheck.Retries == 0 {
+				t.Errorf("Expected timings for %s, got %+v", name, healthcheck)
+			}
+		}
+	}
+}
+
+func TestComposeFile_HealthcheckDefaults(t *testing.T) {
+	svc := &services.Service{
+		ID:            "custom",
+		ContainerName: "custom",
+		Image:         "example/custom:latest",
+		Ports:         []services.PortMapping{{Host: "9999", Container: "8080"}},
+		Healthcheck:   &services.HealthcheckConfig{Path: "/health", StartPeriod: "15s"},
+	}
+	healthcheck := newComposeService(svc).Healthcheck
+	if healthcheck == nil {
+		t.Fatal("Expected a healthcheck")
+	}
+	if strings.Join(healthcheck.Test, " ") != "CMD-SHELL curl -fsS -o /dev/null http://localhost:8080/health || exit 1" {
+		t.Errorf("Expected a probe on the container port, got %q", healthcheck.Test)
+	}
+	if healthcheck.Interval != "30s" || healthcheck.Timeout != "10s" || healthcheck.Retries != 3 || healthcheck.StartPeriod != "15s" {
+		t.Errorf("Expected default timings, got %+v", healthcheck)
+	}
+
+	svc.Healthcheck = nil
+	if newComposeService(svc).Healthcheck != nil {
+		t.Error("Did not expect a healthcheck without a definition")
+	}
+}
+
+func TestComposeFile_DependsOn(t *testing.T) {
+	registry, err := services.NewRegistry()
+	if err != nil {
+		t.Fatalf("Failed to create registry: %v", err)
+	}
+	generator := NewComposeGenerator(registry, t.TempDir())
+	ids := []string{"caddy", "sonarr", "prowlarr", "qbittorrent"}
+
+	content, err := generator.Preview(ids, false)
+	if err != nil {
+		t.Fatalf("Failed to preview: %v", err)
+	}
+	if !strings.Contains(content, "depends_on:\n      qbittorrent:\n        condition: service_healthy\n      prowlarr:\n        condition: service_healthy") {
+		t.Errorf("Expected Sonarr dependencies in definition order:\n%s", content)
+	}
+	decoded := decodeCompose(t, content)
+	if deps := decoded.Services["prowlarr"].DependsOn; len(deps) != 0 {
+		t.Errorf("Expected Prowlarr to start without dependencies, got %v", deps)
+	}
+
+	content, err = generator.Preview(ids, true)
+	if err != nil {
+		t.Fatalf("Failed to preview: %v", err)
+	}
+	decoded = decodeCompose(t, content)
+	sonarr := decoded.Services["sonarr"].DependsOn
+	if len(sonarr) != 3 || sonarr["gluetun"].Condition != ConditionServiceHealthy || sonarr["qbittorrent"].Condition != ConditionServiceHealthy {
+		t.Errorf("Expected Sonarr to wait for gluetun and its dependencies, got %v", sonarr)
+	}
+	if caddy := decoded.Services["caddy"].DependsOn; caddy["gluetun"].Condition != ConditionServiceHealthy {
+		t.Errorf("Expected the proxy inside the tunnel to wait for a healthy gluetun, got %v", caddy)
+	}
+}
+
+func TestComposeDependsOn_Conditions(t *testing.T) {
+	healthy := &services.Service{ID: "db", ContainerName: "db", Healthcheck: &services.HealthcheckConfig{Test: []string{"CMD", "true"}}}
+	started := &services.Service{ID: "cache", ContainerName: "cache"}
+	app := &services.Service{ID: "app", ContainerName: "app", Dependencies: []string{"db", "cache", "missing"}}
+	names, selected := serviceIndex([]*services.Service{healthy, started, app})
+
+	dependsOn := composeDependsOn(app, names, selected)
+	expected := ComposeDependsOn{
+		{Service: "db", Condition: ConditionServiceHealthy},
+		{Service: "cache", Condition: ConditionServiceStarted},
+	}
+	if len(dependsOn) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, dependsOn)
+	}
+	for i := range expected {
+		if dependsOn[i] != expected[i] {
+			t.Errorf("Expected %v, got %v", expected[i], dependsOn[i])
+		}
+	}
+}
+
+func TestComposeFile_HWAccel(t *testing.T) {
+	registry, err := services.NewRegistry()
+	if err != nil {
+		t.Fatalf("Failed to create registry: %v", err)
+	}
+	ids := []string{"jellyfin", "fileflows", "sonarr", "prowlarr", "qbittorrent"}
+
+	plain, err := NewComposeGenerator(registry, t.TempDir()).Preview(ids, false)
+	if err != nil {
+		t.Fatalf("Failed to preview: %v", err)
+	}
+	if strings.Count(plain, "/dev/dri:/dev/dri") != 1 || !strings.Contains(plain, "/dev/dri/renderD128") {
+		t.Errorf("Expected only FileFlows to keep its default GPU devices without --hwaccel:\n%s", plain)
+	}
+
+	none := NewComposeGenerator(registry, t.TempDir())
+	none.SetOptions(ComposeOptions{HWAccel: services.HWAccelNone})
+	cpuOnly, err := none.Preview(ids, false)
+	if err != nil {
+		t.Fatalf("Failed to preview: %v", err)
+	}
+	if strings.Contains(cpuOnly, "/dev/dri") {
+		t.Errorf("Did not expect GPU devices with --hwaccel none:\n%s", cpuOnly)
+	}
+
+	generator := NewComposeGenerator(registry, t.TempDir())
+	generator.SetOptions(ComposeOptions{HWAccel: services.HWAccelVAAPI, HWAccelGroups: []string{"105"}})
+	selected, err := generator.prepareServices(ids, false)
+	if err != nil {
+		t.Fatalf("Failed to prepare services: %v", err)
+	}
+	compose, err := (&BridgeModeStrategy{}).BuildCompose(selected)
+	if err != nil {
+		t.Fatalf("Failed to build compose: %v", err)
+	}
+	for _, name := range []string{"jellyfin", "fileflows"} {
+		svc := compose.Service(name)
+		if !contains(svc.Devices, "/dev/dri:/dev/dri") || !contains(svc.GroupAdd, "105") {
+			t.Errorf("Expected %s to get /dev/dri and the render group, got %v %v", name, svc.Devices, svc.GroupAdd)
+		}
+	}
+	if sonarr := compose.Service("sonarr"); len(sonarr.Devices) != 0 || len(sonarr.GroupAdd) != 0 {
+		t.Errorf("Expected Sonarr to stay without devices, got %v", sonarr.Devices)
+	}
+
+	generator.SetOptions(ComposeOptions{HWAccel: services.HWAccelNVIDIA})
+	content, err := generator.Preview(ids, false)
+	if err != nil {
+		t.Fatalf("Failed to preview: %v", err)
+	}
+	expected := "deploy:\n      resources:\n        reservations:\n          devices:\n            - driver: nvidia\n              count: all\n              capabilities: [gpu]"
+	if !strings.Contains(content, expected) {
+		t.Errorf("Expected an NVIDIA device reservation:\n%s", content)
+	}
+}
+
+func TestComposeFile_Resources(t *testing.T) {
+	registry, err := services.NewRegistry()
+	if err != nil {
+		t.Fatalf("Failed to create registry: %v", err)
+	}
+	generator := NewComposeGenerator(registry, t.TempDir())
+	generator.SetOptions(ComposeOptions{Resources: &services.ResourceSettings{
+		Defaults: services.ResourceConfig{Logging: &services.LoggingConfig{Driver: "json-file", MaxSize: "10m", MaxFile: "3"}},
+		Services: map[string]services.ResourceConfig{"qbittorrent": {MemLimit: "1g", CPUs: "1.5"}},
+	}})
+
+	for _, vpnMode := range []bool{false, true} {
+		content, err := generator.Preview([]string{"qbittorrent", "prowlarr"}, vpnMode)
+		if err != nil {
+			t.Fatalf("Failed to preview: %v", err)
+		}
+		if !strings.Contains(content, "    mem_limit: 1g\n    cpus: \"1.5\"\n    logging:\n      driver: json-file\n      options:\n        max-file: \"3\"\n        max-size: 10m\n") {
+			t.Errorf("Expected qBittorrent limits and log rotation (vpn=%v):\n%s", vpnMode, content)
+		}
+		if strings.Count(content, "max-size: 10m") != strings.Count(content, "container_name:") {
+			t.Errorf("Expected log rotation on every service (vpn=%v):\n%s", vpnMode, content)
+		}
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,199 @@
+package generator
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ComposePort is a host port binding published by a service in an existing
+// docker-compose.yml
+type ComposePort struct {
+	Service   string
+	Host      int
+	Container string
+	Protocol  string
+
+	node *yaml.Node
+}
+
+// PortChange moves a published host port of a compose service
+type PortChange struct {
+	Service  string
+	Protocol string
+	From     int
+	To       int
+}
+
+// ParseComposePorts returns the host port bindings of every service, in file
+// order. Bindings without a fixed host port or with port ranges are skipped.
+func ParseComposePorts(content []byte) ([]ComposePort, error) {
+	var document yaml.Node
+	if err := yaml.Unmarshal(content, &document); err != nil {
+		return nil, fmt.Errorf("failed to parse compose file: %w", err)
+	}
+	if len(document.Content) == 0 {
+		return nil, nil
+	}
+
+	servicesNode := mappingValue(document.Content[0], "services")
+	if servicesNode == nil || servicesNode.Kind != yaml.MappingNode {
+		return nil, nil
+	}
+
+	var ports []ComposePort
+	for i := 0; i+1 < len(servicesNode.Content); i += 2 {
+		name := servicesNode.Content[i].Value
+		portsNode := mappingValue(servicesNode.Content[i+1], "ports")
+		if portsNode == nil || portsNode.Kind != yaml.SequenceNode {
+			continue
+		}
+		for _, entry := range portsNode.Content {
+			port, ok := parseComposePort(entry)
+			if !ok {
+				continue
+			}
+			port.Service = name
+			ports = append(ports, port)
+		}
+	}
+	return ports, nil
+}
+
+// RewriteHostPorts applies the changes to the compose content. Only the
+// affected port lines are edited, so comments and formatting are preserved.
+func RewriteHostPorts(content []byte, changes []PortChange) ([]byte, error) {
+	ports, err := ParseComposePorts(content)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := strings.Split(string(content), "\n")
+	for _, change := range changes {
+		matched := false
+		for _, port := range ports {
+			if port.Service != change.Service || port.Host != change.From || port.Protocol != change.Protocol {
+				continue
+			}
+			if err := rewritePortLine(lines, port, change.To); err != nil {
+				return nil, err
+			}
+			matched = true
+		}
+		if !matched {
+			return nil, fmt.Errorf("service %s does not publish port %d/%s", change.Service, change.From, change.Protocol)
+		}
+	}
+	return []byte(strings.Join(lines, "\n")), nil
+}
+
+// FixComposePorts rewrites docker-compose.yml in outputDir with the changes,
+// keeping a timestamped backup of the previous file. It returns the previous
+// and the new content.
+func FixComposePorts(outputDir string, changes []PortChange) ([]byte, []byte, error) {
+	composePath := filepath.Join(outputDir, "docker-compose.yml")
+	before, err := os.ReadFile(composePath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read compose file: %w", err)
+	}
+	after, err := RewriteHostPorts(before, changes)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := backupFile(outputDir, "docker-compose.yml", 0644); err != nil {
+		return nil, nil, fmt.Errorf("failed to backup existing file: %w", err)
+	}
+	if err := os.WriteFile(composePath, after, 0644); err != nil {
+		return nil, nil, fmt.Errorf("failed to write file: %w", err)
+	}
+	return before, after, nil
+}
+
+// parseComposePort reads the short ("8080:80/udp", "127.0.0.1:8080:80") and
+// long (published/target) port syntaxes
+func parseComposePort(entry *yaml.Node) (ComposePort, bool) {
+	port := ComposePort{Protocol: "tcp"}
+	switch entry.Kind {
+	case yaml.ScalarNode:
+		spec := entry.Value
+		if base, protocol, found := strings.Cut(spec, "/"); found {
+			spec = base
+			port.Protocol = protocol
+		}
+		parts := strings.Split(spec, ":")
+		if len(parts) < 2 {
+			return port, false
+		}
+		host, err := strconv.Atoi(parts[len(parts)-2])
+		if err != nil {
+			return port, false
+		}
+		port.Host = host
+		port.Container = parts[len(parts)-1]
+		port.node = entry
+	case yaml.MappingNode:
+		published := mappingValue(entry, "published")
+		if published == nil {
+			return port, false
+		}
+		host, err := strconv.Atoi(published.Value)
+		if err != nil {
+			return port, false
+		}
+		port.Host = host
+		if target := mappingValue(entry, "target"); target != nil {
+			port.Container = target.Value
+		}
+		if protocol := mappingValue(entry, "protocol"); protocol != nil {
+			port.Protocol = protocol.Value
+		}
+		port.node = published
+	default:
+		return port, false
+	}
+	return port, true
+}
+
+// rewritePortLine replaces the host port in the line holding the port node
+func rewritePortLine(lines []string, port ComposePort, newPort int) error {
+	index := port.node.Line - 1
+	if index < 0 || index >= len(lines) {
+		return fmt.Errorf("port %d of %s is outside the compose file", port.Host, port.Service)
+	}
+
+	oldValue := port.node.Value
+	newValue := strconv.Itoa(newPort)
+	if port.node.Kind == yaml.ScalarNode && strings.Contains(oldValue, ":") {
+		parts := strings.Split(oldValue, ":")
+		parts[len(parts)-2] = newValue
+		newValue = strings.Join(parts, ":")
+	}
+
+	line := lines[index]
+	column := min(max(port.node.Column-1, 0), len(line))
+	offset := strings.Index(line[column:], oldValue)
+	if offset < 0 {
+		return fmt.Errorf("port %d of %s uses a multi-line value that cannot be rewritten", port.Host, port.Service)
+	}
+	start := column + offset
+	lines[index] = line[:start] + newValue + line[start+len(oldValue):]
+	return nil
+}
+
+// mappingValue returns the value node for key in a YAML mapping node
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
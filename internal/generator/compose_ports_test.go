@@ -0,0 +1,108 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+const portsCompose = `services:
+  gluetun:
+    image: qmcgaw/gluetun:latest
+    ports:
+      - "8888:8888"
+      - "8989:8989" # Sonarr
+      - "6881:6881/udp"
+      - 127.0.0.1:9696:9696
+  jellyfin:
+    ports:
+      - target: 8096
+        published: "8096"
+        protocol: tcp
+      - "7359"
+`
+
+func TestParseComposePorts(t *testing.T) {
+	ports, err := ParseComposePorts([]byte(portsCompose))
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+
+	var got []string
+	for _, port := range ports {
+		got = append(got, port.Service+" "+port.Container+"/"+port.Protocol+"@"+strconv.Itoa(port.Host))
+	}
+	want := []string{
+		"gluetun 8888/tcp@8888",
+		"gluetun 8989/tcp@8989",
+		"gluetun 6881/udp@6881",
+		"gluetun 9696/tcp@9696",
+		"jellyfin 8096/tcp@8096",
+	}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("Unexpected ports:\n got %v\nwant %v", got, want)
+	}
+}
+
+func TestRewriteHostPorts(t *testing.T) {
+	rewritten, err := RewriteHostPorts([]byte(portsCompose), []PortChange{
+		{Service: "gluetun", Protocol: "tcp", From: 8989, To: 8990},
+		{Service: "gluetun", Protocol: "udp", From: 6881, To: 6882},
+		{Service: "gluetun", Protocol: "tcp", From: 9696, To: 9697},
+		{Service: "jellyfin", Protocol: "tcp", From: 8096, To: 8097},
+	})
+	if err != nil {
+		t.Fatalf("Failed to rewrite: %v", err)
+	}
+
+	if strings.Contains(string(rewritten), `"8989:8989"`) {
+		t.Errorf("Expected the Sonarr binding to be rewritten:\n%s", rewritten)
+	}
+	for _, expected := range []string{
+		`      - "8888:8888"`,
+		`      - "8990:8989" # Sonarr`,
+		`      - "6882:6881/udp"`,
+		`      - 127.0.0.1:9697:9696`,
+		`        published: "8097"`,
+		`      - "7359"`,
+	} {
+		if !strings.Contains(string(rewritten), expected) {
+			t.Errorf("Expected %q in rewritten compose:\n%s", expected, rewritten)
+		}
+	}
+
+	if _, err := RewriteHostPorts([]byte(portsCompose), []PortChange{{Service: "gluetun", Protocol: "tcp", From: 1234, To: 1235}}); err == nil {
+		t.Error("Expected an error for a port the service does not publish")
+	}
+}
+
+func TestFixComposePortsKeepsBackup(t *testing.T) {
+	tmpDir := t.TempDir()
+	composePath := filepath.Join(tmpDir, "docker-compose.yml")
+	if err := os.WriteFile(composePath, []byte(portsCompose), 0644); err != nil {
+		t.Fatalf("Failed to write compose: %v", err)
+	}
+
+	before, after, err := FixComposePorts(tmpDir, []PortChange{{Service: "gluetun", Protocol: "tcp", From: 8888, To: 8889}})
+	if err != nil {
+		t.Fatalf("Failed to fix ports: %v", err)
+	}
+	if string(before) != portsCompose || !strings.Contains(string(after), `"8889:8888"`) {
+		t.Errorf("Unexpected before/after content:\n%s", after)
+	}
+
+	written, _ := os.ReadFile(composePath)
+	if string(written) != string(after) {
+		t.Error("Expected the rewritten compose to be written")
+	}
+	backups, _ := filepath.Glob(filepath.Join(tmpDir, "docker-compose.yml.backup.*"))
+	if len(backups) != 1 {
+		t.Fatalf("Expected one backup, got %v", backups)
+	}
+	backup, _ := os.ReadFile(backups[0])
+	if string(backup) != portsCompose {
+		t.Error("Expected the backup to hold the previous compose file")
+	}
+}
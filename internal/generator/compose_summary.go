@@ -0,0 +1,84 @@
+package generator
+
+import (
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ComposeServiceSummary is what Corsarr reads back from a service of an
+// existing docker-compose.yml, generated or written by hand
+type ComposeServiceSummary struct {
+	Image         string
+	ContainerName string
+	NetworkMode   string
+	// Ports and Volumes use the short "<host>:<container>" syntax
+	Ports       []string
+	Volumes     []string
+	Environment map[string]string
+}
+
+// SummarizeCompose reads every service of a compose document. Environment
+// accepts the list and the map syntax, ports and volumes the short and the
+// long syntax.
+func SummarizeCompose(content []byte) (map[string]ComposeServiceSummary, error) {
+	var document struct {
+		Services map[string]struct {
+			Image         string      `yaml:"image"`
+			ContainerName string      `yaml:"container_name"`
+			NetworkMode   string      `yaml:"network_mode"`
+			Ports         []yaml.Node `yaml:"ports"`
+			Volumes       []yaml.Node `yaml:"volumes"`
+			Environment   yaml.Node   `yaml:"environment"`
+		} `yaml:"services"`
+	}
+	if err := yaml.Unmarshal(content, &document); err != nil {
+		return nil, err
+	}
+
+	summaries := make(map[string]ComposeServiceSummary, len(document.Services))
+	for name, service := range document.Services {
+		summary := ComposeServiceSummary{
+			Image:         service.Image,
+			ContainerName: service.ContainerName,
+			NetworkMode:   service.NetworkMode,
+			Environment:   make(map[string]string),
+		}
+		for _, port := range service.Ports {
+			summary.Ports = append(summary.Ports, nodeSpec(port, "published", "target"))
+		}
+		for _, volume := range service.Volumes {
+			summary.Volumes = append(summary.Volumes, nodeSpec(volume, "source", "target"))
+		}
+		switch service.Environment.Kind {
+		case yaml.SequenceNode:
+			for _, entry := range service.Environment.Content {
+				key, value, _ := strings.Cut(entry.Value, "=")
+				summary.Environment[key] = value
+			}
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(service.Environment.Content); i += 2 {
+				summary.Environment[service.Environment.Content[i].Value] = service.Environment.Content[i+1].Value
+			}
+		}
+		summaries[name] = summary
+	}
+	return summaries, nil
+}
+
+// nodeSpec renders a short syntax entry as is and a long syntax entry as
+// "<source>:<target>"
+func nodeSpec(node yaml.Node, source, target string) string {
+	if node.Kind != yaml.MappingNode {
+		return node.Value
+	}
+	fields := make(map[string]string)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		fields[node.Content[i].Value] = node.Content[i+1].Value
+	}
+	spec := fields[source] + ":" + fields[target]
+	if protocol := fields["protocol"]; protocol != "" && protocol != "tcp" {
+		spec += "/" + protocol
+	}
+	return spec
+}
@@ -184,3 +184,25 @@ func TestComposeGenerator_Generate(t *testing.T) {
 		}
 	})
 }
+
+func TestComposeGenerator_PortOverrides(t *testing.T) {
+	registry, err := services.NewRegistry()
+	if err != nil {
+		t.Fatalf("Failed to create registry: %v", err)
+	}
+	generator := NewComposeGenerator(registry, t.TempDir())
+	generator.SetOptions(ComposeOptions{PortOverrides: services.PortOverrides{"sonarr": "18989"}})
+
+	for _, vpnMode := range []bool{false, true} {
+		content, err := generator.Preview([]string{"sonarr", "prowlarr", "qbittorrent"}, vpnMode)
+		if err != nil {
+			t.Fatalf("Failed to preview (vpn=%v): %v", vpnMode, err)
+		}
+		if !strings.Contains(content, `"18989:8989"`) {
+			t.Errorf("Expected Sonarr on host port 18989 (vpn=%v):\n%s", vpnMode, content)
+		}
+		if strings.Contains(content, `"8989:8989"`) {
+			t.Errorf("Expected the default Sonarr port to be replaced (vpn=%v):\n%s", vpnMode, content)
+		}
+	}
+}
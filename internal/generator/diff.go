@@ -0,0 +1,193 @@
+package generator
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// MaskedValue replaces secret values in diffs
+const MaskedValue = "********"
+
+// ChangeKind tells whether a diff entry was added, removed or modified
+type ChangeKind string
+
+const (
+	ChangeAdded    ChangeKind = "+"
+	ChangeRemoved  ChangeKind = "-"
+	ChangeModified ChangeKind = "~"
+)
+
+// Change is one semantic difference between a file on disk and the output
+// that would replace it. Secret values are already masked.
+type Change struct {
+	Kind ChangeKind
+	// Service is the compose service, empty for .env entries
+	Service string
+	// Field is service, image, network_mode, port, volume or environment
+	Field  string
+	Key    string
+	Before string
+	After  string
+}
+
+// String renders the change as a single line such as
+// "~ radarr environment TZ: UTC -> Europe/Madrid"
+func (c Change) String() string {
+	subject := strings.TrimSpace(strings.Join([]string{c.Service, c.Field, c.Key}, " "))
+	switch {
+	case c.Kind == ChangeModified:
+		return fmt.Sprintf("%s %s: %s -> %s", c.Kind, subject, c.Before, c.After)
+	case c.Field == "service":
+		return fmt.Sprintf("%s %s", c.Kind, subject)
+	case c.Kind == ChangeAdded:
+		return fmt.Sprintf("%s %s: %s", c.Kind, subject, c.After)
+	default:
+		return fmt.Sprintf("%s %s: %s", c.Kind, subject, c.Before)
+	}
+}
+
+var (
+	sensitiveKeyPattern = regexp.MustCompile(`(?i)(PASSWORD|PASSPHRASE|SECRET|TOKEN|PRIVATE_KEY|API_KEY)`)
+	// assignmentPattern matches KEY=value in .env files and compose lists
+	assignmentPattern = regexp.MustCompile(`^(\s*(?:-\s*)?"?)([A-Za-z_][A-Za-z0-9_]*)=(.*?)("?)$`)
+)
+
+// IsSensitiveKey reports whether the values of a variable must not be shown.
+// *_SECRETFILE variables only hold the path of a mounted secret.
+func IsSensitiveKey(key string) bool {
+	if strings.HasSuffix(strings.ToUpper(key), "_SECRETFILE") {
+		return false
+	}
+	return IsSecretEnvVar(key) || sensitiveKeyPattern.MatchString(key)
+}
+
+// maskValue hides secret values, keeping variable references such as
+// ${WIREGUARD_PRIVATE_KEY} readable
+func maskValue(key, value string) string {
+	if value == "" || !IsSensitiveKey(key) || (strings.HasPrefix(value, "${") && strings.HasSuffix(value, "}")) {
+		return value
+	}
+	return MaskedValue
+}
+
+// MaskSecrets masks the values of sensitive KEY=value lines in a .env or
+// compose file, so that the text can be printed or diffed
+func MaskSecrets(text string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		match := assignmentPattern.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		if masked := maskValue(match[2], match[3]); masked != match[3] {
+			lines[i] = match[1] + match[2] + "=" + masked + match[4]
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// DiffCompose compares two docker-compose.yml documents service by service.
+// An empty document stands for a missing file.
+func DiffCompose(before, after []byte) ([]Change, error) {
+	oldServices, err := SummarizeCompose(before)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse current compose file: %w", err)
+	}
+	newServices, err := SummarizeCompose(after)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse generated compose file: %w", err)
+	}
+
+	var changes []Change
+	for _, name := range sortedKeys(oldServices, newServices) {
+		oldService, existed := oldServices[name]
+		newService, exists := newServices[name]
+		switch {
+		case !existed:
+			changes = append(changes, Change{Kind: ChangeAdded, Service: name, Field: "service"})
+		case !exists:
+			changes = append(changes, Change{Kind: ChangeRemoved, Service: name, Field: "service"})
+		default:
+			if oldService.Image != newService.Image {
+				changes = append(changes, Change{Kind: ChangeModified, Service: name, Field: "image", Before: oldService.Image, After: newService.Image})
+			}
+			if oldService.NetworkMode != newService.NetworkMode {
+				changes = append(changes, Change{Kind: ChangeModified, Service: name, Field: "network_mode", Before: oldService.NetworkMode, After: newService.NetworkMode})
+			}
+			changes = append(changes, diffLists(name, "port", oldService.Ports, newService.Ports)...)
+			changes = append(changes, diffLists(name, "volume", oldService.Volumes, newService.Volumes)...)
+			changes = append(changes, diffValues(name, "environment", oldService.Environment, newService.Environment)...)
+		}
+	}
+	return changes, nil
+}
+
+// DiffEnv compares two .env files key by key
+func DiffEnv(before, after string) []Change {
+	return diffValues("", "", ParseEnvFile(before), ParseEnvFile(after))
+}
+
+// diffLists reports the entries only present on one side
+func diffLists(service, field string, before, after []string) []Change {
+	var changes []Change
+	for _, entry := range before {
+		if !containsString(after, entry) {
+			changes = append(changes, Change{Kind: ChangeRemoved, Service: service, Field: field, Before: entry})
+		}
+	}
+	for _, entry := range after {
+		if !containsString(before, entry) {
+			changes = append(changes, Change{Kind: ChangeAdded, Service: service, Field: field, After: entry})
+		}
+	}
+	return changes
+}
+
+// diffValues reports added, removed and modified keys with masked secrets
+func diffValues(service, field string, before, after map[string]string) []Change {
+	var changes []Change
+	for _, key := range sortedKeys(before, after) {
+		oldValue, existed := before[key]
+		newValue, exists := after[key]
+		change := Change{Service: service, Field: field, Key: key, Before: maskValue(key, oldValue), After: maskValue(key, newValue)}
+		switch {
+		case !existed:
+			change.Kind = ChangeAdded
+		case !exists:
+			change.Kind = ChangeRemoved
+		case oldValue != newValue:
+			change.Kind = ChangeModified
+		default:
+			continue
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+// sortedKeys returns the keys of both maps in order
+func sortedKeys[V any](maps ...map[string]V) []string {
+	seen := make(map[string]bool)
+	var keys []string
+	for _, m := range maps {
+		for key := range m {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func containsString(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,85 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDiffCompose(t *testing.T) {
+	before := `services:
+  radarr:
+    image: lscr.io/linuxserver/radarr:latest
+    ports:
+      - 7878:7878
+    environment:
+      - TZ=${TZ}
+  jellyfin:
+    image: jellyfin/jellyfin:latest
+`
+	after := `services:
+  radarr:
+    image: lscr.io/linuxserver/radarr:latest
+    ports:
+      - published: 17878
+        target: 7878
+    environment:
+      TZ: ${TZ}
+      API_TOKEN: hunter2
+  sonarr:
+    image: lscr.io/linuxserver/sonarr:latest
+`
+
+	changes, err := DiffCompose([]byte(before), []byte(after))
+	if err != nil {
+		t.Fatalf("Failed to diff: %v", err)
+	}
+
+	var got []string
+	for _, change := range changes {
+		got = append(got, change.String())
+	}
+	want := []string{
+		"- jellyfin service",
+		"- radarr port: 7878:7878",
+		"+ radarr port: 17878:7878",
+		"+ radarr environment API_TOKEN: ********",
+		"+ sonarr service",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("DiffCompose() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	if changes, err := DiffCompose([]byte(before), []byte(before)); err != nil || len(changes) != 0 {
+		t.Errorf("Expected no changes for identical files, got %v (%v)", changes, err)
+	}
+	if _, err := DiffCompose([]byte("services: ["), []byte(after)); err == nil {
+		t.Error("Expected an error for an invalid compose file")
+	}
+}
+
+func TestDiffEnv(t *testing.T) {
+	before := "# Settings\nTZ=UTC\nPUID=1000\nWIREGUARD_PRIVATE_KEY=old-key\n"
+	after := "TZ=Europe/Madrid\nPGID=1000\nWIREGUARD_PRIVATE_KEY=new-key\n"
+
+	var got []string
+	for _, change := range DiffEnv(before, after) {
+		got = append(got, change.String())
+	}
+	want := []string{
+		"+ PGID: 1000",
+		"- PUID: 1000",
+		"~ TZ: UTC -> Europe/Madrid",
+		"~ WIREGUARD_PRIVATE_KEY: ******** -> ********",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("DiffEnv() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestMaskSecrets(t *testing.T) {
+	input := "WIREGUARD_PRIVATE_KEY=private\nOPENVPN_PASSWORD=\nTZ=UTC\n      - WIREGUARD_PRIVATE_KEY=${WIREGUARD_PRIVATE_KEY}\n      - \"OPENVPN_PASSWORD=secret\"\n      - WIREGUARD_PRIVATE_KEY_SECRETFILE=/run/secrets/wireguard_private_key\n"
+	want := "WIREGUARD_PRIVATE_KEY=********\nOPENVPN_PASSWORD=\nTZ=UTC\n      - WIREGUARD_PRIVATE_KEY=${WIREGUARD_PRIVATE_KEY}\n      - \"OPENVPN_PASSWORD=********\"\n      - WIREGUARD_PRIVATE_KEY_SECRETFILE=/run/secrets/wireguard_private_key\n"
+	if got := MaskSecrets(input); got != want {
+		t.Errorf("MaskSecrets() =\n%s\nwant\n%s", got, want)
+	}
+}
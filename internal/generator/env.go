@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"text/template"
 	"time"
 )
@@ -28,18 +29,35 @@ type EnvConfig struct {
 	UMASK              string
 	VPNConfig          *VPNConfig
 	CustomEnv          map[string]string
+	// SecretsMode writes the VPN credentials to files under secrets/
+	// instead of .env
+	SecretsMode bool
 }
 
 // VPNConfig holds VPN-specific configuration
 type VPNConfig struct {
-	ServiceProvider     string
-	Type                string
-	WireguardPrivateKey string
-	WireguardPublicKey  string
-	WireguardAddresses  string
+	ServiceProvider       string
+	Type                  string
+	WireguardPrivateKey   string
+	WireguardPublicKey    string
+	WireguardPresharedKey string
+	WireguardAddresses    string
+	WireguardEndpointIP   string
+	WireguardEndpointPort string
+	OpenVPNUser           string
+	OpenVPNPassword       string
+	// OpenVPNCustomConfig is the path of the .ovpn file inside the Gluetun
+	// container for the custom provider
+	OpenVPNCustomConfig string
 	ServerCountries     string
+	ServerCities        string
+	ServerHostnames     string
+	ServerRegions       string
 	PortForwarding      string
 	DNSAddress          string
+	// FirewallOutboundSubnets are the comma-separated LAN subnets the
+	// services behind Gluetun reach outside the tunnel
+	FirewallOutboundSubnets string
 }
 
 // NewEnvGenerator creates a new env generator
@@ -58,6 +76,10 @@ func (g *EnvGenerator) Generate(config *EnvConfig, backup bool) error {
 		}
 	}
 
+	if err := WriteSecrets(g.outputDir, config.Secrets()); err != nil {
+		return err
+	}
+
 	// Generate env file
 	content, err := g.renderTemplate(config)
 	if err != nil {
@@ -128,6 +150,28 @@ func (g *EnvGenerator) Preview(config *EnvConfig) (string, error) {
 	return g.renderTemplate(config)
 }
 
+// envValues returns the variables written to .env, keyed by name
+func envValues(config *EnvConfig) map[string]string {
+	values := map[string]string{
+		"COMPOSE_PROJECT_NAME": config.ComposeProjectName,
+		"ARRPATH":              config.ARRPath,
+		"TZ":                   config.Timezone,
+		"PUID":                 config.PUID,
+		"PGID":                 config.PGID,
+		"UMASK":                config.UMASK,
+	}
+	for _, variable := range config.VPNEnv() {
+		values[variable.Key] = variable.Value
+	}
+	for key, value := range config.CustomEnv {
+		values[key] = value
+	}
+	for _, secret := range config.Secrets() {
+		delete(values, secret.EnvVar)
+	}
+	return values
+}
+
 // NewDefaultEnvConfig creates a default environment configuration
 func NewDefaultEnvConfig() *EnvConfig {
 	return &EnvConfig{
@@ -140,3 +184,24 @@ func NewDefaultEnvConfig() *EnvConfig {
 		CustomEnv:          make(map[string]string),
 	}
 }
+
+// ParseEnvFile reads the KEY=value lines of a .env file, skipping comments
+// and blank lines and removing the quotes around values
+func ParseEnvFile(content string) map[string]string {
+	values := make(map[string]string)
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, found := strings.Cut(strings.TrimPrefix(line, "export "), "=")
+		if !found {
+			continue
+		}
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		values[strings.TrimSpace(key)] = value
+	}
+	return values
+}
@@ -0,0 +1,450 @@
+package generator
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/woliveiras/corsarr/internal/services"
+)
+
+// Output formats accepted by the generate command
+const (
+	FormatCompose    = "compose"
+	FormatKubernetes = "k8s"
+)
+
+const kubernetesManifestFile = "kubernetes.yaml"
+
+var (
+	kubernetesNamePattern  = regexp.MustCompile(`[^a-z0-9-]+`)
+	kubernetesEnvReference = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+)
+
+// KubernetesStrategy renders Kubernetes manifests for the selected services.
+// In VPN mode every service that routes through Gluetun runs as a container in
+// the Gluetun pod, sharing its network namespace like network_mode does in
+// Compose.
+type KubernetesStrategy struct {
+	vpnMode bool
+	env     *EnvConfig
+}
+
+// KubernetesManifestData holds data for the Kubernetes template
+type KubernetesManifestData struct {
+	Namespace   string
+	ConfigMap   []KubernetesEnvVar
+	Secret      []KubernetesEnvVar
+	Claims      []string
+	Deployments []KubernetesDeployment
+	Services    []KubernetesService
+}
+
+// KubernetesDeployment is a single-replica workload
+type KubernetesDeployment struct {
+	Name       string
+	Containers []KubernetesContainer
+	Volumes    []KubernetesVolume
+}
+
+// KubernetesContainer is a container inside a deployment pod
+type KubernetesContainer struct {
+	Name         string
+	Image        string
+	Ports        []KubernetesPort
+	Env          []KubernetesEnvVar
+	Mounts       []KubernetesMount
+	Capabilities []string
+	// Limits caps memory and CPU and requests extended resources such as
+	// nvidia.com/gpu for GPU transcoding
+	Limits    []KubernetesEnvVar
+	UseSecret bool
+}
+
+// KubernetesEnvVar is a name/value pair for ConfigMaps, Secrets and containers
+type KubernetesEnvVar struct {
+	Name  string
+	Value string
+}
+
+// KubernetesVolume is a pod volume backed by a claim or a host path
+type KubernetesVolume struct {
+	Name      string
+	ClaimName string
+	HostPath  string
+}
+
+// KubernetesMount mounts a pod volume into a container
+type KubernetesMount struct {
+	Name      string
+	MountPath string
+	ReadOnly  bool
+}
+
+// KubernetesPort is a container or service port
+type KubernetesPort struct {
+	Name          string
+	Port          string
+	ContainerPort string
+	Protocol      string
+}
+
+// KubernetesService exposes the ports of one Corsarr service under its
+// container name, so the hostnames used between services keep resolving.
+type KubernetesService struct {
+	Name     string
+	Selector string
+	Ports    []KubernetesPort
+}
+
+// NewKubernetesStrategy creates a strategy that renders Kubernetes manifests
+func NewKubernetesStrategy(vpnMode bool, env *EnvConfig) *KubernetesStrategy {
+	return &KubernetesStrategy{vpnMode: vpnMode, env: env}
+}
+
+// GenerateCompose renders Kubernetes manifests in place of a compose file
+func (s *KubernetesStrategy) GenerateCompose(selectedServices []*services.Service) (string, error) {
+	if proxy := findProxy(selectedServices); proxy != nil {
+		return "", fmt.Errorf("%s is not supported in Kubernetes manifests; use an Ingress controller instead", proxy.ID)
+	}
+	data, err := s.buildManifestData(selectedServices)
+	if err != nil {
+		return "", err
+	}
+	return renderTemplate(s.GetTemplatePath(), "kubernetes", data)
+}
+
+// GetTemplatePath returns the template path for Kubernetes manifests
+func (s *KubernetesStrategy) GetTemplatePath() string {
+	return "templates/kubernetes/manifests.tmpl"
+}
+
+func (s *KubernetesStrategy) buildManifestData(selectedServices []*services.Service) (*KubernetesManifestData, error) {
+	env := s.env
+	if env == nil {
+		env = NewDefaultEnvConfig()
+	}
+
+	namespace := kubernetesName(env.ComposeProjectName)
+	if namespace == "" {
+		namespace = "corsarr"
+	}
+	data := &KubernetesManifestData{Namespace: namespace}
+	data.ConfigMap, data.Secret = kubernetesEnv(env)
+
+	var gluetun *services.Service
+	var routed []*services.Service
+	var standalone []*services.Service
+	for _, svc := range selectedServices {
+		switch {
+		case svc.Category == services.CategoryVPN:
+			gluetun = svc
+		case s.vpnMode && strings.HasPrefix(svc.Network.VPNMode.NetworkMode, "service:"):
+			routed = append(routed, svc)
+		default:
+			standalone = append(standalone, svc)
+		}
+	}
+	if s.vpnMode && gluetun == nil {
+		return nil, fmt.Errorf("gluetun service not found in VPN mode")
+	}
+
+	if s.vpnMode {
+		// Gluetun goes first so the tunnel container starts before its
+		// dependents, mirroring depends_on in the Compose output.
+		members := append([]*services.Service{gluetun}, routed...)
+		deployment := KubernetesDeployment{Name: kubernetesName(gluetun.ContainerName)}
+		routedPorts := make(map[string]bool)
+		for _, svc := range routed {
+			for _, port := range svc.Ports {
+				routedPorts[port.Host+"/"+port.Protocol] = true
+			}
+		}
+		for _, svc := range members {
+			container, volumes, err := kubernetesContainer(svc, env.ARRPath)
+			if err != nil {
+				return nil, err
+			}
+
+			ports := svc.Ports
+			if svc == gluetun {
+				// Gluetun also lists the ports of the services behind it;
+				// those are published by each service's own Service object.
+				ports = nil
+				for _, port := range gluetun.Ports {
+					if !routedPorts[port.Host+"/"+port.Protocol] {
+						ports = append(ports, port)
+					}
+				}
+				container.Ports = kubernetesContainerPorts(ports)
+				container.UseSecret = len(data.Secret) > 0
+			}
+			deployment.Containers = append(deployment.Containers, container)
+			deployment.Volumes = append(deployment.Volumes, volumes...)
+
+			if service, ok := kubernetesServiceFor(svc, deployment.Name, ports); ok {
+				data.Services = append(data.Services, service)
+			}
+		}
+		data.Deployments = append(data.Deployments, deployment)
+	}
+
+	for _, svc := range standalone {
+		container, volumes, err := kubernetesContainer(svc, env.ARRPath)
+		if err != nil {
+			return nil, err
+		}
+		deployment := KubernetesDeployment{
+			Name:       container.Name,
+			Containers: []KubernetesContainer{container},
+			Volumes:    volumes,
+		}
+		data.Deployments = append(data.Deployments, deployment)
+		if service, ok := kubernetesServiceFor(svc, deployment.Name, svc.Ports); ok {
+			data.Services = append(data.Services, service)
+		}
+	}
+
+	for _, deployment := range data.Deployments {
+		for _, volume := range deployment.Volumes {
+			if volume.ClaimName != "" {
+				data.Claims = append(data.Claims, volume.ClaimName)
+			}
+		}
+	}
+
+	return data, nil
+}
+
+// kubernetesEnv splits the .env values into ConfigMap and Secret entries
+func kubernetesEnv(env *EnvConfig) ([]KubernetesEnvVar, []KubernetesEnvVar) {
+	values := envValues(env)
+	delete(values, "COMPOSE_PROJECT_NAME")
+	delete(values, "ARRPATH")
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var configMap, secret []KubernetesEnvVar
+	for _, key := range keys {
+		if values[key] == "" {
+			continue
+		}
+		entry := KubernetesEnvVar{Name: key, Value: values[key]}
+		// Secrets are moved from the .env values into the VPN Secret
+		if IsSecretEnvVar(key) {
+			secret = append(secret, entry)
+		} else {
+			configMap = append(configMap, entry)
+		}
+	}
+	return configMap, secret
+}
+
+// kubernetesContainer converts a service definition into a container and the
+// pod volumes it mounts
+func kubernetesContainer(svc *services.Service, arrPath string) (KubernetesContainer, []KubernetesVolume, error) {
+	name := kubernetesName(svc.ContainerName)
+	container := KubernetesContainer{
+		Name:         name,
+		Image:        svc.Image,
+		Capabilities: svc.CapAdd,
+	}
+	if svc.Resources.CPUs != "" {
+		container.Limits = append(container.Limits, KubernetesEnvVar{Name: "cpu", Value: svc.Resources.CPUs})
+	}
+	if svc.Resources.MemLimit != "" {
+		container.Limits = append(container.Limits, KubernetesEnvVar{Name: "memory", Value: kubernetesQuantity(svc.Resources.MemLimit)})
+	}
+	if svc.GPU != nil {
+		// Device plugins expose GPUs as extended resources
+		container.Limits = append(container.Limits, KubernetesEnvVar{Name: svc.GPU.Driver + ".com/gpu", Value: "1"})
+	}
+
+	for _, port := range svc.Ports {
+		if strings.Contains(port.Host, "-") || strings.Contains(port.Container, "-") {
+			return container, nil, fmt.Errorf("service %s: port ranges are not supported in Kubernetes output", svc.ID)
+		}
+	}
+	container.Ports = kubernetesContainerPorts(svc.Ports)
+
+	for _, entry := range svc.Environment {
+		key, value, found := strings.Cut(entry, "=")
+		if !found || value == "${"+key+"}" {
+			// Variables taken verbatim from .env come from the ConfigMap
+			continue
+		}
+		container.Env = append(container.Env, KubernetesEnvVar{
+			Name:  key,
+			Value: kubernetesEnvReference.ReplaceAllString(value, "$$($1)"),
+		})
+	}
+
+	var volumes []KubernetesVolume
+	for i, volume := range svc.Volumes {
+		volumeName := fmt.Sprintf("%s-%d", name, i)
+		hostPath := strings.ReplaceAll(volume.Host, "${ARRPATH}", arrPath)
+		switch {
+		case volume.Container == "/config":
+			volumeName = name + "-config"
+			volumes = append(volumes, KubernetesVolume{Name: volumeName, ClaimName: volumeName})
+		case path.IsAbs(hostPath):
+			volumes = append(volumes, KubernetesVolume{Name: volumeName, HostPath: path.Clean(hostPath)})
+		default:
+			return container, nil, fmt.Errorf("service %s: hostPath volume %q must be absolute", svc.ID, hostPath)
+		}
+		container.Mounts = append(container.Mounts, KubernetesMount{
+			Name:      volumeName,
+			MountPath: volume.Container,
+			ReadOnly:  volume.ReadOnly,
+		})
+	}
+
+	for i, device := range svc.Devices {
+		hostDevice, containerDevice, found := strings.Cut(device, ":")
+		if !found {
+			containerDevice = hostDevice
+		}
+		containerDevice, _, _ = strings.Cut(containerDevice, ":")
+		volumeName := fmt.Sprintf("%s-device-%d", name, i)
+		volumes = append(volumes, KubernetesVolume{Name: volumeName, HostPath: hostDevice})
+		container.Mounts = append(container.Mounts, KubernetesMount{Name: volumeName, MountPath: containerDevice})
+	}
+
+	return container, volumes, nil
+}
+
+// kubernetesQuantity converts a Docker byte size (512m, 2g, 10mb) to a
+// Kubernetes binary quantity (512Mi, 2Gi, 10Mi)
+func kubernetesQuantity(size string) string {
+	value := strings.TrimSuffix(strings.ToLower(size), "b")
+	suffixes := map[string]string{"k": "Ki", "m": "Mi", "g": "Gi"}
+	if len(value) > 0 {
+		if suffix, ok := suffixes[value[len(value)-1:]]; ok {
+			return value[:len(value)-1] + suffix
+		}
+	}
+	return value
+}
+
+func kubernetesContainerPorts(ports []services.PortMapping) []KubernetesPort {
+	var containerPorts []KubernetesPort
+	for _, port := range ports {
+		containerPorts = append(containerPorts, KubernetesPort{
+			ContainerPort: port.Container,
+			Protocol:      strings.ToUpper(kubernetesProtocol(port)),
+		})
+	}
+	return containerPorts
+}
+
+// kubernetesServiceFor publishes the host ports of a service through a
+// LoadBalancer Service named after the container
+func kubernetesServiceFor(svc *services.Service, selector string, ports []services.PortMapping) (KubernetesService, bool) {
+	if len(ports) == 0 {
+		return KubernetesService{}, false
+	}
+	service := KubernetesService{
+		Name:     kubernetesName(svc.ContainerName),
+		Selector: selector,
+	}
+	for _, port := range ports {
+		protocol := kubernetesProtocol(port)
+		service.Ports = append(service.Ports, KubernetesPort{
+			Name:          fmt.Sprintf("%s-%s", protocol, port.Host),
+			Port:          port.Host,
+			ContainerPort: port.Container,
+			Protocol:      strings.ToUpper(protocol),
+		})
+	}
+	return service, true
+}
+
+// kubernetesProtocol returns the lowercase protocol of a mapping, defaulting
+// to tcp like compose does when a definition leaves it out
+func kubernetesProtocol(port services.PortMapping) string {
+	if port.Protocol == "" {
+		return "tcp"
+	}
+	return strings.ToLower(port.Protocol)
+}
+
+// kubernetesName converts an identifier into a DNS-1123 label
+func kubernetesName(value string) string {
+	name := kubernetesNamePattern.ReplaceAllString(strings.ToLower(value), "-")
+	name = strings.Trim(name, "-")
+	if len(name) > 63 {
+		name = strings.TrimRight(name[:63], "-")
+	}
+	return name
+}
+
+// KubernetesGenerator handles kubernetes.yaml generation
+type KubernetesGenerator struct {
+	compose   *ComposeGenerator
+	outputDir string
+}
+
+// NewKubernetesGenerator creates a new Kubernetes manifest generator
+func NewKubernetesGenerator(registry *services.Registry, outputDir string) *KubernetesGenerator {
+	return &KubernetesGenerator{
+		compose:   NewComposeGenerator(registry, outputDir),
+		outputDir: outputDir,
+	}
+}
+
+// SetOptions sets the rendering options such as host port overrides
+func (g *KubernetesGenerator) SetOptions(options ComposeOptions) {
+	g.compose.SetOptions(options)
+}
+
+// Generate writes kubernetes.yaml for the selected services. The file holds
+// a Secret when VPN credentials are present, so it is written with 0600.
+func (g *KubernetesGenerator) Generate(serviceIDs []string, vpnMode bool, env *EnvConfig, backup bool) error {
+	selectedServices, err := g.compose.prepareServices(serviceIDs, vpnMode)
+	if err != nil {
+		return err
+	}
+	if err := g.compose.validateServices(selectedServices); err != nil {
+		return err
+	}
+
+	if backup {
+		if err := backupFile(g.outputDir, kubernetesManifestFile, 0600); err != nil {
+			return fmt.Errorf("failed to backup existing file: %w", err)
+		}
+	}
+
+	content, err := NewKubernetesStrategy(vpnMode, env).GenerateCompose(selectedServices)
+	if err != nil {
+		return fmt.Errorf("failed to generate Kubernetes manifests: %w", err)
+	}
+
+	outputPath := filepath.Join(g.outputDir, kubernetesManifestFile)
+	if err := os.WriteFile(outputPath, []byte(content), 0600); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+
+	return nil
+}
+
+// Preview generates the manifests without writing to file
+func (g *KubernetesGenerator) Preview(serviceIDs []string, vpnMode bool, env *EnvConfig) (string, error) {
+	selectedServices, err := g.compose.prepareServices(serviceIDs, vpnMode)
+	if err != nil {
+		return "", err
+	}
+	return NewKubernetesStrategy(vpnMode, env).GenerateCompose(selectedServices)
+}
+
+// OutputPath returns the path of the generated manifest file
+func (g *KubernetesGenerator) OutputPath() string {
+	return filepath.Join(g.outputDir, kubernetesManifestFile)
+}
@@ -0,0 +1,221 @@
+package generator
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/woliveiras/corsarr/internal/services"
+	"gopkg.in/yaml.v3"
+)
+
+func decodeManifests(t *testing.T, content string) []map[string]interface{} {
+	t.Helper()
+	decoder := yaml.NewDecoder(strings.NewReader(content))
+	var documents []map[string]interface{}
+	for {
+		var document map[string]interface{}
+		err := decoder.Decode(&document)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Generated manifests are not valid YAML: %v\n%s", err, content)
+		}
+		documents = append(documents, document)
+	}
+	return documents
+}
+
+func findManifest(documents []map[string]interface{}, kind, name string) map[string]interface{} {
+	for _, document := range documents {
+		metadata, _ := document["metadata"].(map[string]interface{})
+		if document["kind"] == kind && metadata["name"] == name {
+			return document
+		}
+	}
+	return nil
+}
+
+func TestKubernetesStrategy_BridgeMode(t *testing.T) {
+	registry, err := services.NewRegistry()
+	if err != nil {
+		t.Fatalf("Failed to create registry: %v", err)
+	}
+	generator := NewKubernetesGenerator(registry, t.TempDir())
+
+	env := NewDefaultEnvConfig()
+	env.ARRPath = "/srv/corsarr/"
+	content, err := generator.Preview([]string{"qbittorrent", "radarr"}, false, env)
+	if err != nil {
+		t.Fatalf("Failed to preview: %v", err)
+	}
+	documents := decodeManifests(t, content)
+
+	if findManifest(documents, "Namespace", "corsarr") == nil {
+		t.Error("Expected corsarr namespace")
+	}
+	configMap := findManifest(documents, "ConfigMap", "corsarr-env")
+	if configMap == nil || configMap["data"].(map[string]interface{})["PUID"] != "1000" {
+		t.Errorf("Expected .env values in the ConfigMap, got %v", configMap)
+	}
+	if findManifest(documents, "Secret", "corsarr-vpn") != nil {
+		t.Error("Did not expect a VPN Secret in bridge mode")
+	}
+	for _, name := range []string{"qbittorrent", "radarr"} {
+		if findManifest(documents, "Deployment", name) == nil {
+			t.Errorf("Expected Deployment %s", name)
+		}
+		if findManifest(documents, "Service", name) == nil {
+			t.Errorf("Expected Service %s", name)
+		}
+		if findManifest(documents, "PersistentVolumeClaim", name+"-config") == nil {
+			t.Errorf("Expected config claim for %s", name)
+		}
+	}
+	if !strings.Contains(content, `path: "/srv/corsarr/data/movies"`) {
+		t.Errorf("Expected ARRPATH to be resolved in hostPath volumes:\n%s", content)
+	}
+	if !strings.Contains(content, `value: "8081"`) {
+		t.Errorf("Expected service-specific environment to be kept:\n%s", content)
+	}
+}
+
+func TestKubernetesStrategy_VPNModeUsesGluetunSidecar(t *testing.T) {
+	registry, err := services.NewRegistry()
+	if err != nil {
+		t.Fatalf("Failed to create registry: %v", err)
+	}
+	generator := NewKubernetesGenerator(registry, t.TempDir())
+
+	env := NewDefaultEnvConfig()
+	env.VPNConfig = &VPNConfig{
+		ServiceProvider:     "protonvpn",
+		Type:                "wireguard",
+		WireguardPrivateKey: "private-key",
+	}
+	content, err := generator.Preview([]string{"qbittorrent", "prowlarr"}, true, env)
+	if err != nil {
+		t.Fatalf("Failed to preview: %v", err)
+	}
+	documents := decodeManifests(t, content)
+
+	deployment := findManifest(documents, "Deployment", "gluetun")
+	if deployment == nil {
+		t.Fatalf("Expected a gluetun Deployment:\n%s", content)
+	}
+	if findManifest(documents, "Deployment", "qbittorrent") != nil {
+		t.Error("Expected qBittorrent to run inside the gluetun pod")
+	}
+	spec := deployment["spec"].(map[string]interface{})["template"].(map[string]interface{})["spec"].(map[string]interface{})
+	containers := spec["containers"].([]interface{})
+	var names []string
+	for _, container := range containers {
+		names = append(names, container.(map[string]interface{})["name"].(string))
+	}
+	if strings.Join(names, ",") != "gluetun,qbittorrent,prowlarr" {
+		t.Errorf("Unexpected gluetun pod containers: %v", names)
+	}
+
+	secret := findManifest(documents, "Secret", "corsarr-vpn")
+	if secret == nil || secret["stringData"].(map[string]interface{})["WIREGUARD_PRIVATE_KEY"] != "private-key" {
+		t.Errorf("Expected WireGuard key in the VPN Secret, got %v", secret)
+	}
+	configMap := findManifest(documents, "ConfigMap", "corsarr-env")
+	if _, leaked := configMap["data"].(map[string]interface{})["WIREGUARD_PRIVATE_KEY"]; leaked {
+		t.Error("WireGuard private key must not be stored in the ConfigMap")
+	}
+
+	service := findManifest(documents, "Service", "qbittorrent")
+	if service == nil {
+		t.Fatal("Expected a qbittorrent Service")
+	}
+	selector := service["spec"].(map[string]interface{})["selector"].(map[string]interface{})
+	if selector["app.kubernetes.io/name"] != "gluetun" {
+		t.Errorf("Expected qbittorrent Service to select the gluetun pod, got %v", selector)
+	}
+	gluetunService := findManifest(documents, "Service", "gluetun")
+	for _, port := range gluetunService["spec"].(map[string]interface{})["ports"].([]interface{}) {
+		if port.(map[string]interface{})["port"] == 8081 {
+			t.Error("Expected qBittorrent port to be published by its own Service only")
+		}
+	}
+}
+
+func TestKubernetesStrategy_RejectsRelativeHostPath(t *testing.T) {
+	env := NewDefaultEnvConfig()
+	env.ARRPath = "./data/"
+	strategy := NewKubernetesStrategy(false, env)
+
+	_, err := strategy.GenerateCompose([]*services.Service{{
+		ID:            "radarr",
+		ContainerName: "radarr",
+		Image:         "lscr.io/linuxserver/radarr:latest",
+		Volumes:       []services.VolumeMapping{{Host: "${ARRPATH}data/movies", Container: "/movies"}},
+	}})
+	if err == nil || !strings.Contains(err.Error(), "must be absolute") {
+		t.Errorf("Expected relative hostPath error, got %v", err)
+	}
+}
+
+func TestKubernetesGenerator_Generate(t *testing.T) {
+	registry, err := services.NewRegistry()
+	if err != nil {
+		t.Fatalf("Failed to create registry: %v", err)
+	}
+	tmpDir := t.TempDir()
+	generator := NewKubernetesGenerator(registry, tmpDir)
+
+	if err := generator.Generate([]string{"prowlarr"}, false, NewDefaultEnvConfig(), true); err != nil {
+		t.Fatalf("Failed to generate: %v", err)
+	}
+	info, err := os.Stat(filepath.Join(tmpDir, "kubernetes.yaml"))
+	if err != nil {
+		t.Fatalf("Expected kubernetes.yaml: %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("Expected 0600 permissions, got %v", info.Mode().Perm())
+	}
+}
+
+func TestKubernetesQuantity(t *testing.T) {
+	tests := map[string]string{
+		"512m": "512Mi",
+		"2G":   "2Gi",
+		"1.5g": "1.5Gi",
+		"10mb": "10Mi",
+		"64k":  "64Ki",
+		"1024": "1024",
+	}
+	for size, expected := range tests {
+		if got := kubernetesQuantity(size); got != expected {
+			t.Errorf("kubernetesQuantity(%q) = %q, want %q", size, got, expected)
+		}
+	}
+}
+
+func TestKubernetesPorts_DefaultProtocol(t *testing.T) {
+	ports := []services.PortMapping{
+		{Host: "8080", Container: "80"},
+		{Host: "53", Container: "53", Protocol: "udp"},
+	}
+
+	containerPorts := kubernetesContainerPorts(ports)
+	if containerPorts[0].Protocol != "TCP" || containerPorts[1].Protocol != "UDP" {
+		t.Errorf("Unexpected container port protocols: %+v", containerPorts)
+	}
+
+	service, ok := kubernetesServiceFor(&services.Service{ContainerName: "custom"}, "custom", ports)
+	if !ok {
+		t.Fatal("Expected a Service for published ports")
+	}
+	if service.Ports[0].Protocol != "TCP" || service.Ports[0].Name != "tcp-8080" {
+		t.Errorf("Expected an empty protocol to default to tcp, got %+v", service.Ports[0])
+	}
+	if service.Ports[1].Protocol != "UDP" || service.Ports[1].Name != "udp-53" {
+		t.Errorf("Unexpected udp port: %+v", service.Ports[1])
+	}
+}
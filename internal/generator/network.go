@@ -2,6 +2,7 @@ package generator
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/woliveiras/corsarr/internal/services"
 )
@@ -62,7 +63,8 @@ func ValidateNetworkConfiguration(selectedServices []*services.Service, vpnMode
 }
 
 // GetExposedPorts returns all exposed ports for VPN mode
-// In VPN mode, all service ports must be exposed through Gluetun
+// In VPN mode, all service ports must be exposed through Gluetun, except
+// those of services routed around the tunnel
 func GetExposedPorts(selectedServices []*services.Service, vpnMode bool) []services.PortMapping {
 	if !vpnMode {
 		return nil // In bridge mode, each service exposes its own ports
@@ -70,8 +72,8 @@ func GetExposedPorts(selectedServices []*services.Service, vpnMode bool) []servi
 
 	var ports []services.PortMapping
 	for _, service := range selectedServices {
-		// Skip Gluetun itself
-		if service.Category == services.CategoryVPN {
+		// Skip Gluetun itself and services publishing their own ports
+		if service.Category == services.CategoryVPN || bypassesVPN(service) {
 			continue
 		}
 
@@ -83,3 +85,108 @@ func GetExposedPorts(selectedServices []*services.Service, vpnMode bool) []servi
 
 	return ports
 }
+
+// bypassesVPN reports whether a service joins its bridge networks instead of
+// Gluetun's network namespace in VPN mode
+func bypassesVPN(service *services.Service) bool {
+	return service.Category != services.CategoryVPN && service.Network.VPNMode.NetworkMode == services.RouteBridge
+}
+
+// hybridVPN reports whether any selected service is routed around the tunnel
+func hybridVPN(selectedServices []*services.Service) bool {
+	for _, service := range selectedServices {
+		if bypassesVPN(service) {
+			return true
+		}
+	}
+	return false
+}
+
+// ServiceAddress is where other containers reach a service in VPN mode
+type ServiceAddress struct {
+	ServiceID string
+	// Route is services.RouteTunnel or services.RouteBridge
+	Route string
+	// Tunneled is the host:port used by services sharing Gluetun's namespace
+	Tunneled string
+	// Bridged is the host:port used by services on the bridge networks
+	Bridged string
+}
+
+// ServiceAddresses lists the address of each selected service's main port as
+// seen from both sides of the tunnel, so download clients and indexers can be
+// configured with the right hostname. Services without ports are left out.
+func ServiceAddresses(selectedServices []*services.Service) []ServiceAddress {
+	var tunneled, bridged *services.Service
+	for _, svc := range selectedServices {
+		switch {
+		case svc.Category == services.CategoryVPN:
+		case bypassesVPN(svc):
+			if bridged == nil {
+				bridged = svc
+			}
+		case tunneled == nil && strings.HasPrefix(svc.Network.VPNMode.NetworkMode, "service:"):
+			tunneled = svc
+		}
+	}
+	// A service on each side of the tunnel stands for the others
+	if tunneled == nil {
+		tunneled = &services.Service{Network: services.NetworkConfig{VPNMode: services.VPNModeConfig{NetworkMode: "service:gluetun"}}}
+	}
+	if bridged == nil {
+		bridged = &services.Service{Network: services.NetworkConfig{VPNMode: services.VPNModeConfig{NetworkMode: services.RouteBridge}}}
+	}
+
+	var addresses []ServiceAddress
+	for _, svc := range selectedServices {
+		port := mainContainerPort(svc)
+		if svc.Category == services.CategoryVPN || port == "" {
+			continue
+		}
+		address := ServiceAddress{
+			ServiceID: svc.ID,
+			Route:     services.RouteTunnel,
+			Tunneled:  serviceHost(tunneled, svc, true) + ":" + port,
+			Bridged:   serviceHost(bridged, svc, true) + ":" + port,
+		}
+		if bypassesVPN(svc) {
+			address.Route = services.RouteBridge
+		}
+		addresses = append(addresses, address)
+	}
+	return addresses
+}
+
+// serviceHost returns the hostname one service uses to reach another. In VPN
+// mode services behind Gluetun share its network namespace, so services in
+// the same namespace reach them on localhost and any other service goes
+// through the Gluetun container. Services routed around the tunnel keep
+// their bridge hostname.
+func serviceHost(from, to *services.Service, vpnMode bool) string {
+	if !vpnMode || bypassesVPN(to) {
+		if to.Network.BridgeMode.Hostname != "" {
+			return to.Network.BridgeMode.Hostname
+		}
+		return to.ContainerName
+	}
+	targetMode := to.Network.VPNMode.NetworkMode
+	if !strings.HasPrefix(targetMode, "service:") {
+		return to.ContainerName
+	}
+	if from.Network.VPNMode.NetworkMode == targetMode {
+		return "localhost"
+	}
+	return strings.TrimPrefix(targetMode, "service:")
+}
+
+// mainContainerPort returns the container port of the web interface, or the
+// first port of services without one
+func mainContainerPort(svc *services.Service) string {
+	if svc.WebUI != nil {
+		return webUIContainerPort(svc)
+	}
+	if len(svc.Ports) > 0 {
+		return svc.Ports[0].Container
+	}
+	return ""
+}
@@ -234,6 +234,33 @@ func TestGetExposedPorts(t *testing.T) {
 			vpnMode:     true,
 			expectedLen: 2, // Excludes Gluetun ports
 		},
+		{
+			name: "VPN mode - skips services routed around the tunnel",
+			services: []*services.Service{
+				{
+					ID:       "gluetun",
+					Category: services.CategoryVPN,
+				},
+				{
+					ID: "radarr",
+					Ports: []services.PortMapping{
+						{Host: "7878", Container: "7878"},
+					},
+				},
+				{
+					ID: "jellyfin",
+					Ports: []services.PortMapping{
+						{Host: "8096", Container: "8096"},
+						{Host: "7359", Container: "7359", Protocol: "udp"},
+					},
+					Network: services.NetworkConfig{
+						VPNMode: services.VPNModeConfig{NetworkMode: services.RouteBridge},
+					},
+				},
+			},
+			vpnMode:     true,
+			expectedLen: 1,
+		},
 		{
 			name: "Bridge mode - returns nil",
 			services: []*services.Service{
@@ -259,3 +286,31 @@ func TestGetExposedPorts(t *testing.T) {
 		})
 	}
 }
+
+func TestServiceAddresses(t *testing.T) {
+	registry, err := services.NewRegistry()
+	if err != nil {
+		t.Fatalf("Failed to create registry: %v", err)
+	}
+	generator := NewComposeGenerator(registry, t.TempDir())
+	generator.SetOptions(ComposeOptions{VPNRoutes: services.VPNRoutes{"request": services.RouteBridge}})
+	selected, err := generator.SelectedServices([]string{"qbittorrent", "sonarr", "jellyseerr"}, true)
+	if err != nil {
+		t.Fatalf("Failed to select services: %v", err)
+	}
+
+	want := map[string]ServiceAddress{
+		"qbittorrent": {ServiceID: "qbittorrent", Route: services.RouteTunnel, Tunneled: "localhost:8081", Bridged: "gluetun:8081"},
+		"sonarr":      {ServiceID: "sonarr", Route: services.RouteTunnel, Tunneled: "localhost:8989", Bridged: "gluetun:8989"},
+		"jellyseerr":  {ServiceID: "jellyseerr", Route: services.RouteBridge, Tunneled: "jellyseerr:5055", Bridged: "jellyseerr:5055"},
+	}
+	addresses := ServiceAddresses(selected)
+	if len(addresses) != len(want) {
+		t.Fatalf("Expected %d addresses, got %+v", len(want), addresses)
+	}
+	for _, address := range addresses {
+		if address != want[address.ServiceID] {
+			t.Errorf("Address of %s = %+v, want %+v", address.ServiceID, address, want[address.ServiceID])
+		}
+	}
+}
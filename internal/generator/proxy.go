@@ -0,0 +1,214 @@
+package generator
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/woliveiras/corsarr/internal/services"
+)
+
+// Reverse proxy routing modes
+const (
+	ProxyRoutingHost = "host"
+	ProxyRoutingPath = "path"
+)
+
+// ProxyConfigFile is the reverse proxy configuration written next to the stack
+const ProxyConfigFile = "Caddyfile"
+
+const (
+	proxyConfigTemplate = "templates/proxy/Caddyfile.tmpl"
+	defaultProxyDomain  = "local"
+)
+
+// ProxyRoute is one reverse proxy entry for a web interface. Path routes are
+// only used for services that can serve their web interface below a URL base;
+// the others keep a host route.
+type ProxyRoute struct {
+	ServiceID  string
+	Host       string
+	Path       string
+	Upstream   string
+	PathRouted bool
+}
+
+// ProxyConfigData holds data for the reverse proxy template
+type ProxyConfigData struct {
+	PathRoutes []ProxyRoute
+	HostRoutes []ProxyRoute
+}
+
+// ValidateProxyRouting checks a routing mode flag value
+func ValidateProxyRouting(routing string) error {
+	switch routing {
+	case "", ProxyRoutingHost, ProxyRoutingPath:
+		return nil
+	default:
+		return fmt.Errorf("unsupported proxy routing %q (use %s or %s)", routing, ProxyRoutingHost, ProxyRoutingPath)
+	}
+}
+
+// findProxy returns the selected reverse proxy service, if any
+func findProxy(selectedServices []*services.Service) *services.Service {
+	for _, svc := range selectedServices {
+		if svc.Category == services.CategoryProxy {
+			return svc
+		}
+	}
+	return nil
+}
+
+// applyProxyOptions routes web interfaces through the selected reverse proxy.
+// Services are cloned so registry definitions stay untouched: path routes set
+// the URL base variable, and web interface ports stop being published unless
+// PublishAppPorts is set.
+func applyProxyOptions(selectedServices []*services.Service, options ComposeOptions) []*services.Service {
+	if findProxy(selectedServices) == nil {
+		return selectedServices
+	}
+
+	adjusted := make([]*services.Service, 0, len(selectedServices))
+	for _, svc := range selectedServices {
+		if !isProxyTarget(svc) {
+			adjusted = append(adjusted, svc)
+			continue
+		}
+		clone := svc.Clone()
+		if options.ProxyRouting == ProxyRoutingPath && supportsPathRouting(clone) {
+			clone.Environment = append(clone.Environment, clone.WebUI.URLBaseEnv+"=/"+clone.ID)
+		}
+		if !options.PublishAppPorts {
+			// Health probes run inside the container, so keep their port
+			// once the web interface mapping is gone
+			if clone.Healthcheck != nil && clone.Healthcheck.Path != "" && clone.Healthcheck.Port == "" {
+				clone.Healthcheck.Port = webUIContainerPort(clone)
+			}
+			// Without the mapping, the web interface is only reachable on its
+			// container port
+			containerPort := webUIContainerPort(clone)
+			ports := clone.Ports[:0]
+			for _, port := range clone.Ports {
+				if port.Host == clone.WebUI.Port && port.Protocol == "tcp" {
+					continue
+				}
+				ports = append(ports, port)
+			}
+			clone.Ports = ports
+			clone.WebUI.Port = containerPort
+		}
+		adjusted = append(adjusted, clone)
+	}
+
+	return adjusted
+}
+
+// isProxyTarget reports whether a service gets a reverse proxy route
+func isProxyTarget(svc *services.Service) bool {
+	return svc.WebUI != nil && svc.Category != services.CategoryProxy && svc.Category != services.CategoryVPN
+}
+
+// supportsPathRouting reports whether a web interface can be served below a
+// path. Interfaces without a URL base setting emit root-relative asset and API
+// URLs, which break once a prefix is stripped, so they keep a host route.
+func supportsPathRouting(svc *services.Service) bool {
+	return svc.WebUI.URLBaseEnv != ""
+}
+
+// HostRoutedServices returns the selected web interfaces that keep a host
+// route because they cannot be served below a path
+func HostRoutedServices(selectedServices []*services.Service) []string {
+	if findProxy(selectedServices) == nil {
+		return nil
+	}
+	var ids []string
+	for _, svc := range selectedServices {
+		if isProxyTarget(svc) && !supportsPathRouting(svc) {
+			ids = append(ids, svc.ID)
+		}
+	}
+	return ids
+}
+
+// BuildProxyRoutes returns one route per selected service with a web interface
+func BuildProxyRoutes(selectedServices []*services.Service, vpnMode bool, options ComposeOptions) []ProxyRoute {
+	proxy := findProxy(selectedServices)
+	if proxy == nil {
+		return nil
+	}
+	domain := strings.Trim(options.ProxyDomain, ".")
+	if domain == "" {
+		domain = defaultProxyDomain
+	}
+
+	var routes []ProxyRoute
+	for _, svc := range selectedServices {
+		if !isProxyTarget(svc) {
+			continue
+		}
+		route := ProxyRoute{
+			ServiceID:  svc.ID,
+			Host:       svc.ID + "." + domain,
+			Path:       "/" + svc.ID,
+			Upstream:   upstreamHost(proxy, svc, vpnMode) + ":" + webUIContainerPort(svc),
+			PathRouted: options.ProxyRouting == ProxyRoutingPath && supportsPathRouting(svc),
+		}
+		routes = append(routes, route)
+	}
+	return routes
+}
+
+// upstreamHost returns the hostname the proxy uses to reach a service
+func upstreamHost(proxy, target *services.Service, vpnMode bool) string {
+	return serviceHost(proxy, target, vpnMode)
+}
+
+// webUIContainerPort maps the published web interface port to the port the
+// service listens on inside its container
+func webUIContainerPort(svc *services.Service) string {
+	for _, port := range svc.Ports {
+		if port.Host == svc.WebUI.Port {
+			return port.Container
+		}
+	}
+	return svc.WebUI.Port
+}
+
+// RenderProxyConfig renders the Caddyfile for the selected services. It
+// returns an empty string when no reverse proxy is selected.
+func RenderProxyConfig(selectedServices []*services.Service, vpnMode bool, options ComposeOptions) (string, error) {
+	if findProxy(selectedServices) == nil {
+		return "", nil
+	}
+	var data ProxyConfigData
+	for _, route := range BuildProxyRoutes(selectedServices, vpnMode, options) {
+		if route.PathRouted {
+			data.PathRoutes = append(data.PathRoutes, route)
+		} else {
+			data.HostRoutes = append(data.HostRoutes, route)
+		}
+	}
+	return renderTemplate(proxyConfigTemplate, "proxy", data)
+}
+
+// writeProxyConfig writes the Caddyfile next to the generated stack when a
+// reverse proxy is selected
+func writeProxyConfig(outputDir string, selectedServices []*services.Service, vpnMode bool, options ComposeOptions, backup bool) error {
+	content, err := RenderProxyConfig(selectedServices, vpnMode, options)
+	if err != nil {
+		return fmt.Errorf("failed to generate %s: %w", ProxyConfigFile, err)
+	}
+	if content == "" {
+		return nil
+	}
+	if backup {
+		if err := backupFile(outputDir, ProxyConfigFile, 0644); err != nil {
+			return fmt.Errorf("failed to backup existing file: %w", err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(outputDir, ProxyConfigFile), []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	return nil
+}
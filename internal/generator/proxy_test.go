@@ -0,0 +1,205 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/woliveiras/corsarr/internal/services"
+)
+
+func TestComposeGenerator_ProxyHostRoutesBridgeMode(t *testing.T) {
+	registry, err := services.NewRegistry()
+	if err != nil {
+		t.Fatalf("Failed to create registry: %v", err)
+	}
+	tmpDir := t.TempDir()
+	generator := NewComposeGenerator(registry, tmpDir)
+	generator.SetOptions(ComposeOptions{ProxyRouting: ProxyRoutingHost, ProxyDomain: "media.lan"})
+
+	if err := generator.Generate([]string{"caddy", "sonarr", "prowlarr", "qbittorrent"}, false, false); err != nil {
+		t.Fatalf("Failed to generate: %v", err)
+	}
+
+	compose, err := os.ReadFile(filepath.Join(tmpDir, "docker-compose.yml"))
+	if err != nil {
+		t.Fatalf("Failed to read compose: %v", err)
+	}
+	if strings.Contains(string(compose), `"8989:8989"`) {
+		t.Errorf("Expected Sonarr port to stay unpublished behind the proxy:\n%s", compose)
+	}
+	if !strings.Contains(string(compose), `"6881:6881"`) {
+		t.Errorf("Expected non web interface ports to stay published:\n%s", compose)
+	}
+	if !strings.Contains(string(compose), `"80:80"`) {
+		t.Errorf("Expected the proxy port to be published:\n%s", compose)
+	}
+
+	caddyfile, err := os.ReadFile(filepath.Join(tmpDir, ProxyConfigFile))
+	if err != nil {
+		t.Fatalf("Expected a Caddyfile: %v", err)
+	}
+	for _, expected := range []string{
+		"http://sonarr.media.lan {\n\treverse_proxy sonarr:8989",
+		"http://qbittorrent.media.lan {\n\treverse_proxy qbittorrent:8081",
+	} {
+		if !strings.Contains(string(caddyfile), expected) {
+			t.Errorf("Expected %q in Caddyfile:\n%s", expected, caddyfile)
+		}
+	}
+
+	// Registry definitions must not be modified by the proxy options
+	sonarr, _ := registry.GetService("sonarr")
+	if len(sonarr.Ports) == 0 {
+		t.Error("Expected registry Sonarr definition to keep its ports")
+	}
+}
+
+func TestComposeGenerator_ProxyPathRoutesVPNMode(t *testing.T) {
+	registry, err := services.NewRegistry()
+	if err != nil {
+		t.Fatalf("Failed to create registry: %v", err)
+	}
+	generator := NewComposeGenerator(registry, t.TempDir())
+	generator.SetOptions(ComposeOptions{ProxyRouting: ProxyRoutingPath})
+
+	compose, err := generator.Preview([]string{"caddy", "radarr", "qbittorrent"}, true)
+	if err != nil {
+		t.Fatalf("Failed to preview: %v", err)
+	}
+	if !strings.Contains(compose, "RADARR__SERVER__URLBASE=/radarr") {
+		t.Errorf("Expected Radarr URL base for path routing:\n%s", compose)
+	}
+	if strings.Contains(compose, `"7878:7878"`) || strings.Contains(compose, `"8081:8081"`) {
+		t.Errorf("Expected web interface ports to be removed from gluetun:\n%s", compose)
+	}
+
+	caddyfile, err := generator.ProxyConfig([]string{"caddy", "radarr", "qbittorrent"}, true)
+	if err != nil {
+		t.Fatalf("Failed to render Caddyfile: %v", err)
+	}
+	if !strings.Contains(caddyfile, "handle /radarr* {\n\t\treverse_proxy localhost:7878") {
+		t.Errorf("Expected Radarr path route through the shared namespace:\n%s", caddyfile)
+	}
+	if !strings.Contains(caddyfile, "http://qbittorrent.local {\n\treverse_proxy localhost:8081") {
+		t.Errorf("Expected qBittorrent to keep a host route:\n%s", caddyfile)
+	}
+	if strings.Contains(caddyfile, "handle /qbittorrent") {
+		t.Errorf("Did not expect a path route for qBittorrent:\n%s", caddyfile)
+	}
+}
+
+func TestHostRoutedServices(t *testing.T) {
+	registry, err := services.NewRegistry()
+	if err != nil {
+		t.Fatalf("Failed to create registry: %v", err)
+	}
+	selected, err := registry.GetServicesByIDs([]string{"caddy", "sonarr", "jellyfin", "bazarr"})
+	if err != nil {
+		t.Fatalf("Failed to get services: %v", err)
+	}
+
+	ids := HostRoutedServices(selected)
+	if strings.Join(ids, ",") != "jellyfin,bazarr" {
+		t.Errorf("Expected jellyfin and bazarr to keep host routes, got %v", ids)
+	}
+	if ids := HostRoutedServices(selected[1:]); ids != nil {
+		t.Errorf("Expected no host routes without a proxy, got %v", ids)
+	}
+}
+
+func TestComposeGenerator_ProxyPublishAppPorts(t *testing.T) {
+	registry, err := services.NewRegistry()
+	if err != nil {
+		t.Fatalf("Failed to create registry: %v", err)
+	}
+	generator := NewComposeGenerator(registry, t.TempDir())
+	generator.SetOptions(ComposeOptions{PublishAppPorts: true})
+
+	compose, err := generator.Preview([]string{"caddy", "sonarr"}, false)
+	if err != nil {
+		t.Fatalf("Failed to preview: %v", err)
+	}
+	if !strings.Contains(compose, `"8989:8989"`) {
+		t.Errorf("Expected Sonarr port to stay published:\n%s", compose)
+	}
+}
+
+func TestComposeGenerator_ProxyWithPortOverride(t *testing.T) {
+	registry, err := services.NewRegistry()
+	if err != nil {
+		t.Fatalf("Failed to create registry: %v", err)
+	}
+	generator := NewComposeGenerator(registry, t.TempDir())
+	generator.SetOptions(ComposeOptions{PortOverrides: services.PortOverrides{"sonarr": "18989"}})
+	ids := []string{"caddy", "sonarr", "prowlarr", "qbittorrent"}
+
+	caddyfile, err := generator.ProxyConfig(ids, false)
+	if err != nil {
+		t.Fatalf("Failed to render Caddyfile: %v", err)
+	}
+	if !strings.Contains(caddyfile, "reverse_proxy sonarr:8989") {
+		t.Errorf("Expected the proxy to use Sonarr's container port:\n%s", caddyfile)
+	}
+
+	compose, err := generator.Preview(ids, false)
+	if err != nil {
+		t.Fatalf("Failed to preview: %v", err)
+	}
+	if !strings.Contains(compose, "http://localhost:8989/ping") {
+		t.Errorf("Expected the health probe to keep Sonarr's container port:\n%s", compose)
+	}
+}
+
+func TestComposeGenerator_NoProxyConfigWithoutProxy(t *testing.T) {
+	registry, err := services.NewRegistry()
+	if err != nil {
+		t.Fatalf("Failed to create registry: %v", err)
+	}
+	tmpDir := t.TempDir()
+	generator := NewComposeGenerator(registry, tmpDir)
+
+	if err := generator.Generate([]string{"prowlarr"}, false, false); err != nil {
+		t.Fatalf("Failed to generate: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, ProxyConfigFile)); !os.IsNotExist(err) {
+		t.Errorf("Did not expect a Caddyfile without a proxy, got %v", err)
+	}
+}
+
+func TestUpstreamHost(t *testing.T) {
+	proxy := &services.Service{ID: "caddy", Category: services.CategoryProxy}
+	proxy.Network.VPNMode.NetworkMode = "bridge"
+	target := &services.Service{ID: "sonarr", ContainerName: "sonarr"}
+	target.Network.VPNMode.NetworkMode = "service:gluetun"
+
+	if host := upstreamHost(proxy, target, true); host != "gluetun" {
+		t.Errorf("Expected a proxy outside the tunnel to reach Sonarr through gluetun, got %q", host)
+	}
+	proxy.Network.VPNMode.NetworkMode = "service:gluetun"
+	if host := upstreamHost(proxy, target, true); host != "localhost" {
+		t.Errorf("Expected localhost inside the shared namespace, got %q", host)
+	}
+	if host := upstreamHost(proxy, target, false); host != "sonarr" {
+		t.Errorf("Expected the container name in bridge mode, got %q", host)
+	}
+
+	bridged := &services.Service{ID: "jellyfin", ContainerName: "jellyfin"}
+	bridged.Network.VPNMode.NetworkMode = services.RouteBridge
+	bridged.Network.BridgeMode.Hostname = "jellyfin"
+	if host := upstreamHost(proxy, bridged, true); host != "jellyfin" {
+		t.Errorf("Expected a service routed around the tunnel to keep its hostname, got %q", host)
+	}
+}
+
+func TestValidateProxyRouting(t *testing.T) {
+	for _, routing := range []string{"", ProxyRoutingHost, ProxyRoutingPath} {
+		if err := ValidateProxyRouting(routing); err != nil {
+			t.Errorf("Expected %q to be valid: %v", routing, err)
+		}
+	}
+	if err := ValidateProxyRouting("subdomain"); err == nil {
+		t.Error("Expected unsupported routing to fail")
+	}
+}
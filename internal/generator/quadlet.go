@@ -0,0 +1,370 @@
+package generator
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/woliveiras/corsarr/internal/services"
+)
+
+// FormatQuadlet selects Podman Quadlet units as generate output
+const FormatQuadlet = "quadlet"
+
+const (
+	quadletContainerTemplate = "templates/quadlet/container.tmpl"
+	quadletNetworkTemplate   = "templates/quadlet/network.tmpl"
+)
+
+// QuadletUnit is a single generated Quadlet file
+type QuadletUnit struct {
+	Filename string
+	Content  string
+}
+
+// QuadletContainerData holds data for a .container unit
+type QuadletContainerData struct {
+	Description  string
+	Requires     []string
+	Image        string
+	Name         string
+	HostName     string
+	Networks     []string
+	Init         bool
+	PublishPorts []string
+	Volumes      []string
+	EnvFile      string
+	Environment  []string
+	Secrets      []string
+	Devices      []string
+	Groups       []string
+	Capabilities []string
+	Health       *QuadletHealth
+	LogDriver    string
+	LogOptions   []string
+	PodmanArgs   []string
+	Restart      string
+}
+
+// QuadletHealth holds the Health* keys of a .container unit
+type QuadletHealth struct {
+	Cmd         string
+	Interval    string
+	Timeout     string
+	Retries     int
+	StartPeriod string
+}
+
+// QuadletNetworkData holds data for a .network unit
+type QuadletNetworkData struct {
+	Name string
+}
+
+// QuadletGenerator writes Podman Quadlet .container and .network units that
+// systemd turns into services, replacing docker compose on Podman hosts.
+type QuadletGenerator struct {
+	compose   *ComposeGenerator
+	outputDir string
+}
+
+// NewQuadletGenerator creates a new Quadlet generator
+func NewQuadletGenerator(registry *services.Registry, outputDir string) *QuadletGenerator {
+	return &QuadletGenerator{
+		compose:   NewComposeGenerator(registry, outputDir),
+		outputDir: outputDir,
+	}
+}
+
+// SetOptions sets the rendering options such as reverse proxy routing
+func (g *QuadletGenerator) SetOptions(options ComposeOptions) {
+	g.compose.SetOptions(options)
+}
+
+// Render builds the units for the selected services. Each container gets only
+// the variables its definition lists, resolved from .env; Gluetun alone reads
+// .env through EnvironmentFile so the VPN credentials stay out of the units.
+func (g *QuadletGenerator) Render(serviceIDs []string, vpnMode bool, env *EnvConfig) ([]QuadletUnit, error) {
+	selectedServices, err := g.compose.prepareServices(serviceIDs, vpnMode)
+	if err != nil {
+		return nil, err
+	}
+	if env == nil {
+		env = NewDefaultEnvConfig()
+	}
+	values := envValues(env)
+
+	var gluetun *services.Service
+	for _, svc := range selectedServices {
+		if svc.Category == services.CategoryVPN {
+			gluetun = svc
+		}
+	}
+	if vpnMode && gluetun == nil {
+		return nil, fmt.Errorf("gluetun service not found in VPN mode")
+	}
+
+	var units []QuadletUnit
+	networks := make(map[string]bool)
+	for _, svc := range selectedServices {
+		data := QuadletContainerData{
+			Description:  svc.Name,
+			Image:        qualifiedImage(svc.Image),
+			Name:         svc.ContainerName,
+			Init:         svc.Init,
+			Devices:      svc.Devices,
+			Groups:       svc.GroupAdd,
+			Capabilities: svc.CapAdd,
+			Secrets:      svc.Secrets,
+			Health:       quadletHealth(svc),
+			PodmanArgs:   quadletResourceArgs(svc.Resources),
+			Restart:      systemdRestart(svc.Restart),
+		}
+
+		ports := svc.Ports
+		switch {
+		case vpnMode && svc == gluetun:
+			// Gluetun publishes the ports of every service sharing its network
+			ports = append(append([]services.PortMapping{}, svc.Ports...), GetExposedPorts(selectedServices, true)...)
+			if hybridVPN(selectedServices) {
+				// Services routed around the tunnel reach the others through Gluetun
+				data.HostName = svc.Network.BridgeMode.Hostname
+				data.Networks = quadletBridgeNetworks(svc, networks)
+			}
+		case vpnMode && strings.HasPrefix(svc.Network.VPNMode.NetworkMode, "service:"):
+			target := strings.TrimPrefix(svc.Network.VPNMode.NetworkMode, "service:")
+			data.Networks = []string{"container:" + target}
+			data.Requires = append(data.Requires, target+".service")
+			ports = nil
+		default:
+			data.HostName = svc.Network.BridgeMode.Hostname
+			data.Networks = quadletBridgeNetworks(svc, networks)
+		}
+		data.PublishPorts = quadletPorts(ports)
+		if logging := svc.Resources.Logging; logging != nil {
+			data.LogDriver = logging.Driver
+			if logging.MaxSize != "" {
+				// Podman rotates by size only; max_file has no equivalent
+				data.LogOptions = append(data.LogOptions, "max-size="+logging.MaxSize)
+			}
+		}
+		if svc.GPU != nil {
+			// Podman reaches GPUs through CDI devices, e.g. nvidia.com/gpu=all
+			data.Devices = append(append([]string(nil), data.Devices...), svc.GPU.Driver+".com/gpu="+svc.GPU.Count)
+		}
+
+		for _, volume := range svc.Volumes {
+			spec := expandEnvReferences(volume.Host, values) + ":" + volume.Container
+			if volume.ReadOnly {
+				spec += ":ro"
+			}
+			data.Volumes = append(data.Volumes, systemdQuote(spec))
+		}
+		if svc.Category == services.CategoryVPN {
+			data.EnvFile = ".env"
+		}
+		for _, entry := range svc.Environment {
+			key, value, found := strings.Cut(entry, "=")
+			if !found {
+				continue
+			}
+			if value == "${"+key+"}" {
+				if _, set := values[key]; !set || data.EnvFile != "" {
+					// Unset, or taken verbatim from .env through EnvironmentFile
+					continue
+				}
+			}
+			data.Environment = append(data.Environment, systemdQuote(key+"="+expandEnvReferences(value, values)))
+		}
+
+		content, err := renderTemplate(quadletContainerTemplate, "quadlet-container", data)
+		if err != nil {
+			return nil, err
+		}
+		units = append(units, QuadletUnit{Filename: svc.ContainerName + ".container", Content: content})
+	}
+
+	names := make([]string, 0, len(networks))
+	for name := range networks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		content, err := renderTemplate(quadletNetworkTemplate, "quadlet-network", QuadletNetworkData{Name: name})
+		if err != nil {
+			return nil, err
+		}
+		units = append(units, QuadletUnit{Filename: name + ".network", Content: content})
+	}
+
+	return units, nil
+}
+
+// Generate writes the units and the .env they read into the output directory
+func (g *QuadletGenerator) Generate(serviceIDs []string, vpnMode bool, env *EnvConfig, backup bool) ([]QuadletUnit, error) {
+	selectedServices, err := g.compose.prepareServices(serviceIDs, vpnMode)
+	if err != nil {
+		return nil, err
+	}
+	if err := g.compose.validateServices(selectedServices); err != nil {
+		return nil, err
+	}
+	units, err := g.Render(serviceIDs, vpnMode, env)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, unit := range units {
+		if backup {
+			if err := backupFile(g.outputDir, unit.Filename, 0644); err != nil {
+				return nil, fmt.Errorf("failed to backup existing file: %w", err)
+			}
+		}
+		if err := os.WriteFile(filepath.Join(g.outputDir, unit.Filename), []byte(unit.Content), 0644); err != nil {
+			return nil, fmt.Errorf("failed to write file: %w", err)
+		}
+	}
+
+	if err := NewEnvGenerator(g.outputDir).Generate(env, backup); err != nil {
+		return nil, fmt.Errorf("failed to generate .env: %w", err)
+	}
+
+	if err := writeProxyConfig(g.outputDir, selectedServices, vpnMode, g.compose.options, backup); err != nil {
+		return nil, err
+	}
+
+	return units, nil
+}
+
+// Preview renders the units without writing them
+func (g *QuadletGenerator) Preview(serviceIDs []string, vpnMode bool, env *EnvConfig) (string, error) {
+	units, err := g.Render(serviceIDs, vpnMode, env)
+	if err != nil {
+		return "", err
+	}
+	proxyConfig, err := g.compose.ProxyConfig(serviceIDs, vpnMode)
+	if err != nil {
+		return "", err
+	}
+	if proxyConfig != "" {
+		units = append(units, QuadletUnit{Filename: ProxyConfigFile, Content: proxyConfig})
+	}
+
+	var builder strings.Builder
+	for i, unit := range units {
+		if i > 0 {
+			builder.WriteString("\n")
+		}
+		fmt.Fprintf(&builder, "# %s\n%s", unit.Filename, unit.Content)
+	}
+	return builder.String(), nil
+}
+
+// quadletBridgeNetworks returns the Network= values for a bridge-mode service
+// and records the .network units it needs
+func quadletBridgeNetworks(svc *services.Service, networks map[string]bool) []string {
+	var refs []string
+	for _, network := range svc.Network.BridgeMode.Networks {
+		networks[network] = true
+		refs = append(refs, network+".network")
+	}
+	return refs
+}
+
+// quadletPorts converts port mappings into PublishPort= values, dropping
+// duplicates that Podman would reject
+func quadletPorts(ports []services.PortMapping) []string {
+	seen := make(map[string]bool)
+	var published []string
+	for _, port := range ports {
+		protocol := port.Protocol
+		if protocol == "" {
+			protocol = "tcp"
+		}
+		spec := port.Host + ":" + port.Container + "/" + protocol
+		if seen[spec] {
+			continue
+		}
+		seen[spec] = true
+		published = append(published, spec)
+	}
+	return published
+}
+
+// qualifiedImage prefixes Docker Hub images with their registry, since
+// Podman does not resolve short names without an interactive prompt
+func qualifiedImage(image string) string {
+	first, _, found := strings.Cut(image, "/")
+	if found && (strings.ContainsAny(first, ".:") || first == "localhost") {
+		return image
+	}
+	return "docker.io/" + image
+}
+
+// systemdRestart maps Compose restart policies to systemd Restart= values
+func systemdRestart(policy string) string {
+	switch policy {
+	case "no":
+		return "no"
+	case "on-failure":
+		return "on-failure"
+	default:
+		return "always"
+	}
+}
+
+// systemdQuote quotes a unit value when it contains whitespace or quotes
+func systemdQuote(value string) string {
+	if strings.ContainsAny(value, " \t\"'\\") {
+		return strconv.Quote(value)
+	}
+	return value
+}
+
+// quadletResourceArgs passes memory and CPU limits, which have no dedicated
+// Quadlet keys, through PodmanArgs
+func quadletResourceArgs(resources services.ResourceConfig) []string {
+	var args []string
+	if resources.MemLimit != "" {
+		args = append(args, "--memory="+resources.MemLimit)
+	}
+	if resources.CPUs != "" {
+		args = append(args, "--cpus="+resources.CPUs)
+	}
+	return args
+}
+
+// quadletHealth converts the compose healthcheck. Quadlet passes HealthCmd to
+// podman --health-cmd as is: a shell command, or a JSON array for exec form.
+func quadletHealth(svc *services.Service) *QuadletHealth {
+	healthcheck := composeHealthcheck(svc)
+	if healthcheck == nil {
+		return nil
+	}
+	cmd := strings.Join(healthcheck.Test[1:], " ")
+	if healthcheck.Test[0] == "CMD" {
+		encoded, _ := json.Marshal(healthcheck.Test[1:])
+		cmd = string(encoded)
+	}
+	return &QuadletHealth{
+		Cmd:         cmd,
+		Interval:    healthcheck.Interval,
+		Timeout:     healthcheck.Timeout,
+		Retries:     healthcheck.Retries,
+		StartPeriod: healthcheck.StartPeriod,
+	}
+}
+
+// expandEnvReferences replaces ${NAME} with the .env value, leaving unknown
+// references untouched
+func expandEnvReferences(value string, values map[string]string) string {
+	return os.Expand(value, func(name string) string {
+		if resolved, ok := values[name]; ok {
+			return resolved
+		}
+		return "${" + name + "}"
+	})
+}
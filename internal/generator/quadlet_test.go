@@ -0,0 +1,199 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/woliveiras/corsarr/internal/services"
+)
+
+func TestQuadletGenerator_BridgeMode(t *testing.T) {
+	registry, err := services.NewRegistry()
+	if err != nil {
+		t.Fatalf("Failed to create registry: %v", err)
+	}
+	generator := NewQuadletGenerator(registry, t.TempDir())
+
+	env := NewDefaultEnvConfig()
+	env.ARRPath = "/srv/corsarr/"
+	units, err := generator.Render([]string{"qbittorrent", "radarr"}, false, env)
+	if err != nil {
+		t.Fatalf("Failed to render: %v", err)
+	}
+
+	contents := make(map[string]string)
+	for _, unit := range units {
+		contents[unit.Filename] = unit.Content
+	}
+	for _, filename := range []string{"qbittorrent.container", "radarr.container", "media.network"} {
+		if _, ok := contents[filename]; !ok {
+			t.Fatalf("Expected unit %s, got %v", filename, units)
+		}
+	}
+
+	radarr := contents["radarr.container"]
+	for _, expected := range []string{
+		"Image=lscr.io/linuxserver/radarr:latest",
+		"Network=media.network",
+		"HostName=radarr",
+		"PublishPort=7878:7878/tcp",
+		"Volume=/srv/corsarr/config/radarr:/config",
+		"Environment=PUID=1000",
+		"WantedBy=default.target",
+	} {
+		if !strings.Contains(radarr, expected) {
+			t.Errorf("Expected radarr unit to contain %q:\n%s", expected, radarr)
+		}
+	}
+	if strings.Contains(radarr, "${") {
+		t.Errorf("Expected .env references to be resolved:\n%s", radarr)
+	}
+	if strings.Contains(radarr, "EnvironmentFile=") {
+		t.Errorf("Expected only the listed variables, not the whole .env:\n%s", radarr)
+	}
+	if !strings.Contains(contents["media.network"], "NetworkName=media") {
+		t.Errorf("Unexpected network unit:\n%s", contents["media.network"])
+	}
+}
+
+func TestQuadletGenerator_VPNModeSharesGluetunNetwork(t *testing.T) {
+	registry, err := services.NewRegistry()
+	if err != nil {
+		t.Fatalf("Failed to create registry: %v", err)
+	}
+	generator := NewQuadletGenerator(registry, t.TempDir())
+
+	env := NewDefaultEnvConfig()
+	env.VPNConfig = &VPNConfig{ServiceProvider: "protonvpn", Type: "wireguard", WireguardPrivateKey: "private-key"}
+	units, err := generator.Render([]string{"qbittorrent"}, true, env)
+	if err != nil {
+		t.Fatalf("Failed to render: %v", err)
+	}
+	if len(units) != 2 || units[0].Filename != "gluetun.container" {
+		t.Fatalf("Expected gluetun and qbittorrent units, got %v", units)
+	}
+
+	gluetun, qbittorrent := units[0].Content, units[1].Content
+	if !strings.Contains(gluetun, "Image=docker.io/qmcgaw/gluetun:latest") {
+		t.Errorf("Expected fully qualified Gluetun image:\n%s", gluetun)
+	}
+	if strings.Count(gluetun, "PublishPort=8081:8081/tcp") != 1 {
+		t.Errorf("Expected qBittorrent port published once through Gluetun:\n%s", gluetun)
+	}
+	if strings.Contains(gluetun, "private-key") || !strings.Contains(gluetun, "EnvironmentFile=.env") {
+		t.Errorf("Expected Gluetun to read the WireGuard private key from .env:\n%s", gluetun)
+	}
+	if strings.Contains(qbittorrent, "private-key") || strings.Contains(qbittorrent, "EnvironmentFile=") {
+		t.Errorf("VPN credentials must only reach Gluetun:\n%s", qbittorrent)
+	}
+	for _, expected := range []string{"Network=container:gluetun", "Requires=gluetun.service", "After=gluetun.service"} {
+		if !strings.Contains(qbittorrent, expected) {
+			t.Errorf("Expected qbittorrent unit to contain %q:\n%s", expected, qbittorrent)
+		}
+	}
+	if strings.Contains(qbittorrent, "PublishPort=") {
+		t.Errorf("Services behind Gluetun must not publish ports:\n%s", qbittorrent)
+	}
+	if !strings.Contains(gluetun, `HealthCmd=["/gluetun-entrypoint","healthcheck"]`) {
+		t.Errorf("Expected exec form health command for Gluetun:\n%s", gluetun)
+	}
+	if !strings.Contains(qbittorrent, "HealthCmd=curl -fsS -o /dev/null http://localhost:8081/ || exit 1\nHealthInterval=30s") {
+		t.Errorf("Expected shell health command for qBittorrent:\n%s", qbittorrent)
+	}
+}
+
+func TestQuadletGenerator_VPNModeHybridRoutes(t *testing.T) {
+	registry, err := services.NewRegistry()
+	if err != nil {
+		t.Fatalf("Failed to create registry: %v", err)
+	}
+	generator := NewQuadletGenerator(registry, t.TempDir())
+	generator.SetOptions(ComposeOptions{VPNRoutes: services.VPNRoutes{"jellyfin": services.RouteBridge}})
+
+	units, err := generator.Render([]string{"qbittorrent", "jellyfin"}, true, NewDefaultEnvConfig())
+	if err != nil {
+		t.Fatalf("Failed to render: %v", err)
+	}
+	contents := make(map[string]string)
+	for _, unit := range units {
+		contents[unit.Filename] = unit.Content
+	}
+
+	for _, expected := range []string{"HostName=gluetun", "Network=media.network"} {
+		if !strings.Contains(contents["gluetun.container"], expected) {
+			t.Errorf("Expected Gluetun unit to contain %q:\n%s", expected, contents["gluetun.container"])
+		}
+	}
+	if strings.Contains(contents["gluetun.container"], "PublishPort=8096") {
+		t.Errorf("Expected Jellyfin ports to leave Gluetun:\n%s", contents["gluetun.container"])
+	}
+	jellyfin := contents["jellyfin.container"]
+	if strings.Contains(jellyfin, "container:gluetun") || !strings.Contains(jellyfin, "PublishPort=8096:8096/tcp") {
+		t.Errorf("Expected Jellyfin on the media network with its own ports:\n%s", jellyfin)
+	}
+	if _, ok := contents["media.network"]; !ok {
+		t.Error("Expected a media.network unit")
+	}
+}
+
+func TestQuadletGenerator_Generate(t *testing.T) {
+	registry, err := services.NewRegistry()
+	if err != nil {
+		t.Fatalf("Failed to create registry: %v", err)
+	}
+	tmpDir := t.TempDir()
+	generator := NewQuadletGenerator(registry, tmpDir)
+
+	if _, err := generator.Generate([]string{"prowlarr"}, false, NewDefaultEnvConfig(), true); err != nil {
+		t.Fatalf("Failed to generate: %v", err)
+	}
+	for _, filename := range []string{"prowlarr.container", "media.network", ".env"} {
+		if _, err := os.Stat(filepath.Join(tmpDir, filename)); err != nil {
+			t.Errorf("Expected %s: %v", filename, err)
+		}
+	}
+}
+
+func TestQuadletGenerator_HWAccel(t *testing.T) {
+	registry, err := services.NewRegistry()
+	if err != nil {
+		t.Fatalf("Failed to create registry: %v", err)
+	}
+	generator := NewQuadletGenerator(registry, t.TempDir())
+
+	generator.SetOptions(ComposeOptions{HWAccel: services.HWAccelVAAPI, HWAccelGroups: []string{"105"}})
+	units, err := generator.Render([]string{"jellyfin"}, false, NewDefaultEnvConfig())
+	if err != nil {
+		t.Fatalf("Failed to render: %v", err)
+	}
+	for _, expected := range []string{"AddDevice=/dev/dri:/dev/dri", "GroupAdd=105"} {
+		if !strings.Contains(units[0].Content, expected) {
+			t.Errorf("Expected %q in the Jellyfin unit:\n%s", expected, units[0].Content)
+		}
+	}
+
+	generator.SetOptions(ComposeOptions{HWAccel: services.HWAccelNVIDIA})
+	units, err = generator.Render([]string{"jellyfin"}, false, NewDefaultEnvConfig())
+	if err != nil {
+		t.Fatalf("Failed to render: %v", err)
+	}
+	if !strings.Contains(units[0].Content, "AddDevice=nvidia.com/gpu=all") {
+		t.Errorf("Expected the NVIDIA CDI device:\n%s", units[0].Content)
+	}
+}
+
+func TestQualifiedImage(t *testing.T) {
+	tests := map[string]string{
+		"qmcgaw/gluetun:latest":             "docker.io/qmcgaw/gluetun:latest",
+		"nginx":                             "docker.io/nginx",
+		"lscr.io/linuxserver/sonarr:latest": "lscr.io/linuxserver/sonarr:latest",
+		"localhost/custom:dev":              "localhost/custom:dev",
+	}
+	for image, expected := range tests {
+		if got := qualifiedImage(image); got != expected {
+			t.Errorf("qualifiedImage(%q) = %q, want %q", image, got, expected)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package generator
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/woliveiras/corsarr/internal/services"
+)
+
+const (
+	// SecretsDir holds one file per secret, next to docker-compose.yml
+	SecretsDir = "secrets"
+	// SecretsMountDir is where Compose mounts secrets inside containers
+	SecretsMountDir = "/run/secrets"
+)
+
+// SecretSpec describes a sensitive variable that secrets mode moves out of
+// .env into its own file
+type SecretSpec struct {
+	// Name is the Compose secret and the file name under secrets/
+	Name string
+	// EnvVar is the variable the value is written to without secrets mode
+	EnvVar string
+	// FileEnvVar points the image at the mounted file instead. Gluetun reads
+	// *_SECRETFILE variables.
+	FileEnvVar string
+	// VPNType limits the secret to wireguard or openvpn tunnels
+	VPNType string
+}
+
+// Secret is a secret required by the configuration and its value. An empty
+// value keeps the file written by a previous run.
+type Secret struct {
+	SecretSpec
+	Value string
+}
+
+// secretSpecs lists every variable handled by secrets mode
+var secretSpecs = []SecretSpec{
+	{Name: "wireguard_private_key", EnvVar: "WIREGUARD_PRIVATE_KEY", FileEnvVar: "WIREGUARD_PRIVATE_KEY_SECRETFILE", VPNType: "wireguard"},
+	{Name: "wireguard_preshared_key", EnvVar: "WIREGUARD_PRESHARED_KEY", FileEnvVar: "WIREGUARD_PRESHARED_KEY_SECRETFILE", VPNType: "wireguard"},
+	{Name: "openvpn_password", EnvVar: "OPENVPN_PASSWORD", FileEnvVar: "OPENVPN_PASSWORD_SECRETFILE", VPNType: "openvpn"},
+}
+
+// IsSecretEnvVar reports whether a variable holds a secret
+func IsSecretEnvVar(name string) bool {
+	for _, spec := range secretSpecs {
+		if spec.EnvVar == name {
+			return true
+		}
+	}
+	return false
+}
+
+// MountPath is the path of the secret inside the container
+func (s SecretSpec) MountPath() string {
+	return SecretsMountDir + "/" + s.Name
+}
+
+// Secrets returns the secrets the VPN provider reads, or nil outside secrets
+// mode. Optional secrets are only included when set.
+func (c *EnvConfig) Secrets() []Secret {
+	if c == nil || !c.SecretsMode || c.VPNConfig == nil {
+		return nil
+	}
+	vpnType := c.VPNConfig.vpnTypeOrDefault()
+	var secrets []Secret
+	for _, variable := range c.VPNConfig.Provider().Variables[vpnType] {
+		for _, spec := range secretSpecs {
+			if spec.EnvVar != variable.Name || spec.VPNType != vpnType {
+				continue
+			}
+			value := c.VPNConfig.Value(spec.EnvVar)
+			if variable.Required || value != "" {
+				secrets = append(secrets, Secret{SecretSpec: spec, Value: value})
+			}
+		}
+	}
+	return secrets
+}
+
+// SecretNames returns the names of the secrets referenced by the compose file
+func (c *EnvConfig) SecretNames() []string {
+	var names []string
+	for _, secret := range c.Secrets() {
+		names = append(names, secret.Name)
+	}
+	return names
+}
+
+// WriteSecrets writes each secret to its own file under secrets/ with owner
+// only permissions. Secrets without a value must already have a file.
+func WriteSecrets(outputDir string, secrets []Secret) error {
+	if len(secrets) == 0 {
+		return nil
+	}
+	dir := filepath.Join(outputDir, SecretsDir)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("failed to create secrets directory: %w", err)
+	}
+	for _, secret := range secrets {
+		path := filepath.Join(dir, secret.Name)
+		if secret.Value == "" {
+			if _, err := os.Stat(path); err != nil {
+				return fmt.Errorf("secret %s has no value and %s does not exist", secret.Name, path)
+			}
+			continue
+		}
+		if err := os.WriteFile(path, []byte(secret.Value), 0600); err != nil {
+			return fmt.Errorf("failed to write secret %s: %w", secret.Name, err)
+		}
+	}
+	return nil
+}
+
+// applySecrets replaces the secret variables of each service with their
+// *_SECRETFILE counterpart and mounts the matching Compose secrets. The
+// registry definitions are never modified.
+func applySecrets(selected []*services.Service, names []string) []*services.Service {
+	if len(names) == 0 {
+		return selected
+	}
+	specs := make(map[string]SecretSpec, len(names))
+	for _, spec := range secretSpecs {
+		for _, name := range names {
+			if spec.Name == name {
+				specs[spec.EnvVar] = spec
+			}
+		}
+	}
+
+	adjusted := make([]*services.Service, len(selected))
+	for i, svc := range selected {
+		adjusted[i] = svc
+		for j, entry := range svc.Environment {
+			key, _, _ := strings.Cut(entry, "=")
+			spec, ok := specs[key]
+			if !ok {
+				continue
+			}
+			if adjusted[i] == svc {
+				adjusted[i] = svc.Clone()
+			}
+			adjusted[i].Environment[j] = spec.FileEnvVar + "=" + spec.MountPath()
+			adjusted[i].Secrets = append(adjusted[i].Secrets, spec.Name)
+		}
+	}
+	return adjusted
+}
+
+// composeSecrets declares the file of every secret mounted by a service
+func composeSecrets(selected []*services.Service) map[string]ComposeSecret {
+	secrets := make(map[string]ComposeSecret)
+	for _, svc := range selected {
+		for _, name := range svc.Secrets {
+			secrets[name] = ComposeSecret{File: "./" + SecretsDir + "/" + name}
+		}
+	}
+	if len(secrets) == 0 {
+		return nil
+	}
+	return secrets
+}
@@ -0,0 +1,120 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/woliveiras/corsarr/internal/services"
+)
+
+func TestEnvConfig_Secrets(t *testing.T) {
+	tests := []struct {
+		name  string
+		mode  bool
+		vpn   *VPNConfig
+		names []string
+	}{
+		{name: "secrets mode off", mode: false, vpn: &VPNConfig{Type: "wireguard", WireguardPrivateKey: "key"}},
+		{name: "no VPN", mode: true},
+		{name: "wireguard", mode: true, vpn: &VPNConfig{Type: "wireguard", WireguardPrivateKey: "key"}, names: []string{"wireguard_private_key"}},
+		{name: "openvpn", mode: true, vpn: &VPNConfig{Type: "openvpn", OpenVPNPassword: "secret"}, names: []string{"openvpn_password"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &EnvConfig{SecretsMode: tt.mode, VPNConfig: tt.vpn}
+			if got := strings.Join(config.SecretNames(), ","); got != strings.Join(tt.names, ",") {
+				t.Errorf("SecretNames() = %q, want %q", got, strings.Join(tt.names, ","))
+			}
+		})
+	}
+}
+
+func TestWriteSecrets(t *testing.T) {
+	outputDir := t.TempDir()
+	secret := Secret{SecretSpec: secretSpecs[0], Value: "private-key"}
+
+	if err := WriteSecrets(outputDir, []Secret{secret}); err != nil {
+		t.Fatalf("Failed to write secrets: %v", err)
+	}
+	path := filepath.Join(outputDir, SecretsDir, secret.Name)
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Expected %s to exist: %v", path, err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("Expected secret permissions 0600, got %o", info.Mode().Perm())
+	}
+
+	// A later run without the value keeps the file
+	secret.Value = ""
+	if err := WriteSecrets(outputDir, []Secret{secret}); err != nil {
+		t.Fatalf("Expected the existing secret to be kept: %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "private-key" {
+		t.Errorf("Expected the secret to be unchanged, got %q", data)
+	}
+
+	if err := WriteSecrets(t.TempDir(), []Secret{secret}); err == nil {
+		t.Error("Expected an error for a secret without value or file")
+	}
+}
+
+func TestEnvGenerator_SecretsMode(t *testing.T) {
+	outputDir := t.TempDir()
+	config := NewDefaultEnvConfig()
+	config.SecretsMode = true
+	config.VPNConfig = &VPNConfig{ServiceProvider: "mullvad", Type: "wireguard", WireguardPrivateKey: "private-key"}
+
+	if err := NewEnvGenerator(outputDir).Generate(config, false); err != nil {
+		t.Fatalf("Failed to generate: %v", err)
+	}
+	content, err := os.ReadFile(filepath.Join(outputDir, ".env"))
+	if err != nil {
+		t.Fatalf("Failed to read .env: %v", err)
+	}
+	if strings.Contains(string(content), "private-key") || strings.Contains(string(content), "WIREGUARD_PRIVATE_KEY") {
+		t.Errorf("Expected the private key to stay out of .env:\n%s", content)
+	}
+	if _, ok := envValues(config)["WIREGUARD_PRIVATE_KEY"]; ok {
+		t.Error("Expected envValues to omit the private key in secrets mode")
+	}
+	if _, err := os.Stat(filepath.Join(outputDir, SecretsDir, "wireguard_private_key")); err != nil {
+		t.Errorf("Expected the secret file to be written: %v", err)
+	}
+}
+
+func TestComposeFile_Secrets(t *testing.T) {
+	registry, err := services.NewRegistry()
+	if err != nil {
+		t.Fatalf("Failed to create registry: %v", err)
+	}
+	generator := NewComposeGenerator(registry, t.TempDir())
+	generator.SetOptions(ComposeOptions{Secrets: []string{"wireguard_private_key"}})
+
+	content, err := generator.Preview([]string{"qbittorrent"}, true)
+	if err != nil {
+		t.Fatalf("Failed to preview: %v", err)
+	}
+	decoded := decodeCompose(t, content)
+	env := decoded.Services["gluetun"].Environment
+	if !contains(env, "WIREGUARD_PRIVATE_KEY_SECRETFILE=/run/secrets/wireguard_private_key") || contains(env, "WIREGUARD_PRIVATE_KEY=${WIREGUARD_PRIVATE_KEY}") {
+		t.Errorf("Expected gluetun to read the key from its secret, got %v", env)
+	}
+	if !strings.Contains(content, "    secrets:\n      - wireguard_private_key\n") {
+		t.Errorf("Expected gluetun to mount the secret:\n%s", content)
+	}
+	if !strings.Contains(content, "\nsecrets:\n  wireguard_private_key:\n    file: ./secrets/wireguard_private_key\n") {
+		t.Errorf("Expected a top-level secrets section:\n%s", content)
+	}
+
+	gluetun, err := registry.GetService("gluetun")
+	if err != nil {
+		t.Fatalf("Failed to get gluetun: %v", err)
+	}
+	if len(gluetun.Secrets) != 0 || !contains(gluetun.Environment, "WIREGUARD_PRIVATE_KEY=${WIREGUARD_PRIVATE_KEY}") {
+		t.Error("Expected the registry definition to be unchanged")
+	}
+}
@@ -4,18 +4,19 @@ import (
 	"bytes"
 	"embed"
 	"fmt"
+	"strconv"
 	"text/template"
 
 	"github.com/woliveiras/corsarr/internal/services"
 )
 
-//go:embed templates/docker-compose/*.tmpl
+//go:embed templates/kubernetes/*.tmpl templates/quadlet/*.tmpl templates/proxy/*.tmpl
 var templatesFS embed.FS
 
 // ComposeStrategy defines the interface for compose generation strategies
 type ComposeStrategy interface {
+	BuildCompose(selectedServices []*services.Service) (*ComposeFile, error)
 	GenerateCompose(selectedServices []*services.Service) (string, error)
-	GetTemplatePath() string
 }
 
 // VPNModeStrategy generates compose for VPN mode
@@ -24,18 +25,6 @@ type VPNModeStrategy struct{}
 // BridgeModeStrategy generates compose for bridge mode
 type BridgeModeStrategy struct{}
 
-// VPNComposeData holds data for VPN mode template
-type VPNComposeData struct {
-	Services     []*services.Service
-	Gluetun      *services.Service
-	ExposedPorts []services.PortMapping
-}
-
-// BridgeComposeData holds data for bridge mode template
-type BridgeComposeData struct {
-	Services []*services.Service
-}
-
 // NewComposeStrategy creates the appropriate strategy based on VPN mode
 func NewComposeStrategy(vpnMode bool) ComposeStrategy {
 	if vpnMode {
@@ -44,8 +33,11 @@ func NewComposeStrategy(vpnMode bool) ComposeStrategy {
 	return &BridgeModeStrategy{}
 }
 
-// GenerateCompose implements ComposeStrategy for VPN mode
-func (s *VPNModeStrategy) GenerateCompose(selectedServices []*services.Service) (string, error) {
+// BuildCompose implements ComposeStrategy for VPN mode. Gluetun publishes
+// the ports of every service sharing its network namespace. Services routed
+// around the tunnel join their bridge networks as in bridge mode, and Gluetun
+// joins them too so they can reach the services behind it.
+func (s *VPNModeStrategy) BuildCompose(selectedServices []*services.Service) (*ComposeFile, error) {
 	// Separate Gluetun from other services
 	var gluetun *services.Service
 	var otherServices []*services.Service
@@ -59,38 +51,104 @@ func (s *VPNModeStrategy) GenerateCompose(selectedServices []*services.Service)
 	}
 
 	if gluetun == nil {
-		return "", fmt.Errorf("gluetun service not found in VPN mode")
+		return nil, fmt.Errorf("gluetun service not found in VPN mode")
 	}
 
 	// Get exposed ports for Gluetun
 	exposedPorts := GetExposedPorts(selectedServices, true)
 
-	data := VPNComposeData{
-		Services:     otherServices,
-		Gluetun:      gluetun,
-		ExposedPorts: exposedPorts,
+	names, selected := serviceIndex(selectedServices)
+	names[gluetun.ID] = "gluetun"
+
+	compose := &ComposeFile{}
+	gluetunService := newComposeService(gluetun)
+	gluetunService.Name = "gluetun"
+	gluetunService.Ports = composePorts(append(append([]services.PortMapping{}, gluetun.Ports...), exposedPorts...))
+	if hybridVPN(selectedServices) {
+		gluetunService.Hostname = gluetun.Network.BridgeMode.Hostname
+		gluetunService.Networks = append([]string(nil), gluetun.Network.BridgeMode.Networks...)
+	}
+	compose.Services = append(compose.Services, gluetunService)
+
+	for _, svc := range otherServices {
+		composeService := newComposeService(svc)
+		if bypassesVPN(svc) {
+			composeService.DependsOn = composeDependsOn(svc, names, selected)
+			composeService.Hostname = svc.Network.BridgeMode.Hostname
+			composeService.Networks = append([]string(nil), svc.Network.BridgeMode.Networks...)
+			composeService.Ports = composePorts(svc.Ports)
+			compose.Services = append(compose.Services, composeService)
+			continue
+		}
+		composeService.NetworkMode = QuotedString(svc.Network.VPNMode.NetworkMode)
+		// Services sharing Gluetun's namespace have no network until the
+		// tunnel is up; the rest only need Gluetun to be running
+		condition := ConditionServiceStarted
+		if svc.Network.VPNMode.NetworkMode == "service:gluetun" {
+			condition = dependencyCondition(gluetun)
+		}
+		composeService.DependsOn = append(ComposeDependsOn{{Service: "gluetun", Condition: condition}},
+			composeDependsOn(svc, names, selected)...)
+		compose.Services = append(compose.Services, composeService)
 	}
+	compose.Networks = bridgeNetworks(compose.Services)
+	compose.Secrets = composeSecrets(selectedServices)
+
+	return compose, nil
+}
 
-	return renderTemplate(s.GetTemplatePath(), "compose-vpn", data)
+// GenerateCompose implements ComposeStrategy for VPN mode
+func (s *VPNModeStrategy) GenerateCompose(selectedServices []*services.Service) (string, error) {
+	compose, err := s.BuildCompose(selectedServices)
+	if err != nil {
+		return "", err
+	}
+	return compose.Marshal()
 }
 
-// GetTemplatePath returns the template path for VPN mode
-func (s *VPNModeStrategy) GetTemplatePath() string {
-	return "templates/docker-compose/vpn-mode.tmpl"
+// BuildCompose implements ComposeStrategy for bridge mode
+func (s *BridgeModeStrategy) BuildCompose(selectedServices []*services.Service) (*ComposeFile, error) {
+	names, selected := serviceIndex(selectedServices)
+
+	compose := &ComposeFile{}
+	for _, svc := range selectedServices {
+		composeService := newComposeService(svc)
+		composeService.DependsOn = composeDependsOn(svc, names, selected)
+		composeService.Hostname = svc.Network.BridgeMode.Hostname
+		composeService.Networks = append([]string(nil), svc.Network.BridgeMode.Networks...)
+		composeService.Ports = composePorts(svc.Ports)
+		compose.Services = append(compose.Services, composeService)
+	}
+	compose.Networks = bridgeNetworks(compose.Services)
+	compose.Secrets = composeSecrets(selectedServices)
+
+	return compose, nil
 }
 
 // GenerateCompose implements ComposeStrategy for bridge mode
 func (s *BridgeModeStrategy) GenerateCompose(selectedServices []*services.Service) (string, error) {
-	data := BridgeComposeData{
-		Services: selectedServices,
+	compose, err := s.BuildCompose(selectedServices)
+	if err != nil {
+		return "", err
 	}
+	return compose.Marshal()
+}
 
-	return renderTemplate(s.GetTemplatePath(), "compose-bridge", data)
+// serviceIndex maps service IDs to their compose service names and definitions
+func serviceIndex(selectedServices []*services.Service) (map[string]string, map[string]*services.Service) {
+	names := make(map[string]string, len(selectedServices))
+	selected := make(map[string]*services.Service, len(selectedServices))
+	for _, svc := range selectedServices {
+		names[svc.ID] = svc.ContainerName
+		selected[svc.ID] = svc
+	}
+	return names, selected
 }
 
-// GetTemplatePath returns the template path for bridge mode
-func (s *BridgeModeStrategy) GetTemplatePath() string {
-	return "templates/docker-compose/bridge-mode.tmpl"
+// templateFuncs are available to every generator template
+var templateFuncs = template.FuncMap{
+	// quote renders a double-quoted YAML scalar
+	"quote": strconv.Quote,
 }
 
 // renderTemplate is a helper function to render templates
@@ -100,7 +158,7 @@ func renderTemplate(templatePath, templateName string, data interface{}) (string
 		return "", fmt.Errorf("failed to read template %s: %w", templatePath, err)
 	}
 
-	tmpl, err := template.New(templateName).Parse(string(tmplContent))
+	tmpl, err := template.New(templateName).Funcs(templateFuncs).Parse(string(tmplContent))
 	if err != nil {
 		return "", fmt.Errorf("failed to parse template: %w", err)
 	}
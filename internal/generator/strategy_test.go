@@ -1,6 +1,7 @@
 package generator
 
 import (
+	"fmt"
 	"testing"
 
 	"github.com/woliveiras/corsarr/internal/services"
@@ -31,13 +32,8 @@ func TestNewComposeStrategy(t *testing.T) {
 				t.Fatal("Strategy is nil")
 			}
 			
-			// Check template path to verify strategy type
-			path := strategy.GetTemplatePath()
-			if tt.vpnMode && path != "templates/docker-compose/vpn-mode.tmpl" {
-				t.Errorf("Expected VPN template path, got %s", path)
-			}
-			if !tt.vpnMode && path != "templates/docker-compose/bridge-mode.tmpl" {
-				t.Errorf("Expected bridge template path, got %s", path)
+			if got := fmt.Sprintf("%T", strategy); got != tt.expectType {
+				t.Errorf("Expected %s, got %s", tt.expectType, got)
 			}
 		})
 	}
@@ -96,6 +92,56 @@ func TestVPNModeStrategy_GenerateCompose(t *testing.T) {
 	})
 }
 
+func TestVPNModeStrategy_HybridRoutes(t *testing.T) {
+	registry, err := services.NewRegistry()
+	if err != nil {
+		t.Fatalf("Failed to create registry: %v", err)
+	}
+	generator := NewComposeGenerator(registry, t.TempDir())
+	generator.SetOptions(ComposeOptions{VPNRoutes: services.VPNRoutes{"streaming": services.RouteBridge, "request": services.RouteBridge}})
+	selected, err := generator.SelectedServices([]string{"qbittorrent", "sonarr", "jellyfin", "jellyseerr"}, true)
+	if err != nil {
+		t.Fatalf("Failed to select services: %v", err)
+	}
+
+	compose, err := (&VPNModeStrategy{}).BuildCompose(selected)
+	if err != nil {
+		t.Fatalf("Failed to build compose: %v", err)
+	}
+
+	gluetun := compose.Service("gluetun")
+	if gluetun.Hostname != "gluetun" || len(gluetun.Networks) != 1 || gluetun.Networks[0] != "media" {
+		t.Errorf("Expected Gluetun to join the media network, got hostname %q networks %v", gluetun.Hostname, gluetun.Networks)
+	}
+	for _, port := range gluetun.Ports {
+		if port == "8096:8096" || port == "7359:7359/udp" {
+			t.Errorf("Expected Gluetun not to publish Jellyfin port %s", port)
+		}
+	}
+
+	jellyfin := compose.Service("jellyfin")
+	if jellyfin.NetworkMode != "" || jellyfin.Hostname != "jellyfin" || len(jellyfin.Networks) == 0 {
+		t.Errorf("Expected Jellyfin on the media network, got network_mode %q networks %v", jellyfin.NetworkMode, jellyfin.Networks)
+	}
+	if jellyfin.DependsOn.Has("gluetun") {
+		t.Error("Expected Jellyfin not to wait for the tunnel")
+	}
+	published := false
+	for _, port := range jellyfin.Ports {
+		published = published || port == "7359:7359/udp"
+	}
+	if !published {
+		t.Errorf("Expected Jellyfin to publish its discovery port, got %v", jellyfin.Ports)
+	}
+
+	if sonarr := compose.Service("sonarr"); sonarr.NetworkMode != "service:gluetun" || len(sonarr.Networks) != 0 {
+		t.Errorf("Expected Sonarr to stay behind Gluetun, got network_mode %q", sonarr.NetworkMode)
+	}
+	if _, ok := compose.Networks["media"]; !ok {
+		t.Errorf("Expected the media network to be declared, got %v", compose.Networks)
+	}
+}
+
 func TestBridgeModeStrategy_GenerateCompose(t *testing.T) {
 	strategy := &BridgeModeStrategy{}
 
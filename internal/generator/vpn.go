@@ -0,0 +1,489 @@
+package generator
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/woliveiras/corsarr/internal/services"
+)
+
+// VPN tunnel types supported by Gluetun
+const (
+	VPNTypeWireGuard = "wireguard"
+	VPNTypeOpenVPN   = "openvpn"
+)
+
+// VPNVariable is a Gluetun variable a tunnel type reads
+type VPNVariable struct {
+	Name     string
+	Required bool
+}
+
+// VPNProvider describes the Gluetun variables a VPN provider needs
+type VPNProvider struct {
+	// ID is the VPN_SERVICE_PROVIDER value
+	ID   string
+	Name string
+	// Variables lists the credentials and addresses of each tunnel type,
+	// keyed by wireguard or openvpn. Types without an entry are unsupported.
+	Variables map[string][]VPNVariable
+	// Filters are the server selection variables the provider accepts
+	Filters []string
+	// PortForwarding tells whether Gluetun can forward a port with it
+	PortForwarding bool
+}
+
+// Types returns the tunnel types the provider supports, WireGuard first
+func (p VPNProvider) Types() []string {
+	var types []string
+	for _, vpnType := range []string{VPNTypeWireGuard, VPNTypeOpenVPN} {
+		if _, ok := p.Variables[vpnType]; ok {
+			types = append(types, vpnType)
+		}
+	}
+	return types
+}
+
+// Supports reports whether the provider offers the tunnel type
+func (p VPNProvider) Supports(vpnType string) bool {
+	_, ok := p.Variables[vpnType]
+	return ok
+}
+
+var (
+	serverLocationFilters = []string{"SERVER_COUNTRIES", "SERVER_CITIES", "SERVER_HOSTNAMES"}
+	openVPNCredentials    = []VPNVariable{{Name: "OPENVPN_USER", Required: true}, {Name: "OPENVPN_PASSWORD", Required: true}}
+)
+
+// vpnProviders is the provider catalog, in the order the prompts offer it
+var vpnProviders = []VPNProvider{
+	{
+		ID:   "mullvad",
+		Name: "Mullvad",
+		Variables: map[string][]VPNVariable{
+			VPNTypeWireGuard: {
+				{Name: "WIREGUARD_PRIVATE_KEY", Required: true},
+				{Name: "WIREGUARD_ADDRESSES", Required: true},
+			},
+		},
+		Filters: serverLocationFilters,
+	},
+	{
+		ID:   "protonvpn",
+		Name: "ProtonVPN",
+		Variables: map[string][]VPNVariable{
+			VPNTypeWireGuard: {{Name: "WIREGUARD_PRIVATE_KEY", Required: true}},
+			VPNTypeOpenVPN:   openVPNCredentials,
+		},
+		Filters:        serverLocationFilters,
+		PortForwarding: true,
+	},
+	{
+		ID:   "nordvpn",
+		Name: "NordVPN",
+		Variables: map[string][]VPNVariable{
+			VPNTypeWireGuard: {{Name: "WIREGUARD_PRIVATE_KEY", Required: true}},
+			VPNTypeOpenVPN:   openVPNCredentials,
+		},
+		Filters: serverLocationFilters,
+	},
+	{
+		ID:   "airvpn",
+		Name: "AirVPN",
+		Variables: map[string][]VPNVariable{
+			VPNTypeWireGuard: {
+				{Name: "WIREGUARD_PRIVATE_KEY", Required: true},
+				{Name: "WIREGUARD_PRESHARED_KEY", Required: true},
+				{Name: "WIREGUARD_ADDRESSES", Required: true},
+			},
+		},
+		Filters: serverLocationFilters,
+	},
+	{
+		ID:   "private internet access",
+		Name: "Private Internet Access",
+		Variables: map[string][]VPNVariable{
+			VPNTypeOpenVPN: openVPNCredentials,
+		},
+		Filters:        []string{"SERVER_REGIONS"},
+		PortForwarding: true,
+	},
+	{
+		ID:   "custom",
+		Name: "Custom",
+		Variables: map[string][]VPNVariable{
+			VPNTypeWireGuard: {
+				{Name: "WIREGUARD_PRIVATE_KEY", Required: true},
+				{Name: "WIREGUARD_PUBLIC_KEY", Required: true},
+				{Name: "WIREGUARD_ADDRESSES", Required: true},
+				{Name: "WIREGUARD_ENDPOINT_IP", Required: true},
+				{Name: "WIREGUARD_ENDPOINT_PORT", Required: true},
+			},
+			VPNTypeOpenVPN: {
+				{Name: "OPENVPN_CUSTOM_CONFIG", Required: true},
+				{Name: "OPENVPN_USER"},
+				{Name: "OPENVPN_PASSWORD"},
+			},
+		},
+	},
+}
+
+// genericVPNProvider covers the other providers Gluetun knows, with the
+// variables most of them read
+var genericVPNProvider = VPNProvider{
+	Variables: map[string][]VPNVariable{
+		VPNTypeWireGuard: {
+			{Name: "WIREGUARD_PRIVATE_KEY", Required: true},
+			{Name: "WIREGUARD_ADDRESSES"},
+		},
+		VPNTypeOpenVPN: openVPNCredentials,
+	},
+	Filters: serverLocationFilters,
+}
+
+// VPNProviders returns the provider catalog
+func VPNProviders() []VPNProvider {
+	return vpnProviders
+}
+
+// LookupVPNProvider returns the catalog entry of a provider. Providers outside
+// the catalog get the generic variables and ok is false.
+func LookupVPNProvider(id string) (VPNProvider, bool) {
+	id = strings.ToLower(strings.TrimSpace(id))
+	for _, provider := range vpnProviders {
+		if provider.ID == id || strings.ReplaceAll(provider.ID, " ", "") == strings.ReplaceAll(id, " ", "") {
+			return provider, true
+		}
+	}
+	if id == "pia" {
+		return LookupVPNProvider("private internet access")
+	}
+	provider := genericVPNProvider
+	provider.ID = id
+	provider.Name = id
+	return provider, false
+}
+
+// vpnTypeOrDefault returns the tunnel type, WireGuard when unset
+func (c *VPNConfig) vpnTypeOrDefault() string {
+	if c.Type == "" {
+		return VPNTypeWireGuard
+	}
+	return c.Type
+}
+
+// Provider returns the catalog entry of the configured provider
+func (c *VPNConfig) Provider() VPNProvider {
+	provider, _ := LookupVPNProvider(c.ServiceProvider)
+	return provider
+}
+
+// Value returns the configured value of a Gluetun variable
+func (c *VPNConfig) Value(name string) string {
+	if field := c.field(name); field != nil {
+		return *field
+	}
+	return ""
+}
+
+// SetValue stores the value of a Gluetun variable, ignoring unknown names
+func (c *VPNConfig) SetValue(name, value string) {
+	if field := c.field(name); field != nil {
+		*field = value
+	}
+}
+
+// field maps a Gluetun variable onto its configuration field
+func (c *VPNConfig) field(name string) *string {
+	switch name {
+	case "WIREGUARD_PRIVATE_KEY":
+		return &c.WireguardPrivateKey
+	case "WIREGUARD_PUBLIC_KEY":
+		return &c.WireguardPublicKey
+	case "WIREGUARD_PRESHARED_KEY":
+		return &c.WireguardPresharedKey
+	case "WIREGUARD_ADDRESSES":
+		return &c.WireguardAddresses
+	case "WIREGUARD_ENDPOINT_IP":
+		return &c.WireguardEndpointIP
+	case "WIREGUARD_ENDPOINT_PORT":
+		return &c.WireguardEndpointPort
+	case "OPENVPN_USER":
+		return &c.OpenVPNUser
+	case "OPENVPN_PASSWORD":
+		return &c.OpenVPNPassword
+	case "OPENVPN_CUSTOM_CONFIG":
+		return &c.OpenVPNCustomConfig
+	case "SERVER_COUNTRIES":
+		return &c.ServerCountries
+	case "SERVER_CITIES":
+		return &c.ServerCities
+	case "SERVER_HOSTNAMES":
+		return &c.ServerHostnames
+	case "SERVER_REGIONS":
+		return &c.ServerRegions
+	case "FIREWALL_OUTBOUND_SUBNETS":
+		return &c.FirewallOutboundSubnets
+	}
+	return nil
+}
+
+// EnvVariable is a KEY=value line of the generated .env
+type EnvVariable struct {
+	Key   string
+	Value string
+}
+
+// Env returns the Gluetun variables of the configuration in .env order: the
+// provider and type, the variables the provider reads for the tunnel type,
+// the server filters, port forwarding, DNS and the LAN subnets. Empty
+// optional variables are left out.
+func (c *VPNConfig) Env() []EnvVariable {
+	provider := c.Provider()
+	vpnType := c.vpnTypeOrDefault()
+
+	env := []EnvVariable{
+		{Key: "VPN_SERVICE_PROVIDER", Value: c.ServiceProvider},
+		{Key: "VPN_TYPE", Value: vpnType},
+	}
+	for _, variable := range provider.Variables[vpnType] {
+		if value := c.Value(variable.Name); value != "" || variable.Required {
+			env = append(env, EnvVariable{Key: variable.Name, Value: value})
+		}
+	}
+	for _, filter := range provider.Filters {
+		if value := c.Value(filter); value != "" {
+			env = append(env, EnvVariable{Key: filter, Value: value})
+		}
+	}
+	if provider.PortForwarding {
+		env = append(env, EnvVariable{Key: "VPN_PORT_FORWARDING", Value: valueOrDefault(c.PortForwarding, "off")})
+	}
+	if c.DNSAddress != "" {
+		env = append(env, EnvVariable{Key: "VPN_DNS_ADDRESS", Value: c.DNSAddress})
+	}
+	if c.FirewallOutboundSubnets != "" {
+		env = append(env, EnvVariable{Key: "FIREWALL_OUTBOUND_SUBNETS", Value: c.FirewallOutboundSubnets})
+	}
+	return env
+}
+
+// VPNEnv returns the Gluetun variables written to .env. Variables moved to
+// secret files in secrets mode are left out.
+func (c *EnvConfig) VPNEnv() []EnvVariable {
+	if c == nil || c.VPNConfig == nil {
+		return nil
+	}
+	secrets := make(map[string]bool)
+	for _, secret := range c.Secrets() {
+		secrets[secret.EnvVar] = true
+	}
+	var env []EnvVariable
+	for _, variable := range c.VPNConfig.Env() {
+		if !secrets[variable.Key] {
+			env = append(env, variable)
+		}
+	}
+	return env
+}
+
+// VPNVariableNames returns the variables Gluetun receives, secrets included
+func (c *EnvConfig) VPNVariableNames() []string {
+	if c == nil || c.VPNConfig == nil {
+		return nil
+	}
+	var names []string
+	for _, variable := range c.VPNConfig.Env() {
+		names = append(names, variable.Key)
+	}
+	return names
+}
+
+// Validate checks the configuration against the provider catalog: the tunnel
+// type must be supported, required variables set, and keys, addresses and
+// ports well formed. Secret files written by a previous run satisfy required
+// secrets when secretsMode is set.
+func (c *VPNConfig) Validate(secretsMode bool) error {
+	provider, known := LookupVPNProvider(c.ServiceProvider)
+	if c.ServiceProvider == "" {
+		return fmt.Errorf("VPN provider is required")
+	}
+	vpnType := c.vpnTypeOrDefault()
+	if vpnType != VPNTypeWireGuard && vpnType != VPNTypeOpenVPN {
+		return fmt.Errorf("unsupported VPN type %q (use %s or %s)", vpnType, VPNTypeWireGuard, VPNTypeOpenVPN)
+	}
+	if known && !provider.Supports(vpnType) {
+		return fmt.Errorf("%s does not support %s (use %s)", provider.Name, vpnType, strings.Join(provider.Types(), " or "))
+	}
+
+	var problems []string
+	for _, variable := range provider.Variables[vpnType] {
+		value := c.Value(variable.Name)
+		if value == "" {
+			if variable.Required && !(secretsMode && IsSecretEnvVar(variable.Name)) {
+				problems = append(problems, fmt.Sprintf("%s is required for %s over %s", variable.Name, valueOrDefault(provider.Name, c.ServiceProvider), vpnType))
+			}
+			continue
+		}
+		if err := ValidateVPNValue(variable.Name, value); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+	switch c.PortForwarding {
+	case "", "off":
+	case "on":
+		if known && !provider.PortForwarding {
+			problems = append(problems, fmt.Sprintf("%s does not support port forwarding with Gluetun", provider.Name))
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("VPN_PORT_FORWARDING must be on or off, got %q", c.PortForwarding))
+	}
+	if c.DNSAddress != "" && net.ParseIP(c.DNSAddress) == nil {
+		problems = append(problems, fmt.Sprintf("VPN DNS address %q is not an IP address", c.DNSAddress))
+	}
+	if c.FirewallOutboundSubnets != "" {
+		if err := ValidateVPNValue("FIREWALL_OUTBOUND_SUBNETS", c.FirewallOutboundSubnets); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid VPN configuration: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// ValidateVPNValue checks the format of a WireGuard key, address list or
+// endpoint and of the LAN subnets; other variables are accepted as they are
+func ValidateVPNValue(name, value string) error {
+	switch name {
+	case "WIREGUARD_PRIVATE_KEY", "WIREGUARD_PUBLIC_KEY", "WIREGUARD_PRESHARED_KEY":
+		key, err := base64.StdEncoding.DecodeString(value)
+		if err != nil || len(key) != 32 {
+			return fmt.Errorf("%s must be a base64 encoded 32 byte WireGuard key", name)
+		}
+	case "WIREGUARD_ADDRESSES":
+		for _, address := range strings.Split(value, ",") {
+			if _, _, err := net.ParseCIDR(strings.TrimSpace(address)); err != nil {
+				return fmt.Errorf("%s must list addresses in CIDR notation such as 10.64.222.21/32, got %q", name, address)
+			}
+		}
+	case "FIREWALL_OUTBOUND_SUBNETS":
+		for _, subnet := range strings.Split(value, ",") {
+			if _, _, err := net.ParseCIDR(strings.TrimSpace(subnet)); err != nil {
+				return fmt.Errorf("%s must list subnets in CIDR notation such as 192.168.1.0/24, got %q", name, subnet)
+			}
+		}
+	case "WIREGUARD_ENDPOINT_IP":
+		if net.ParseIP(value) == nil {
+			return fmt.Errorf("%s must be an IP address, got %q", name, value)
+		}
+	case "WIREGUARD_ENDPOINT_PORT":
+		if port, err := strconv.Atoi(value); err != nil || port < 1 || port > 65535 {
+			return fmt.Errorf("%s must be a port between 1 and 65535, got %q", name, value)
+		}
+	}
+	return nil
+}
+
+// Outbound subnet settings besides an explicit list of subnets
+const (
+	// OutboundSubnetsAuto detects the private IPv4 subnets of this machine
+	OutboundSubnetsAuto = "auto"
+	// OutboundSubnetsNone keeps every destination behind the tunnel
+	OutboundSubnetsNone = "none"
+)
+
+// ResolveOutboundSubnets returns the FIREWALL_OUTBOUND_SUBNETS value of a
+// setting: the subnets found by detect for auto or an empty setting, nothing
+// for none, and otherwise the listed subnets with their host bits cleared.
+// It reports whether the subnets were detected.
+func ResolveOutboundSubnets(setting string, detect func() []string) (string, bool, error) {
+	setting = strings.TrimSpace(setting)
+	switch strings.ToLower(setting) {
+	case "", OutboundSubnetsAuto:
+		return strings.Join(detect(), ","), true, nil
+	case OutboundSubnetsNone:
+		return "", false, nil
+	}
+
+	var subnets []string
+	seen := make(map[string]bool)
+	for _, subnet := range strings.Split(setting, ",") {
+		subnet = strings.TrimSpace(subnet)
+		if subnet == "" {
+			continue
+		}
+		_, network, err := net.ParseCIDR(subnet)
+		if err != nil {
+			return "", false, fmt.Errorf("invalid outbound subnet %q (use CIDR notation such as 192.168.1.0/24, %s or %s)", subnet, OutboundSubnetsAuto, OutboundSubnetsNone)
+		}
+		if !seen[network.String()] {
+			seen[network.String()] = true
+			subnets = append(subnets, network.String())
+		}
+	}
+	return strings.Join(subnets, ","), false, nil
+}
+
+// gluetunVariables are the Gluetun settings the generator manages; the
+// service definition references the ones a provider reads
+var gluetunVariables = map[string]bool{
+	"VPN_SERVICE_PROVIDER": true,
+	"VPN_TYPE":             true,
+	"VPN_PORT_FORWARDING":  true,
+	"VPN_DNS_ADDRESS":      true,
+	// FIREWALL_OUTBOUND_SUBNETS lets the services behind Gluetun reach the LAN
+	"FIREWALL_OUTBOUND_SUBNETS": true,
+}
+
+func init() {
+	for _, provider := range append(VPNProviders(), genericVPNProvider) {
+		for _, variables := range provider.Variables {
+			for _, variable := range variables {
+				gluetunVariables[variable.Name] = true
+			}
+		}
+		for _, filter := range provider.Filters {
+			gluetunVariables[filter] = true
+		}
+	}
+}
+
+// applyVPNEnv makes the VPN service reference exactly the variables of the
+// configured provider. The registry definitions are never modified.
+func applyVPNEnv(selected []*services.Service, names []string) []*services.Service {
+	if len(names) == 0 {
+		return selected
+	}
+	adjusted := make([]*services.Service, len(selected))
+	for i, svc := range selected {
+		adjusted[i] = svc
+		if svc.Category != services.CategoryVPN {
+			continue
+		}
+		clone := svc.Clone()
+		clone.Environment = nil
+		for _, entry := range svc.Environment {
+			key, _, _ := strings.Cut(entry, "=")
+			if !gluetunVariables[key] {
+				clone.Environment = append(clone.Environment, entry)
+			}
+		}
+		for _, name := range names {
+			clone.Environment = append(clone.Environment, name+"=${"+name+"}")
+		}
+		adjusted[i] = clone
+	}
+	return adjusted
+}
+
+func valueOrDefault(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
@@ -0,0 +1,189 @@
+package generator
+
+import (
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/woliveiras/corsarr/internal/services"
+)
+
+const testWireGuardKey = "yAnz5TF+lXXJte14tji3zlMNq+hd2rYUIgJBgB3fBmk="
+
+func TestLookupVPNProvider(t *testing.T) {
+	tests := []struct {
+		id    string
+		known bool
+		types string
+	}{
+		{id: "mullvad", known: true, types: "wireguard"},
+		{id: "ProtonVPN", known: true, types: "wireguard,openvpn"},
+		{id: "pia", known: true, types: "openvpn"},
+		{id: "privateinternetaccess", known: true, types: "openvpn"},
+		{id: "custom", known: true, types: "wireguard,openvpn"},
+		{id: "surfshark", known: false, types: "wireguard,openvpn"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			provider, known := LookupVPNProvider(tt.id)
+			if known != tt.known {
+				t.Errorf("LookupVPNProvider(%q) known = %v, want %v", tt.id, known, tt.known)
+			}
+			if got := strings.Join(provider.Types(), ","); got != tt.types {
+				t.Errorf("Types() = %q, want %q", got, tt.types)
+			}
+		})
+	}
+}
+
+func TestVPNConfig_Env(t *testing.T) {
+	tests := []struct {
+		name   string
+		config *VPNConfig
+		want   []string
+	}{
+		{
+			name: "mullvad",
+			config: &VPNConfig{ServiceProvider: "mullvad", WireguardPrivateKey: "key", WireguardAddresses: "10.64.222.21/32",
+				ServerCities: "Amsterdam", OpenVPNUser: "ignored", PortForwarding: "on", DNSAddress: "1.1.1.1"},
+			want: []string{"VPN_SERVICE_PROVIDER=mullvad", "VPN_TYPE=wireguard", "WIREGUARD_PRIVATE_KEY=key",
+				"WIREGUARD_ADDRESSES=10.64.222.21/32", "SERVER_CITIES=Amsterdam", "VPN_DNS_ADDRESS=1.1.1.1"},
+		},
+		{
+			name:   "protonvpn openvpn",
+			config: &VPNConfig{ServiceProvider: "protonvpn", Type: "openvpn", OpenVPNUser: "user", OpenVPNPassword: "secret", ServerCountries: "Netherlands", PortForwarding: "on"},
+			want: []string{"VPN_SERVICE_PROVIDER=protonvpn", "VPN_TYPE=openvpn", "OPENVPN_USER=user", "OPENVPN_PASSWORD=secret",
+				"SERVER_COUNTRIES=Netherlands", "VPN_PORT_FORWARDING=on"},
+		},
+		{
+			name:   "private internet access",
+			config: &VPNConfig{ServiceProvider: "private internet access", Type: "openvpn", OpenVPNUser: "p123", ServerRegions: "Netherlands"},
+			want: []string{"VPN_SERVICE_PROVIDER=private internet access", "VPN_TYPE=openvpn", "OPENVPN_USER=p123", "OPENVPN_PASSWORD=",
+				"SERVER_REGIONS=Netherlands", "VPN_PORT_FORWARDING=off"},
+		},
+		{
+			name:   "lan subnets",
+			config: &VPNConfig{ServiceProvider: "nordvpn", WireguardPrivateKey: "key", FirewallOutboundSubnets: "192.168.1.0/24,10.0.0.0/8"},
+			want: []string{"VPN_SERVICE_PROVIDER=nordvpn", "VPN_TYPE=wireguard", "WIREGUARD_PRIVATE_KEY=key",
+				"FIREWALL_OUTBOUND_SUBNETS=192.168.1.0/24,10.0.0.0/8"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, variable := range tt.config.Env() {
+				got = append(got, variable.Key+"="+variable.Value)
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("Env() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestVPNConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  VPNConfig
+		secrets bool
+		wantErr string
+	}{
+		{name: "mullvad", config: VPNConfig{ServiceProvider: "mullvad", WireguardPrivateKey: testWireGuardKey, WireguardAddresses: "10.64.222.21/32"}},
+		{name: "missing provider", config: VPNConfig{WireguardPrivateKey: testWireGuardKey}, wantErr: "VPN provider is required"},
+		{name: "unsupported type", config: VPNConfig{ServiceProvider: "mullvad", Type: "openvpn"}, wantErr: "Mullvad does not support openvpn"},
+		{name: "missing addresses", config: VPNConfig{ServiceProvider: "mullvad", WireguardPrivateKey: testWireGuardKey}, wantErr: "WIREGUARD_ADDRESSES is required"},
+		{name: "short key", config: VPNConfig{ServiceProvider: "nordvpn", WireguardPrivateKey: "c2hvcnQ="}, wantErr: "WIREGUARD_PRIVATE_KEY must be a base64 encoded 32 byte WireGuard key"},
+		{name: "address without prefix", config: VPNConfig{ServiceProvider: "mullvad", WireguardPrivateKey: testWireGuardKey, WireguardAddresses: "10.64.222.21"}, wantErr: "CIDR notation"},
+		{name: "secret kept in a file", config: VPNConfig{ServiceProvider: "airvpn", WireguardAddresses: "10.128.0.2/32,fd7d:76ee::2/128"}, secrets: true},
+		{
+			name: "custom endpoint",
+			config: VPNConfig{ServiceProvider: "custom", WireguardPrivateKey: testWireGuardKey, WireguardPublicKey: testWireGuardKey,
+				WireguardAddresses: "10.2.0.2/32", WireguardEndpointIP: "vpn.example.com", WireguardEndpointPort: "70000"},
+			wantErr: "WIREGUARD_ENDPOINT_IP must be an IP address",
+		},
+		{name: "port forwarding unsupported", config: VPNConfig{ServiceProvider: "nordvpn", Type: "openvpn", OpenVPNUser: "u", OpenVPNPassword: "p", PortForwarding: "on"}, wantErr: "NordVPN does not support port forwarding"},
+		{name: "unknown provider", config: VPNConfig{ServiceProvider: "surfshark", WireguardPrivateKey: testWireGuardKey}},
+		{name: "lan subnet without prefix", config: VPNConfig{ServiceProvider: "nordvpn", WireguardPrivateKey: testWireGuardKey, FirewallOutboundSubnets: "192.168.1.0/24,192.168.2.1"}, wantErr: "FIREWALL_OUTBOUND_SUBNETS must list subnets in CIDR notation"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate(tt.secrets)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestComposeGenerator_VPNEnv(t *testing.T) {
+	registry, err := services.NewRegistry()
+	if err != nil {
+		t.Fatalf("Failed to create registry: %v", err)
+	}
+	config := &EnvConfig{VPNConfig: &VPNConfig{ServiceProvider: "airvpn", WireguardPrivateKey: testWireGuardKey,
+		WireguardPresharedKey: testWireGuardKey, WireguardAddresses: "10.128.0.2/32", ServerCountries: "Sweden"}}
+
+	generator := NewComposeGenerator(registry, t.TempDir())
+	generator.SetOptions(ComposeOptions{VPNEnv: config.VPNVariableNames()})
+	content, err := generator.Preview([]string{"qbittorrent"}, true)
+	if err != nil {
+		t.Fatalf("Failed to preview: %v", err)
+	}
+	summaries, err := SummarizeCompose([]byte(content))
+	if err != nil {
+		t.Fatalf("Failed to parse compose: %v", err)
+	}
+
+	var gluetunEnv []string
+	for key := range summaries["gluetun"].Environment {
+		if gluetunVariables[key] {
+			gluetunEnv = append(gluetunEnv, key)
+		}
+	}
+	sort.Strings(gluetunEnv)
+	want := "SERVER_COUNTRIES,VPN_SERVICE_PROVIDER,VPN_TYPE,WIREGUARD_ADDRESSES,WIREGUARD_PRESHARED_KEY,WIREGUARD_PRIVATE_KEY"
+	if got := strings.Join(gluetunEnv, ","); got != want {
+		t.Errorf("Gluetun variables = %s, want %s", got, want)
+	}
+
+	config.SecretsMode = true
+	if got := strings.Join(config.SecretNames(), ","); got != "wireguard_private_key,wireguard_preshared_key" {
+		t.Errorf("SecretNames() = %q", got)
+	}
+}
+
+func TestResolveOutboundSubnets(t *testing.T) {
+	detect := func() []string { return []string{"10.0.0.0/24", "192.168.1.0/24"} }
+	tests := []struct {
+		setting      string
+		want         string
+		wantDetected bool
+		wantErr      bool
+	}{
+		{setting: "", want: "10.0.0.0/24,192.168.1.0/24", wantDetected: true},
+		{setting: "Auto", want: "10.0.0.0/24,192.168.1.0/24", wantDetected: true},
+		{setting: "none", want: ""},
+		{setting: "192.168.1.20/24, 172.16.0.0/12,192.168.1.0/24", want: "192.168.1.0/24,172.16.0.0/12"},
+		{setting: "nas.lan", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, detected, err := ResolveOutboundSubnets(tt.setting, detect)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ResolveOutboundSubnets(%q) error = %v", tt.setting, err)
+			continue
+		}
+		if got != tt.want || detected != tt.wantDetected {
+			t.Errorf("ResolveOutboundSubnets(%q) = %q, %v; want %q, %v", tt.setting, got, detected, tt.want, tt.wantDetected)
+		}
+	}
+}
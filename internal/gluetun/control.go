@@ -0,0 +1,117 @@
+package gluetun
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/woliveiras/corsarr/internal/runtime"
+)
+
+// DefaultContainer is the container name of the generated Gluetun service.
+const DefaultContainer = "gluetun"
+
+// controlServerURL is where the control server listens inside the container.
+const controlServerURL = "http://127.0.0.1:8000"
+
+// ErrNoForwardedPort reports that Gluetun has not obtained a forwarded port
+// from the provider yet.
+var ErrNoForwardedPort = errors.New("no forwarded port from Gluetun yet")
+
+// Client queries the Gluetun control server from inside its container with
+// docker exec, so the server never has to be published on the host.
+type Client struct {
+	runner    runtime.CommandRunner
+	container string
+	apiKey    string
+}
+
+func NewClient(runner runtime.CommandRunner, container, apiKey string) *Client {
+	if container == "" {
+		container = DefaultContainer
+	}
+	return &Client{runner: runner, container: container, apiKey: apiKey}
+}
+
+// ForwardedPort returns the port the VPN provider forwards to the tunnel.
+// Gluetun releases before v3.39 only serve the OpenVPN route.
+func (c *Client) ForwardedPort(ctx context.Context) (int, error) {
+	var response struct {
+		Port int `json:"port"`
+	}
+	if err := c.getJSON(ctx, &response, "/v1/portforward", "/v1/openvpn/portforwarded"); err != nil {
+		return 0, fmt.Errorf("read Gluetun forwarded port: %w", err)
+	}
+	if response.Port == 0 {
+		return 0, ErrNoForwardedPort
+	}
+	if response.Port < 1 || response.Port > 65535 {
+		return 0, fmt.Errorf("read Gluetun forwarded port: invalid port %d", response.Port)
+	}
+	return response.Port, nil
+}
+
+// VPNStatus returns the tunnel status, running once the VPN is connected.
+func (c *Client) VPNStatus(ctx context.Context) (string, error) {
+	var response struct {
+		Status string `json:"status"`
+	}
+	if err := c.getJSON(ctx, &response, "/v1/vpn/status", "/v1/openvpn/status"); err != nil {
+		return "", fmt.Errorf("read Gluetun tunnel status: %w", err)
+	}
+	return response.Status, nil
+}
+
+// PublicIP returns the address the internet sees for traffic in the tunnel.
+func (c *Client) PublicIP(ctx context.Context) (string, error) {
+	var response struct {
+		PublicIP string `json:"public_ip"`
+	}
+	if err := c.getJSON(ctx, &response, "/v1/publicip/ip"); err != nil {
+		return "", fmt.Errorf("read Gluetun public IP: %w", err)
+	}
+	if net.ParseIP(response.PublicIP) == nil {
+		return "", fmt.Errorf("read Gluetun public IP: Gluetun has not resolved its public IP yet")
+	}
+	return response.PublicIP, nil
+}
+
+// getJSON decodes the first route that answers; later routes are fallbacks
+// for older Gluetun releases.
+func (c *Client) getJSON(ctx context.Context, target any, routes ...string) error {
+	var firstErr error
+	for _, route := range routes {
+		body, err := c.get(ctx, route)
+		if err == nil {
+			err = json.Unmarshal([]byte(body), target)
+			if err == nil {
+				return nil
+			}
+			err = fmt.Errorf("decode %s: %w", route, err)
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+func (c *Client) get(ctx context.Context, route string) (string, error) {
+	docker, err := c.runner.LookPath("docker")
+	if err != nil {
+		return "", fmt.Errorf("docker was not found: %w", err)
+	}
+	args := []string{"exec", c.container, "wget", "-q", "-O", "-"}
+	if c.apiKey != "" {
+		args = append(args, "--header", "X-API-Key: "+c.apiKey)
+	}
+	args = append(args, controlServerURL+route)
+	output, err := c.runner.Run(ctx, docker, args...)
+	if err != nil {
+		return "", fmt.Errorf("query %s in container %s: %w", route, c.container, err)
+	}
+	return strings.TrimSpace(output), nil
+}
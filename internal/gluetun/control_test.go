@@ -0,0 +1,72 @@
+package gluetun
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// routeRunner answers docker exec wget calls from a table of routes and
+// docker inspect calls from a table of containers
+type routeRunner struct {
+	responses  map[string]string
+	containers map[string]string
+	calls      [][]string
+}
+
+func (r *routeRunner) LookPath(string) (string, error) {
+	return "/usr/bin/docker", nil
+}
+
+func (r *routeRunner) Run(_ context.Context, _ string, args ...string) (string, error) {
+	r.calls = append(r.calls, append([]string(nil), args...))
+	if args[0] == "inspect" {
+		inspected, ok := r.containers[args[len(args)-1]]
+		if !ok {
+			return "", errors.New("Error: No such object: " + args[len(args)-1])
+		}
+		return inspected, nil
+	}
+	url := args[len(args)-1]
+	route := strings.TrimPrefix(url, controlServerURL)
+	response, ok := r.responses[route]
+	if !ok {
+		return "", errors.New("wget: server returned error: HTTP/1.1 404 Not Found")
+	}
+	return response, nil
+}
+
+func TestClientForwardedPort(t *testing.T) {
+	runner := &routeRunner{responses: map[string]string{"/v1/portforward": `{"port":51413}`}}
+	port, err := NewClient(runner, "", "secret").ForwardedPort(context.Background())
+	if err != nil {
+		t.Fatalf("read forwarded port: %v", err)
+	}
+	if port != 51413 {
+		t.Errorf("port = %d, want 51413", port)
+	}
+	want := []string{"exec", "gluetun", "wget", "-q", "-O", "-", "--header", "X-API-Key: secret", "http://127.0.0.1:8000/v1/portforward"}
+	if !reflect.DeepEqual(runner.calls, [][]string{want}) {
+		t.Errorf("unexpected docker calls\nwant: %v\n got: %v", want, runner.calls)
+	}
+}
+
+func TestClientForwardedPortFallsBackToOpenVPNRoute(t *testing.T) {
+	runner := &routeRunner{responses: map[string]string{"/v1/openvpn/portforwarded": `{"port":40000}`}}
+	port, err := NewClient(runner, "vpn", "").ForwardedPort(context.Background())
+	if err != nil || port != 40000 {
+		t.Fatalf("ForwardedPort() = %d, %v; want 40000", port, err)
+	}
+	if len(runner.calls) != 2 || runner.calls[1][1] != "vpn" {
+		t.Errorf("unexpected docker calls %v", runner.calls)
+	}
+}
+
+func TestClientForwardedPortNotReady(t *testing.T) {
+	runner := &routeRunner{responses: map[string]string{"/v1/portforward": `{"port":0}`}}
+	if _, err := NewClient(runner, "", "").ForwardedPort(context.Background()); !errors.Is(err, ErrNoForwardedPort) {
+		t.Fatalf("expected ErrNoForwardedPort, got %v", err)
+	}
+}
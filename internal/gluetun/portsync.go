@@ -0,0 +1,61 @@
+package gluetun
+
+import (
+	"context"
+	"time"
+)
+
+type PortSource interface {
+	ForwardedPort(ctx context.Context) (int, error)
+}
+
+// PortSync pushes the forwarded port of the tunnel to a download client and
+// pushes it again whenever the provider hands out a new one.
+type PortSync struct {
+	source PortSource
+	apply  func(ctx context.Context, port int) error
+	last   int
+}
+
+// SyncResult describes one synchronization round.
+type SyncResult struct {
+	Port    int
+	Changed bool
+	Err     error
+}
+
+func NewPortSync(source PortSource, apply func(ctx context.Context, port int) error) *PortSync {
+	return &PortSync{source: source, apply: apply}
+}
+
+// Once reads the forwarded port and applies it when it differs from the
+// last port applied. A failed apply is retried on the next round.
+func (s *PortSync) Once(ctx context.Context) SyncResult {
+	port, err := s.source.ForwardedPort(ctx)
+	if err != nil {
+		return SyncResult{Err: err}
+	}
+	if port == s.last {
+		return SyncResult{Port: port}
+	}
+	if err := s.apply(ctx, port); err != nil {
+		return SyncResult{Port: port, Err: err}
+	}
+	s.last = port
+	return SyncResult{Port: port, Changed: true}
+}
+
+// Watch runs a round every interval until ctx is done, passing each result
+// to report.
+func (s *PortSync) Watch(ctx context.Context, interval time.Duration, report func(SyncResult)) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		report(s.Once(ctx))
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
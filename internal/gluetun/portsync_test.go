@@ -0,0 +1,54 @@
+package gluetun
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type portSequence struct {
+	ports []int
+}
+
+func (s *portSequence) ForwardedPort(context.Context) (int, error) {
+	port := s.ports[0]
+	s.ports = s.ports[1:]
+	if port == 0 {
+		return 0, ErrNoForwardedPort
+	}
+	return port, nil
+}
+
+func TestPortSyncAppliesChangedPorts(t *testing.T) {
+	var applied []int
+	failNext := false
+	sync := NewPortSync(&portSequence{ports: []int{0, 40000, 40000, 41000, 41000}}, func(_ context.Context, port int) error {
+		if failNext {
+			failNext = false
+			return errors.New("qBittorrent is restarting")
+		}
+		applied = append(applied, port)
+		return nil
+	})
+
+	var changed []bool
+	for round := 0; round < 5; round++ {
+		if round == 3 {
+			failNext = true
+		}
+		result := sync.Once(context.Background())
+		changed = append(changed, result.Changed)
+		if round == 0 && !errors.Is(result.Err, ErrNoForwardedPort) {
+			t.Errorf("round 0: expected ErrNoForwardedPort, got %v", result.Err)
+		}
+	}
+
+	// The failed push of 41000 is retried on the next round
+	if want := []int{40000, 41000}; !reflect.DeepEqual(applied, want) {
+		t.Errorf("applied = %v, want %v", applied, want)
+	}
+	if want := []bool{false, true, false, false, true}; !reflect.DeepEqual(changed, want) {
+		t.Errorf("changed = %v, want %v", changed, want)
+	}
+}
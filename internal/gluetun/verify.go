@@ -0,0 +1,190 @@
+package gluetun
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/woliveiras/corsarr/internal/runtime"
+)
+
+// StatusRunning is the tunnel status of a connected VPN.
+const StatusRunning = "running"
+
+// Problem kinds reported by a verification
+const (
+	ProblemTunnelDown     = "tunnel_down"
+	ProblemIPLeak         = "ip_leak"
+	ProblemUnverified     = "unverified"
+	ProblemOutsideTunnel  = "outside_tunnel"
+	ProblemStaleNamespace = "stale_namespace"
+)
+
+// Route is a container of the compose file and whether it should use the
+// tunnel.
+type Route struct {
+	Service   string
+	Container string
+	// Download marks download clients, which must never reach the internet
+	// outside the tunnel.
+	Download bool
+	// ExpectVPN is set for services the compose file puts behind Gluetun.
+	ExpectVPN bool
+}
+
+// RouteResult is the network namespace a running container actually uses.
+type RouteResult struct {
+	Route
+	NetworkMode string
+	// Tunneled reports whether the container shares Gluetun's namespace.
+	Tunneled bool
+	Err      error
+}
+
+// Problem is a reason not to trust the tunnel. Service is empty for the
+// tunnel itself.
+type Problem struct {
+	Kind    string
+	Service string
+	Detail  string
+}
+
+// Verification holds what was observed about the tunnel and the containers.
+type Verification struct {
+	Status   string
+	TunnelIP string
+	HostIP   string
+	Routes   []RouteResult
+	Errors   []error
+}
+
+// Problems lists every failed check; an empty list means the traffic of the
+// routed services leaves through the tunnel.
+func (v *Verification) Problems() []Problem {
+	var problems []Problem
+	for _, err := range v.Errors {
+		problems = append(problems, Problem{Kind: ProblemUnverified, Detail: err.Error()})
+	}
+	if v.Status != "" && v.Status != StatusRunning {
+		problems = append(problems, Problem{Kind: ProblemTunnelDown, Detail: v.Status})
+	}
+	if v.TunnelIP != "" && v.TunnelIP == v.HostIP {
+		problems = append(problems, Problem{Kind: ProblemIPLeak, Detail: v.TunnelIP})
+	}
+	for _, route := range v.Routes {
+		switch {
+		case route.Err != nil:
+			if route.ExpectVPN || route.Download {
+				problems = append(problems, Problem{Kind: ProblemUnverified, Service: route.Service, Detail: route.Err.Error()})
+			}
+		case route.Tunneled:
+		case route.Download:
+			problems = append(problems, Problem{Kind: ProblemOutsideTunnel, Service: route.Service, Detail: route.NetworkMode})
+		case route.ExpectVPN:
+			problems = append(problems, Problem{Kind: ProblemStaleNamespace, Service: route.Service, Detail: route.NetworkMode})
+		}
+	}
+	return problems
+}
+
+// Verifier checks that the tunnel is up, that it changes the public IP of
+// the host and that the routed containers share Gluetun's network namespace.
+type Verifier struct {
+	runner    runtime.CommandRunner
+	client    *Client
+	container string
+	hostIP    func(ctx context.Context) (string, error)
+}
+
+func NewVerifier(
+	runner runtime.CommandRunner,
+	client *Client,
+	hostIP func(ctx context.Context) (string, error),
+) *Verifier {
+	return &Verifier{runner: runner, client: client, container: client.container, hostIP: hostIP}
+}
+
+func (v *Verifier) Verify(ctx context.Context, routes []Route) *Verification {
+	result := &Verification{}
+	var err error
+	if result.Status, err = v.client.VPNStatus(ctx); err != nil {
+		result.Errors = append(result.Errors, err)
+	}
+	if result.TunnelIP, err = v.client.PublicIP(ctx); err != nil {
+		result.Errors = append(result.Errors, err)
+	}
+	if result.HostIP, err = v.hostIP(ctx); err != nil {
+		result.Errors = append(result.Errors, fmt.Errorf("look up the host public IP: %w", err))
+	}
+
+	gluetunID, _, err := v.inspect(ctx, v.container)
+	if err != nil {
+		result.Errors = append(result.Errors, err)
+	}
+	for _, route := range routes {
+		routeResult := RouteResult{Route: route}
+		_, routeResult.NetworkMode, routeResult.Err = v.inspect(ctx, route.Container)
+		if gluetunID != "" {
+			routeResult.Tunneled = routeResult.NetworkMode == "container:"+gluetunID ||
+				routeResult.NetworkMode == "container:"+v.container
+		}
+		result.Routes = append(result.Routes, routeResult)
+	}
+	return result
+}
+
+// inspect returns the ID and network mode of a container
+func (v *Verifier) inspect(ctx context.Context, container string) (string, string, error) {
+	docker, err := v.runner.LookPath("docker")
+	if err != nil {
+		return "", "", fmt.Errorf("docker was not found: %w", err)
+	}
+	output, err := v.runner.Run(ctx, docker, "inspect", "--format", "{{.Id}} {{.HostConfig.NetworkMode}}", container)
+	if err != nil {
+		return "", "", fmt.Errorf("inspect container %s: %w", container, err)
+	}
+	id, mode, _ := strings.Cut(strings.TrimSpace(output), " ")
+	return id, mode, nil
+}
+
+// PublicIPLookup returns a lookup of the host's public IP through endpoint,
+// which answers with the address as plain text or as {"ip": "..."} JSON.
+func PublicIPLookup(endpoint string) func(ctx context.Context) (string, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	client := &http.Client{Transport: transport, Timeout: 10 * time.Second}
+	return func(ctx context.Context) (string, error) {
+		request, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+		if err != nil {
+			return "", err
+		}
+		response, err := client.Do(request)
+		if err != nil {
+			return "", err
+		}
+		defer response.Body.Close()
+		if response.StatusCode != http.StatusOK {
+			return "", fmt.Errorf("unexpected HTTP status %d from %s", response.StatusCode, endpoint)
+		}
+		body, err := io.ReadAll(io.LimitReader(response.Body, 4096))
+		if err != nil {
+			return "", err
+		}
+		address := strings.TrimSpace(string(body))
+		var decoded struct {
+			IP string `json:"ip"`
+		}
+		if json.Unmarshal(body, &decoded) == nil && decoded.IP != "" {
+			address = decoded.IP
+		}
+		if net.ParseIP(address) == nil {
+			return "", fmt.Errorf("%s did not answer with an IP address", endpoint)
+		}
+		return address, nil
+	}
+}
@@ -0,0 +1,110 @@
+package gluetun
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestVerifierReportsTraffic(t *testing.T) {
+	routes := []Route{
+		{Service: "qbittorrent", Container: "qbittorrent", Download: true, ExpectVPN: true},
+		{Service: "sabnzbd", Container: "sabnzbd", Download: true},
+		{Service: "radarr", Container: "radarr", ExpectVPN: true},
+		{Service: "jellyfin", Container: "jellyfin"},
+	}
+	tests := []struct {
+		name       string
+		responses  map[string]string
+		containers map[string]string
+		hostIP     string
+		want       []Problem
+	}{
+		{
+			name: "all traffic in the tunnel",
+			responses: map[string]string{
+				"/v1/vpn/status":  `{"status":"running"}`,
+				"/v1/publicip/ip": `{"public_ip":"185.65.134.1","country":"Sweden"}`,
+			},
+			containers: map[string]string{
+				"gluetun":     "abc123 corsarr_default",
+				"qbittorrent": "q1 container:abc123",
+				"sabnzbd":     "s1 container:abc123",
+				"radarr":      "r1 container:gluetun",
+				"jellyfin":    "j1 corsarr_media",
+			},
+			hostIP: "203.0.113.7",
+		},
+		{
+			name: "leaks",
+			responses: map[string]string{
+				"/v1/openvpn/status": `{"status":"stopped"}`,
+				"/v1/publicip/ip":    `{"public_ip":"203.0.113.7"}`,
+			},
+			containers: map[string]string{
+				"gluetun":     "def456 corsarr_default",
+				"qbittorrent": "q1 container:abc123",
+				"sabnzbd":     "s1 corsarr_media",
+				"radarr":      "r1 container:abc123",
+				"jellyfin":    "j1 corsarr_media",
+			},
+			hostIP: "203.0.113.7",
+			want: []Problem{
+				{Kind: ProblemTunnelDown, Detail: "stopped"},
+				{Kind: ProblemIPLeak, Detail: "203.0.113.7"},
+				{Kind: ProblemOutsideTunnel, Service: "qbittorrent", Detail: "container:abc123"},
+				{Kind: ProblemOutsideTunnel, Service: "sabnzbd", Detail: "corsarr_media"},
+				{Kind: ProblemStaleNamespace, Service: "radarr", Detail: "container:abc123"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := &routeRunner{responses: tt.responses, containers: tt.containers}
+			hostIP := func(context.Context) (string, error) { return tt.hostIP, nil }
+			verification := NewVerifier(runner, NewClient(runner, "", ""), hostIP).Verify(context.Background(), routes)
+			if got := verification.Problems(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("unexpected problems\nwant: %+v\n got: %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestVerifierReportsMissingContainers(t *testing.T) {
+	runner := &routeRunner{
+		responses: map[string]string{
+			"/v1/vpn/status":  `{"status":"running"}`,
+			"/v1/publicip/ip": `{"public_ip":"185.65.134.1"}`,
+		},
+		containers: map[string]string{"gluetun": "abc123 corsarr_default"},
+	}
+	hostIP := func(context.Context) (string, error) { return "203.0.113.7", nil }
+	verification := NewVerifier(runner, NewClient(runner, "", ""), hostIP).Verify(context.Background(), []Route{
+		{Service: "qbittorrent", Container: "qbittorrent", Download: true, ExpectVPN: true},
+		{Service: "jellyfin", Container: "jellyfin"},
+	})
+	problems := verification.Problems()
+	if len(problems) != 1 || problems[0].Kind != ProblemUnverified || problems[0].Service != "qbittorrent" {
+		t.Errorf("expected the stopped download client to be unverified, got %+v", problems)
+	}
+}
+
+func TestPublicIPLookup(t *testing.T) {
+	responses := map[string]string{"/plain": "203.0.113.7\n", "/json": `{"ip":"2001:db8::1"}`, "/html": "<html>"}
+	server := httptest.NewServer(http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+		_, _ = response.Write([]byte(responses[request.URL.Path]))
+	}))
+	defer server.Close()
+
+	for path, want := range map[string]string{"/plain": "203.0.113.7", "/json": "2001:db8::1"} {
+		if got, err := PublicIPLookup(server.URL + path)(context.Background()); err != nil || got != want {
+			t.Errorf("lookup %s = %q, %v; want %q", path, got, err, want)
+		}
+	}
+	if _, err := PublicIPLookup(server.URL + "/html")(context.Background()); err == nil {
+		t.Error("expected an answer without an IP address to fail")
+	}
+}
@@ -90,6 +90,12 @@ var applicationMetadata = map[string]legalMetadata{
 		licenseURL:      "https://github.com/Radarr/Radarr/blob/develop/LICENSE",
 		imageMaintainer: "LinuxServer.io",
 	},
+	"readarr": {
+		license: "GNU GPL v3", officialURL: "https://readarr.com/",
+		sourceURL:       "https://github.com/Readarr/Readarr",
+		licenseURL:      "https://github.com/Readarr/Readarr/blob/develop/LICENSE.md",
+		imageMaintainer: "LinuxServer.io",
+	},
 	"sonarr": {
 		license: "GNU GPL v3", officialURL: "https://sonarr.tv/",
 		sourceURL:       "https://github.com/Sonarr/Sonarr",
@@ -141,7 +147,7 @@ func newCatalog(
 ) (*Catalog, error) {
 	catalog := &Catalog{links: make(map[string]map[string]string)}
 	for _, service := range registry.GetAllServices() {
-		if service.WebUI == nil {
+		if service.WebUI == nil || runtimecatalog.IsUnpinned(service.ID) {
 			continue
 		}
 		metadata, exists := applicationMetadata[service.ID]
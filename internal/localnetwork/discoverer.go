@@ -54,6 +54,38 @@ func (d *Discoverer) HTTPURLs(port int) []string {
 	return urls
 }
 
+func (d *Discoverer) IPv4Subnets() []string {
+	interfaces, err := d.interfaces()
+	if err != nil {
+		return nil
+	}
+
+	unique := make(map[string]struct{})
+	for _, networkInterface := range interfaces {
+		if !usableInterface(networkInterface) {
+			continue
+		}
+		addresses, addressErr := d.addresses(networkInterface)
+		if addressErr != nil {
+			continue
+		}
+		for _, address := range addresses {
+			ip, network, parseErr := net.ParseCIDR(address.String())
+			if parseErr != nil || ip.To4() == nil || !ip.IsPrivate() {
+				continue
+			}
+			unique[network.String()] = struct{}{}
+		}
+	}
+
+	subnets := make([]string, 0, len(unique))
+	for subnet := range unique {
+		subnets = append(subnets, subnet)
+	}
+	sort.Strings(subnets)
+	return subnets
+}
+
 func usableInterface(networkInterface net.Interface) bool {
 	if networkInterface.Flags&net.FlagUp == 0 || networkInterface.Flags&net.FlagLoopback != 0 {
 		return false
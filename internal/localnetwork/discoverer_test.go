@@ -53,3 +53,38 @@ func TestDiscovererRejectsInvalidPort(t *testing.T) {
 		t.Fatalf("expected no URLs, got %#v", urls)
 	}
 }
+
+func TestDiscovererReturnsPrivateIPv4SubnetsFromPhysicalInterfaces(t *testing.T) {
+	discoverer := &Discoverer{
+		interfaces: func() ([]net.Interface, error) {
+			return []net.Interface{
+				{Index: 1, Name: "eth0", Flags: net.FlagUp},
+				{Index: 2, Name: "wlan0", Flags: net.FlagUp},
+				{Index: 3, Name: "br-1a2b", Flags: net.FlagUp},
+				{Index: 4, Name: "eth1"},
+			}, nil
+		},
+		addresses: func(networkInterface net.Interface) ([]net.Addr, error) {
+			switch networkInterface.Name {
+			case "eth0":
+				return []net.Addr{
+					&net.IPNet{IP: net.ParseIP("192.168.1.42"), Mask: net.CIDRMask(24, 32)},
+					&net.IPNet{IP: net.ParseIP("203.0.113.7"), Mask: net.CIDRMask(24, 32)},
+					&net.IPNet{IP: net.ParseIP("fd00::42"), Mask: net.CIDRMask(64, 128)},
+				}, nil
+			case "wlan0":
+				return []net.Addr{
+					&net.IPNet{IP: net.ParseIP("192.168.1.77"), Mask: net.CIDRMask(24, 32)},
+					&net.IPNet{IP: net.ParseIP("10.10.4.2"), Mask: net.CIDRMask(22, 32)},
+				}, nil
+			default:
+				return []net.Addr{&net.IPNet{IP: net.ParseIP("172.18.0.1"), Mask: net.CIDRMask(16, 32)}}, nil
+			}
+		},
+	}
+
+	want := []string{"10.10.4.0/22", "192.168.1.0/24"}
+	if got := discoverer.IPv4Subnets(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected local subnets %#v", got)
+	}
+}
@@ -0,0 +1,356 @@
+package profile
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/woliveiras/corsarr/internal/generator"
+	"github.com/woliveiras/corsarr/internal/services"
+)
+
+// How a compose service was matched onto the registry
+const (
+	MatchImage = "image"
+	MatchName  = "name"
+)
+
+// importedEnvKeys are the .env settings a profile stores
+var importedEnvKeys = []string{"COMPOSE_PROJECT_NAME", "ARRPATH", "TZ", "PUID", "PGID", "UMASK"}
+
+// ComposeMatch records the registry service a compose service became
+type ComposeMatch struct {
+	ComposeService string
+	ServiceID      string
+	MatchedBy      string
+}
+
+// UnmappedService is a compose service the profile does not cover. DuplicateOf
+// names the compose service already mapped onto the same registry service.
+type UnmappedService struct {
+	ComposeService string
+	Image          string
+	DuplicateOf    string
+}
+
+// ComposeImport is the profile built from an existing docker-compose.yml
+type ComposeImport struct {
+	Profile  *Profile
+	Matches  []ComposeMatch
+	Unmapped []UnmappedService
+	// Defaults lists the .env settings found nowhere, which keep their
+	// default value
+	Defaults []string
+}
+
+// ImportCompose maps the services of a compose file onto registry services
+// by image and then by name, and carries over the .env settings, VPN mode and
+// published host ports into a new profile. env holds the variables of the
+// .env next to the compose file and resolves ${VAR} references.
+func ImportCompose(registry *services.Registry, name string, content []byte, env map[string]string) (*ComposeImport, error) {
+	summaries, err := generator.SummarizeCompose(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse compose file: %w", err)
+	}
+	bindings, err := generator.ParseComposePorts(content)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ComposeImport{Profile: NewProfile(name)}
+	p := result.Profile
+
+	names := make([]string, 0, len(summaries))
+	for composeName := range summaries {
+		names = append(names, composeName)
+	}
+	sort.Strings(names)
+
+	mapped := make(map[string]string)
+	for _, composeName := range names {
+		summary := summaries[composeName]
+		id, matchedBy := matchRegistryService(registry, composeName, summary)
+		if id == "" {
+			result.Unmapped = append(result.Unmapped, UnmappedService{ComposeService: composeName, Image: summary.Image})
+			continue
+		}
+		if previous, ok := mapped[id]; ok {
+			result.Unmapped = append(result.Unmapped, UnmappedService{ComposeService: composeName, Image: summary.Image, DuplicateOf: previous})
+			continue
+		}
+		mapped[id] = composeName
+		result.Matches = append(result.Matches, ComposeMatch{ComposeService: composeName, ServiceID: id, MatchedBy: matchedBy})
+		p.Services = append(p.Services, id)
+	}
+
+	// VPN mode: a Gluetun service, or services sharing its network namespace
+	gluetun := mapped["gluetun"]
+	for _, composeName := range names {
+		if behindGluetun(summaries[composeName], gluetun) {
+			p.VPN.Enabled = true
+		}
+	}
+	if gluetun != "" {
+		p.VPN.Enabled = true
+		importVPNSettings(p, summaries[gluetun].Environment, env)
+	}
+	if p.VPN.Enabled {
+		p.VPNRoutes = importVPNRoutes(registry, result.Matches, summaries, gluetun)
+	}
+
+	for _, key := range importedEnvKeys {
+		if value := importedSetting(key, result, summaries, env); value != "" {
+			p.Environment[key] = value
+		}
+	}
+	if _, ok := p.Environment["ARRPATH"]; !ok {
+		if arrPath := deriveARRPath(registry, result.Matches, summaries, env); arrPath != "" {
+			p.Environment["ARRPATH"] = arrPath
+		}
+	}
+	defaults := generator.NewDefaultEnvConfig()
+	defaultValues := map[string]string{
+		"COMPOSE_PROJECT_NAME": defaults.ComposeProjectName,
+		"ARRPATH":              defaults.ARRPath,
+		"TZ":                   defaults.Timezone,
+		"PUID":                 defaults.PUID,
+		"PGID":                 defaults.PGID,
+		"UMASK":                defaults.UMASK,
+	}
+	for _, key := range importedEnvKeys {
+		if _, ok := p.Environment[key]; !ok {
+			p.Environment[key] = defaultValues[key]
+			result.Defaults = append(result.Defaults, key)
+		}
+	}
+
+	p.Ports = importPorts(registry, result.Matches, summaries, bindings, gluetun)
+	return result, nil
+}
+
+// behindGluetun reports whether a service shares the network namespace of
+// the Gluetun service, which is gluetun unless the file names it otherwise
+func behindGluetun(summary generator.ComposeServiceSummary, gluetun string) bool {
+	switch summary.NetworkMode {
+	case "service:gluetun", "container:gluetun":
+		return true
+	case "":
+		return false
+	}
+	return gluetun != "" && summary.NetworkMode == "service:"+gluetun
+}
+
+// importVPNRoutes keeps the services running outside Gluetun's network
+// namespace in VPN mode as bridge routes
+func importVPNRoutes(registry *services.Registry, matches []ComposeMatch, summaries map[string]generator.ComposeServiceSummary, gluetun string) map[string]string {
+	routes := make(map[string]string)
+	for _, match := range matches {
+		svc, err := registry.GetService(match.ServiceID)
+		if err != nil || svc.Category == services.CategoryVPN || svc.RequiresVPN || behindGluetun(summaries[match.ComposeService], gluetun) {
+			continue
+		}
+		if svc.Network.VPNMode.NetworkMode != services.RouteBridge && len(svc.Network.BridgeMode.Networks) > 0 {
+			routes[svc.ID] = services.RouteBridge
+		}
+	}
+	if len(routes) == 0 {
+		return nil
+	}
+	return routes
+}
+
+// matchRegistryService finds the registry service behind a compose service:
+// the same image repository first, then the service or container name, then
+// the last part of the image name (hotio/radarr for radarr)
+func matchRegistryService(registry *services.Registry, composeName string, summary generator.ComposeServiceSummary) (string, string) {
+	candidates := registry.GetAllServices()
+	repository := imageRepository(summary.Image)
+
+	if repository != "" {
+		for _, candidate := range candidates {
+			if imageRepository(candidate.Image) == repository {
+				return candidate.ID, MatchImage
+			}
+		}
+	}
+	for _, candidate := range candidates {
+		for _, serviceName := range []string{composeName, summary.ContainerName} {
+			if serviceName != "" && (serviceName == candidate.ID || serviceName == candidate.ContainerName) {
+				return candidate.ID, MatchName
+			}
+		}
+	}
+	if base := path.Base(repository); repository != "" {
+		for _, candidate := range candidates {
+			if base == candidate.ID || base == path.Base(imageRepository(candidate.Image)) {
+				return candidate.ID, MatchImage
+			}
+		}
+	}
+	return "", ""
+}
+
+// imageRepository strips the registry host, tag and digest from an image
+// reference: lscr.io/linuxserver/radarr:latest becomes linuxserver/radarr
+func imageRepository(image string) string {
+	image = strings.ToLower(strings.TrimSpace(image))
+	image, _, _ = strings.Cut(image, "@")
+	if slash := strings.LastIndex(image, "/"); strings.LastIndex(image, ":") > slash {
+		image = image[:strings.LastIndex(image, ":")]
+	}
+	parts := strings.Split(image, "/")
+	if len(parts) > 1 && (strings.ContainsAny(parts[0], ".:") || parts[0] == "localhost") {
+		parts = parts[1:]
+	}
+	return strings.TrimPrefix(strings.Join(parts, "/"), "library/")
+}
+
+// importVPNSettings reads the provider and credentials from the Gluetun
+// environment. Credentials mounted from files switch the profile to secrets
+// mode instead.
+func importVPNSettings(p *Profile, gluetunEnv, env map[string]string) {
+	lookup := func(key string) string {
+		if value, ok := gluetunEnv[key]; ok {
+			return expandEnv(value, env)
+		}
+		return env[key]
+	}
+
+	p.VPN.Provider = lookup("VPN_SERVICE_PROVIDER")
+	p.VPN.Type = lookup("VPN_TYPE")
+	p.VPN.Username = lookup("OPENVPN_USER")
+	p.VPN.WireguardPrivateKey = lookup("WIREGUARD_PRIVATE_KEY")
+	p.VPN.WireguardPresharedKey = lookup("WIREGUARD_PRESHARED_KEY")
+	p.VPN.WireguardPublicKey = lookup("WIREGUARD_PUBLIC_KEY")
+	p.VPN.WireguardAddresses = lookup("WIREGUARD_ADDRESSES")
+	p.VPN.EndpointIP = lookup("WIREGUARD_ENDPOINT_IP")
+	p.VPN.EndpointPort = lookup("WIREGUARD_ENDPOINT_PORT")
+	p.VPN.OpenVPNPassword = lookup("OPENVPN_PASSWORD")
+	p.VPN.OpenVPNCustomConfig = lookup("OPENVPN_CUSTOM_CONFIG")
+	p.VPN.Country = lookup("SERVER_COUNTRIES")
+	p.VPN.City = lookup("SERVER_CITIES")
+	p.VPN.Hostnames = lookup("SERVER_HOSTNAMES")
+	p.VPN.Regions = lookup("SERVER_REGIONS")
+	p.VPN.PortForwarding = lookup("VPN_PORT_FORWARDING") == "on"
+	p.VPN.OutboundSubnets = lookup("FIREWALL_OUTBOUND_SUBNETS")
+	for key := range gluetunEnv {
+		if strings.HasSuffix(key, "_SECRETFILE") {
+			p.Secrets = true
+		}
+	}
+}
+
+// importedSetting returns a .env setting from the .env file, or from the
+// literal environment of the mapped services
+func importedSetting(key string, result *ComposeImport, summaries map[string]generator.ComposeServiceSummary, env map[string]string) string {
+	if value := env[key]; value != "" {
+		return value
+	}
+	for _, match := range result.Matches {
+		value, ok := summaries[match.ComposeService].Environment[key]
+		if !ok {
+			continue
+		}
+		if value = expandEnv(value, env); value != "" && !strings.Contains(value, "$") {
+			return value
+		}
+	}
+	return ""
+}
+
+// deriveARRPath recovers the base path from the volumes of the mapped
+// services: /srv/media/config/radarr:/config against the definition
+// ${ARRPATH}config/radarr:/config gives /srv/media/
+func deriveARRPath(registry *services.Registry, matches []ComposeMatch, summaries map[string]generator.ComposeServiceSummary, env map[string]string) string {
+	for _, match := range matches {
+		svc, err := registry.GetService(match.ServiceID)
+		if err != nil {
+			continue
+		}
+		for _, volume := range summaries[match.ComposeService].Volumes {
+			host, container, ok := splitVolume(expandEnv(volume, env))
+			if !ok {
+				continue
+			}
+			for _, mapping := range svc.Volumes {
+				suffix, found := strings.CutPrefix(mapping.Host, "${ARRPATH}")
+				if !found || mapping.Container != container || !strings.HasSuffix(host, suffix) {
+					continue
+				}
+				if base := strings.TrimSuffix(host, suffix); strings.HasSuffix(base, "/") {
+					return base
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// splitVolume splits a short volume syntax into host and container paths,
+// dropping the access mode
+func splitVolume(volume string) (string, string, bool) {
+	parts := strings.Split(volume, ":")
+	if len(parts) < 2 {
+		return "", "", false
+	}
+	if len(parts) > 2 && !strings.HasPrefix(parts[len(parts)-1], "/") {
+		parts = parts[:len(parts)-1]
+	}
+	return strings.Join(parts[:len(parts)-1], ":"), parts[len(parts)-1], true
+}
+
+// importPorts keeps the host ports that differ from the definitions as port
+// overrides. Services behind Gluetun publish their ports on it.
+func importPorts(registry *services.Registry, matches []ComposeMatch, summaries map[string]generator.ComposeServiceSummary, bindings []generator.ComposePort, gluetun string) map[string]string {
+	overrides := make(map[string]string)
+	for _, match := range matches {
+		svc, err := registry.GetService(match.ServiceID)
+		if err != nil {
+			continue
+		}
+		publisher := match.ComposeService
+		if gluetun != "" && behindGluetun(summaries[publisher], gluetun) {
+			publisher = gluetun
+		}
+		for _, mapping := range svc.Ports {
+			protocol := mapping.Protocol
+			if protocol == "" {
+				protocol = "tcp"
+			}
+			for _, binding := range bindings {
+				if binding.Service != publisher || binding.Container != mapping.Container || binding.Protocol != protocol {
+					continue
+				}
+				if host := strconv.Itoa(binding.Host); host != mapping.Host {
+					overrides[svc.ID+":"+mapping.Container] = host
+				}
+				break
+			}
+		}
+	}
+	if len(overrides) == 0 {
+		return nil
+	}
+	return overrides
+}
+
+// expandEnv resolves ${VAR} and ${VAR:-default} references with the .env
+// variables, leaving unknown references in place
+func expandEnv(value string, env map[string]string) string {
+	return os.Expand(value, func(name string) string {
+		key, fallback, hasDefault := strings.Cut(name, ":-")
+		if !hasDefault {
+			key, fallback, hasDefault = strings.Cut(name, "-")
+		}
+		if resolved, ok := env[key]; ok && (resolved != "" || !hasDefault) {
+			return resolved
+		}
+		if hasDefault {
+			return fallback
+		}
+		return "${" + name + "}"
+	})
+}
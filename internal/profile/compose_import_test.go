@@ -0,0 +1,97 @@
+package profile
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/woliveiras/corsarr/internal/services"
+)
+
+func TestImportCompose(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	registry, err := services.NewRegistry()
+	if err != nil {
+		t.Fatalf("Failed to create registry: %v", err)
+	}
+
+	compose := `services:
+  vpn:
+    image: qmcgaw/gluetun:v3
+    environment:
+      - VPN_SERVICE_PROVIDER=mullvad
+      - WIREGUARD_PRIVATE_KEY=${WG_KEY}
+      - WIREGUARD_ADDRESSES=${WG_ADDRESSES:-10.64.222.21/32}
+      - FIREWALL_OUTBOUND_SUBNETS=192.168.1.0/24
+    ports:
+      - 8080:8080
+      - 17878:7878
+  qbit:
+    image: lscr.io/linuxserver/qbittorrent:4.6.0
+    network_mode: service:vpn
+  movies:
+    image: ghcr.io/hotio/radarr:release
+    network_mode: service:vpn
+    volumes:
+      - /srv/media/config/radarr:/config
+  sonarr:
+    image: example/custom-sonarr
+    environment:
+      TZ: Europe/Lisbon
+  tv:
+    image: linuxserver/sonarr
+  watchtower:
+    image: containrrr/watchtower
+`
+	env := map[string]string{"WG_KEY": "private-key", "PUID": "1001"}
+
+	result, err := ImportCompose(registry, "imported", []byte(compose), env)
+	if err != nil {
+		t.Fatalf("Failed to import: %v", err)
+	}
+	p := result.Profile
+
+	var matches []string
+	for _, match := range result.Matches {
+		matches = append(matches, match.ComposeService+"="+match.ServiceID+"/"+match.MatchedBy)
+	}
+	if got := strings.Join(matches, ","); got != "movies=radarr/image,qbit=qbittorrent/image,sonarr=sonarr/name,vpn=gluetun/image" {
+		t.Errorf("Matches = %s", got)
+	}
+	if len(result.Unmapped) != 2 || result.Unmapped[0].DuplicateOf != "sonarr" || result.Unmapped[1].ComposeService != "watchtower" {
+		t.Errorf("Unmapped = %+v", result.Unmapped)
+	}
+
+	if !p.VPN.Enabled || p.VPN.Provider != "mullvad" || p.VPN.WireguardPrivateKey != "private-key" || p.VPN.WireguardAddresses != "10.64.222.21/32" || p.VPN.OutboundSubnets != "192.168.1.0/24" {
+		t.Errorf("Expected the Gluetun settings, got %+v", p.VPN)
+	}
+	wantEnv := map[string]string{"ARRPATH": "/srv/media/", "TZ": "Europe/Lisbon", "PUID": "1001", "PGID": "1000"}
+	for key, value := range wantEnv {
+		if p.Environment[key] != value {
+			t.Errorf("Environment[%s] = %q, want %q", key, p.Environment[key], value)
+		}
+	}
+	if strings.Join(result.Defaults, ",") != "COMPOSE_PROJECT_NAME,PGID,UMASK" {
+		t.Errorf("Defaults = %v", result.Defaults)
+	}
+	if len(p.Ports) != 1 || p.Ports["radarr:7878"] != "17878" {
+		t.Errorf("Ports = %v", p.Ports)
+	}
+	if len(p.VPNRoutes) != 1 || p.VPNRoutes["sonarr"] != services.RouteBridge {
+		t.Errorf("VPNRoutes = %v", p.VPNRoutes)
+	}
+}
+
+func TestImageRepository(t *testing.T) {
+	tests := map[string]string{
+		"lscr.io/linuxserver/radarr:latest":  "linuxserver/radarr",
+		"linuxserver/radarr":                 "linuxserver/radarr",
+		"caddy:2-alpine":                     "caddy",
+		"docker.io/library/caddy":            "caddy",
+		"localhost:5000/radarr:v1@sha256:ab": "radarr",
+	}
+	for image, want := range tests {
+		if got := imageRepository(image); got != want {
+			t.Errorf("imageRepository(%q) = %q, want %q", image, got, want)
+		}
+	}
+}
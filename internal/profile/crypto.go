@@ -0,0 +1,157 @@
+package profile
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+const (
+	// EncryptedFormat identifies files written by EncryptProfileData
+	EncryptedFormat = "corsarr-encrypted-profile/v1"
+	// PassphraseEnv supplies the passphrase of encrypted profiles without
+	// prompting, for automation
+	PassphraseEnv = "CORSARR_PROFILE_PASSPHRASE"
+
+	kdfPBKDF2SHA256 = "pbkdf2-sha256"
+	kdfIterations   = 600000
+	// maxKDFIterations bounds the work a crafted file can demand on import
+	maxKDFIterations = 10 * kdfIterations
+	keyLength        = 32
+	saltLength       = 16
+)
+
+var (
+	// ErrEncrypted is returned when an encrypted profile is read without a
+	// passphrase provider
+	ErrEncrypted = errors.New("profile is encrypted")
+	// ErrWrongPassphrase is returned when decryption fails authentication
+	ErrWrongPassphrase = errors.New("wrong passphrase or corrupted profile")
+)
+
+// encryptedEnvelope is the JSON document of an encrypted profile. The
+// ciphertext holds the YAML profile sealed with AES-256-GCM under a key
+// derived from the passphrase.
+type encryptedEnvelope struct {
+	Format     string `json:"format"`
+	KDF        string `json:"kdf"`
+	Iterations int    `json:"iterations"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// PassphraseProvider returns the passphrase of an encrypted profile. source is
+// the profile name or the imported file.
+type PassphraseProvider func(source string) (string, error)
+
+var passphraseProvider PassphraseProvider
+
+// SetPassphraseProvider sets how LoadProfile and ImportProfile obtain the
+// passphrase of encrypted profiles
+func SetPassphraseProvider(provider PassphraseProvider) {
+	passphraseProvider = provider
+}
+
+// IsEncrypted reports whether data is an encrypted profile
+func IsEncrypted(data []byte) bool {
+	var envelope struct {
+		Format string `json:"format"`
+	}
+	return json.Unmarshal(data, &envelope) == nil && envelope.Format == EncryptedFormat
+}
+
+// EncryptProfileData seals a serialized profile with a passphrase
+func EncryptProfileData(plaintext []byte, passphrase string) ([]byte, error) {
+	if passphrase == "" {
+		return nil, fmt.Errorf("passphrase must not be empty")
+	}
+	envelope := encryptedEnvelope{
+		Format:     EncryptedFormat,
+		KDF:        kdfPBKDF2SHA256,
+		Iterations: kdfIterations,
+		Salt:       make([]byte, saltLength),
+	}
+	if _, err := rand.Read(envelope.Salt); err != nil {
+		return nil, fmt.Errorf("failed to generate salt: %w", err)
+	}
+	aead, err := newProfileCipher(passphrase, envelope.Salt, envelope.Iterations)
+	if err != nil {
+		return nil, err
+	}
+	envelope.Nonce = make([]byte, aead.NonceSize())
+	if _, err := rand.Read(envelope.Nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	envelope.Ciphertext = aead.Seal(nil, envelope.Nonce, plaintext, []byte(EncryptedFormat))
+
+	data, err := json.MarshalIndent(envelope, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal encrypted profile: %w", err)
+	}
+	return data, nil
+}
+
+// DecryptProfileData opens data sealed by EncryptProfileData
+func DecryptProfileData(data []byte, passphrase string) ([]byte, error) {
+	var envelope encryptedEnvelope
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return nil, fmt.Errorf("failed to parse encrypted profile: %w", err)
+	}
+	if envelope.Format != EncryptedFormat {
+		return nil, fmt.Errorf("unsupported encrypted profile format %q", envelope.Format)
+	}
+	if envelope.KDF != kdfPBKDF2SHA256 {
+		return nil, fmt.Errorf("unsupported key derivation %q", envelope.KDF)
+	}
+	if envelope.Iterations < kdfIterations || envelope.Iterations > maxKDFIterations {
+		return nil, fmt.Errorf("unsupported key derivation iterations %d", envelope.Iterations)
+	}
+	aead, err := newProfileCipher(passphrase, envelope.Salt, envelope.Iterations)
+	if err != nil {
+		return nil, err
+	}
+	if len(envelope.Nonce) != aead.NonceSize() {
+		return nil, ErrWrongPassphrase
+	}
+	plaintext, err := aead.Open(nil, envelope.Nonce, envelope.Ciphertext, []byte(EncryptedFormat))
+	if err != nil {
+		return nil, ErrWrongPassphrase
+	}
+	return plaintext, nil
+}
+
+// newProfileCipher derives the AES-256-GCM cipher for a passphrase
+func newProfileCipher(passphrase string, salt []byte, iterations int) (cipher.AEAD, error) {
+	key, err := pbkdf2.Key(sha256.New, passphrase, salt, iterations, keyLength)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive key: %w", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// decryptWithProvider asks the passphrase provider for the passphrase of an
+// encrypted profile and opens it
+func decryptWithProvider(source string, data []byte) ([]byte, string, error) {
+	if passphraseProvider == nil {
+		return nil, "", fmt.Errorf("%s: %w", source, ErrEncrypted)
+	}
+	passphrase, err := passphraseProvider(source)
+	if err != nil {
+		return nil, "", err
+	}
+	plaintext, err := DecryptProfileData(data, passphrase)
+	if err != nil {
+		return nil, "", err
+	}
+	return plaintext, passphrase, nil
+}
@@ -0,0 +1,129 @@
+package profile
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEncryptProfileData_RoundTrip(t *testing.T) {
+	plaintext := []byte("name: secret\nvpn:\n  password: private-key\n")
+
+	data, err := EncryptProfileData(plaintext, "correct horse")
+	if err != nil {
+		t.Fatalf("Failed to encrypt: %v", err)
+	}
+	if !IsEncrypted(data) {
+		t.Error("Expected the output to be detected as encrypted")
+	}
+	if strings.Contains(string(data), "private-key") {
+		t.Error("Expected the plaintext to be hidden")
+	}
+
+	decrypted, err := DecryptProfileData(data, "correct horse")
+	if err != nil {
+		t.Fatalf("Failed to decrypt: %v", err)
+	}
+	if string(decrypted) != string(plaintext) {
+		t.Errorf("Decrypted %q, want %q", decrypted, plaintext)
+	}
+
+	if _, err := DecryptProfileData(data, "wrong"); !errors.Is(err, ErrWrongPassphrase) {
+		t.Errorf("Expected ErrWrongPassphrase, got %v", err)
+	}
+	if _, err := EncryptProfileData(plaintext, ""); err == nil {
+		t.Error("Expected an error for an empty passphrase")
+	}
+	if IsEncrypted(plaintext) {
+		t.Error("Did not expect plain YAML to be detected as encrypted")
+	}
+}
+
+func TestDecryptProfileData_RejectsIterationCount(t *testing.T) {
+	data, err := EncryptProfileData([]byte("name: secret\n"), "correct horse")
+	if err != nil {
+		t.Fatalf("Failed to encrypt: %v", err)
+	}
+
+	for _, iterations := range []string{"1", "2147483647"} {
+		crafted := strings.Replace(string(data), `"iterations": 600000`, `"iterations": `+iterations, 1)
+		if crafted == string(data) {
+			t.Fatalf("Expected the iteration count in the envelope:\n%s", data)
+		}
+		if _, err := DecryptProfileData([]byte(crafted), "correct horse"); err == nil ||
+			errors.Is(err, ErrWrongPassphrase) {
+			t.Errorf("Expected %s iterations to be rejected before deriving the key, got %v", iterations, err)
+		}
+	}
+}
+
+func TestEncryptedProfile_ExportImportAndLoad(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+	t.Cleanup(func() { SetPassphraseProvider(nil) })
+
+	p := NewProfile("encrypted")
+	p.Services = []string{"radarr"}
+	p.VPN.WireguardPrivateKey = "private-key"
+	if err := SaveProfile(p); err != nil {
+		t.Fatalf("Failed to save profile: %v", err)
+	}
+
+	exportPath := filepath.Join(tmpDir, "encrypted.json")
+	options := ExportOptions{IncludeSecrets: true, Passphrase: "correct horse"}
+	if err := ExportProfile("encrypted", exportPath, options); err != nil {
+		t.Fatalf("Failed to export profile: %v", err)
+	}
+	if info, err := os.Stat(exportPath); err != nil || info.Mode().Perm() != 0600 {
+		t.Fatalf("Expected an export with 0600 permissions, got %v %v", info, err)
+	}
+
+	SetPassphraseProvider(nil)
+	if _, err := ImportProfile(exportPath); !errors.Is(err, ErrEncrypted) {
+		t.Fatalf("Expected ErrEncrypted without a provider, got %v", err)
+	}
+
+	SetPassphraseProvider(func(string) (string, error) { return "correct horse", nil })
+	imported, err := ImportProfile(exportPath)
+	if err != nil {
+		t.Fatalf("Failed to import profile: %v", err)
+	}
+	if imported.VPN.WireguardPrivateKey != "private-key" || !imported.Encrypted() {
+		t.Fatalf("Expected the decrypted profile to stay encrypted, got %+v", imported)
+	}
+	imported.Name = "restored"
+	if err := SaveProfile(imported); err != nil {
+		t.Fatalf("Failed to save profile: %v", err)
+	}
+
+	profileDir, _ := GetProfileDir()
+	data, err := os.ReadFile(filepath.Join(profileDir, "restored.yaml"))
+	if err != nil || !IsEncrypted(data) {
+		t.Fatalf("Expected the saved profile to be encrypted: %v", err)
+	}
+
+	profiles, err := ListProfiles()
+	if err != nil {
+		t.Fatalf("Failed to list profiles: %v", err)
+	}
+	for _, metadata := range profiles {
+		if metadata.Name == "restored" && !metadata.Encrypted {
+			t.Error("Expected the listing to flag the encrypted profile")
+		}
+	}
+
+	loaded, err := LoadProfile("restored")
+	if err != nil {
+		t.Fatalf("Failed to load profile: %v", err)
+	}
+	if loaded.VPN.WireguardPrivateKey != "private-key" {
+		t.Errorf("Expected the loaded profile to be decrypted, got %+v", loaded)
+	}
+
+	SetPassphraseProvider(func(string) (string, error) { return "wrong", nil })
+	if _, err := LoadProfile("restored"); !errors.Is(err, ErrWrongPassphrase) {
+		t.Errorf("Expected ErrWrongPassphrase, got %v", err)
+	}
+}
@@ -0,0 +1,176 @@
+package profile
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// legacyProfileVersion is assumed for profiles written without a version
+const legacyProfileVersion = "1.0.0"
+
+// ErrNewerVersion is returned for profiles written by a newer Corsarr
+var ErrNewerVersion = errors.New("profile was written by a newer version of corsarr")
+
+// Migration upgrades a profile document from one format version to the next.
+// Steps work on the decoded YAML document, so they can rename and move keys
+// the current Profile struct no longer has.
+type Migration struct {
+	From        string
+	To          string
+	Description string
+	Apply       func(document map[string]interface{}) error
+}
+
+// migrations are applied in order until the document reaches ProfileVersion
+var migrations = []Migration{
+	{
+		From:        "1.0.0",
+		To:          "1.1.0",
+		Description: "split vpn.password into wireguard_private_key and openvpn_password",
+		Apply:       migrateVPNPassword,
+	},
+}
+
+// MigrateDocument upgrades a decoded profile to ProfileVersion. It reports
+// the version the document had and whether any step ran.
+func MigrateDocument(document map[string]interface{}) (string, bool, error) {
+	version, _ := document["version"].(string)
+	if version == "" {
+		version = legacyProfileVersion
+	}
+	original := version
+
+	if _, err := parseVersion(version); err != nil {
+		return original, false, err
+	}
+	newer, err := compareVersions(version, ProfileVersion)
+	if err != nil {
+		return original, false, err
+	}
+	if newer > 0 {
+		return original, false, fmt.Errorf("%w: version %s, this build supports up to %s; upgrade corsarr", ErrNewerVersion, version, ProfileVersion)
+	}
+
+	for version != ProfileVersion {
+		step, ok := migrationFrom(version)
+		if !ok {
+			return original, false, fmt.Errorf("no migration from profile version %s to %s", version, ProfileVersion)
+		}
+		if err := step.Apply(document); err != nil {
+			return original, false, fmt.Errorf("failed to migrate profile from %s to %s: %w", step.From, step.To, err)
+		}
+		version = step.To
+	}
+	document["version"] = version
+
+	return original, original != version, nil
+}
+
+// migrationFrom returns the step that upgrades the given version
+func migrationFrom(version string) (Migration, bool) {
+	for _, step := range migrations {
+		if step.From == version {
+			return step, true
+		}
+	}
+	return Migration{}, false
+}
+
+// decodeProfile migrates a YAML or JSON profile document and decodes it. It
+// reports the version found in the document and, when the document was
+// upgraded, the upgraded YAML holding only the keys the document had.
+func decodeProfile(data []byte) (*Profile, string, []byte, error) {
+	var document map[string]interface{}
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, "", nil, fmt.Errorf("failed to unmarshal profile: %w", err)
+	}
+	if document == nil {
+		document = make(map[string]interface{})
+	}
+
+	original, migrated, err := MigrateDocument(document)
+	if err != nil {
+		return nil, original, nil, err
+	}
+
+	keys := make(map[string]bool, len(document))
+	for key := range document {
+		keys[key] = true
+	}
+	upgraded, err := yaml.Marshal(document)
+	if err != nil {
+		return nil, original, nil, fmt.Errorf("failed to marshal profile: %w", err)
+	}
+	var profile Profile
+	if err := yaml.Unmarshal(upgraded, &profile); err != nil {
+		return nil, original, nil, fmt.Errorf("failed to unmarshal profile: %w", err)
+	}
+	profile.fields = keys
+
+	if !migrated {
+		return &profile, original, nil, nil
+	}
+	return &profile, original, upgraded, nil
+}
+
+// migrateVPNPassword moves vpn.password, which held the WireGuard private key
+// or the OpenVPN password depending on vpn.type, to a dedicated key
+func migrateVPNPassword(document map[string]interface{}) error {
+	vpn, ok := document["vpn"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	password, ok := vpn["password"]
+	if !ok {
+		return nil
+	}
+	delete(vpn, "password")
+	if vpnType, _ := vpn["type"].(string); vpnType == "openvpn" {
+		vpn["openvpn_password"] = password
+	} else {
+		vpn["wireguard_private_key"] = password
+	}
+	return nil
+}
+
+// compareVersions compares two x.y.z versions, returning -1, 0 or 1
+func compareVersions(a, b string) (int, error) {
+	left, err := parseVersion(a)
+	if err != nil {
+		return 0, err
+	}
+	right, err := parseVersion(b)
+	if err != nil {
+		return 0, err
+	}
+	for i := range left {
+		switch {
+		case left[i] < right[i]:
+			return -1, nil
+		case left[i] > right[i]:
+			return 1, nil
+		}
+	}
+	return 0, nil
+}
+
+// parseVersion splits a x.y.z version into its numbers
+func parseVersion(version string) ([3]int, error) {
+	var numbers [3]int
+	parts := strings.Split(version, ".")
+	if len(parts) != 3 {
+		return numbers, fmt.Errorf("invalid profile version %q", version)
+	}
+	for i, part := range parts {
+		number, err := strconv.Atoi(part)
+		if err != nil || number < 0 {
+			return numbers, fmt.Errorf("invalid profile version %q", version)
+		}
+		numbers[i] = number
+	}
+	return numbers, nil
+}
@@ -0,0 +1,156 @@
+package profile
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMigrateDocument(t *testing.T) {
+	tests := []struct {
+		name     string
+		document map[string]interface{}
+		migrated bool
+		wantVPN  map[string]interface{}
+	}{
+		{
+			name:     "wireguard password",
+			document: map[string]interface{}{"version": "1.0.0", "vpn": map[string]interface{}{"password": "key"}},
+			migrated: true,
+			wantVPN:  map[string]interface{}{"wireguard_private_key": "key"},
+		},
+		{
+			name:     "openvpn password",
+			document: map[string]interface{}{"version": "1.0.0", "vpn": map[string]interface{}{"type": "openvpn", "password": "secret"}},
+			migrated: true,
+			wantVPN:  map[string]interface{}{"type": "openvpn", "openvpn_password": "secret"},
+		},
+		{
+			name:     "unversioned profile",
+			document: map[string]interface{}{"vpn": map[string]interface{}{"enabled": true}},
+			migrated: true,
+			wantVPN:  map[string]interface{}{"enabled": true},
+		},
+		{
+			name:     "current version",
+			document: map[string]interface{}{"version": ProfileVersion, "vpn": map[string]interface{}{"wireguard_private_key": "key"}},
+			migrated: false,
+			wantVPN:  map[string]interface{}{"wireguard_private_key": "key"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, migrated, err := MigrateDocument(tt.document)
+			if err != nil {
+				t.Fatalf("Failed to migrate: %v", err)
+			}
+			if migrated != tt.migrated {
+				t.Errorf("migrated = %v, want %v", migrated, tt.migrated)
+			}
+			if tt.document["version"] != ProfileVersion {
+				t.Errorf("version = %v, want %s", tt.document["version"], ProfileVersion)
+			}
+			vpn := tt.document["vpn"].(map[string]interface{})
+			if len(vpn) != len(tt.wantVPN) {
+				t.Errorf("vpn = %v, want %v", vpn, tt.wantVPN)
+			}
+			for key, value := range tt.wantVPN {
+				if vpn[key] != value {
+					t.Errorf("vpn[%s] = %v, want %v", key, vpn[key], value)
+				}
+			}
+		})
+	}
+}
+
+func TestMigrateDocument_Errors(t *testing.T) {
+	if _, _, err := MigrateDocument(map[string]interface{}{"version": "9.0.0"}); !errors.Is(err, ErrNewerVersion) {
+		t.Errorf("Expected ErrNewerVersion, got %v", err)
+	}
+	if _, _, err := MigrateDocument(map[string]interface{}{"version": "latest"}); err == nil {
+		t.Error("Expected an error for an invalid version")
+	}
+	if _, _, err := MigrateDocument(map[string]interface{}{"version": "0.9.0"}); err == nil {
+		t.Error("Expected an error for a version without migration path")
+	}
+}
+
+func TestLoadProfile_MigratesInPlace(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	original := "name: old\nversion: 1.0.0\nvpn:\n  enabled: true\n  password: private-key\nservices: [radarr]\n"
+	writeProfileFile(t, "old", original)
+
+	loaded, err := LoadProfile("old")
+	if err != nil {
+		t.Fatalf("Failed to load profile: %v", err)
+	}
+	if loaded.Version != ProfileVersion || loaded.VPN.WireguardPrivateKey != "private-key" {
+		t.Errorf("Expected a migrated profile, got %+v", loaded)
+	}
+
+	profileDir, _ := GetProfileDir()
+	backup, err := os.ReadFile(filepath.Join(profileDir, "old.yaml.1.0.0.backup"))
+	if err != nil || string(backup) != original {
+		t.Errorf("Expected the original file as backup, got %q (%v)", backup, err)
+	}
+	upgraded, err := os.ReadFile(filepath.Join(profileDir, "old.yaml"))
+	if err != nil {
+		t.Fatalf("Failed to read profile: %v", err)
+	}
+	if !strings.Contains(string(upgraded), "version: "+ProfileVersion) || strings.Contains(string(upgraded), "password:") {
+		t.Errorf("Expected the profile to be upgraded in place:\n%s", upgraded)
+	}
+
+	profiles, err := ListProfiles()
+	if err != nil || len(profiles) != 1 {
+		t.Errorf("Expected the backup to be ignored by ListProfiles, got %d profiles (%v)", len(profiles), err)
+	}
+}
+
+func TestLoadProfile_RefusesNewerVersion(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	writeProfileFile(t, "future", "name: future\nversion: 99.0.0\n")
+
+	if _, err := LoadProfile("future"); !errors.Is(err, ErrNewerVersion) {
+		t.Errorf("Expected ErrNewerVersion, got %v", err)
+	}
+}
+
+func TestLoadProfile_MigratedChildResolvesTheSame(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	writeProfileFile(t, "home", "name: home\nversion: "+ProfileVersion+"\nvpn:\n  enabled: true\n  provider: mullvad\nenvironment:\n  TZ: Europe/Lisbon\noutput_dir: /srv/media\nservices: [sonarr]\n")
+	writeProfileFile(t, "child", "name: child\nversion: 1.0.0\nextends: home\nservices: [radarr]\n")
+
+	before, err := ResolveProfile("child")
+	if err != nil {
+		t.Fatalf("Failed to resolve profile: %v", err)
+	}
+	after, err := ResolveProfile("child")
+	if err != nil {
+		t.Fatalf("Failed to resolve migrated profile: %v", err)
+	}
+	if !reflect.DeepEqual(before, after) {
+		t.Errorf("Expected the migrated profile to resolve the same:\nbefore: %+v\nafter:  %+v", before, after)
+	}
+	if !after.VPN.Enabled || after.OutputDir != "/srv/media" || after.Environment["TZ"] != "Europe/Lisbon" {
+		t.Errorf("Expected the settings of home to be inherited, got %+v", after)
+	}
+
+	profileDir, _ := GetProfileDir()
+	upgraded, err := os.ReadFile(filepath.Join(profileDir, "child.yaml"))
+	if err != nil {
+		t.Fatalf("Failed to read profile: %v", err)
+	}
+	for _, key := range []string{"vpn:", "environment:", "output_dir:", "created_at:"} {
+		if strings.Contains(string(upgraded), key) {
+			t.Errorf("Expected the migrated file to keep only its own keys, found %s in:\n%s", key, upgraded)
+		}
+	}
+}
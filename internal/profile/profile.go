@@ -7,6 +7,7 @@ import (
 	"path/filepath"
 	"time"
 
+	"github.com/woliveiras/corsarr/internal/services"
 	"gopkg.in/yaml.v3"
 )
 
@@ -17,20 +18,93 @@ type Profile struct {
 	CreatedAt   time.Time         `json:"created_at" yaml:"created_at"`
 	UpdatedAt   time.Time         `json:"updated_at" yaml:"updated_at"`
 	Version     string            `json:"version" yaml:"version"`
-	VPN         VPNConfig         `json:"vpn" yaml:"vpn"`
+	VPN         VPNConfig         `json:"vpn,omitzero" yaml:"vpn,omitempty"`
 	Services    []string          `json:"services" yaml:"services"`
 	Environment map[string]string `json:"environment" yaml:"environment"`
 	OutputDir   string            `json:"output_dir" yaml:"output_dir"`
+	// Ports remaps published host ports, keyed by service ID or
+	// "<service>:<container port>"
+	Ports map[string]string `json:"ports,omitempty" yaml:"ports,omitempty"`
+	// HWAccel is the hardware transcoding backend: vaapi, qsv, nvidia or none
+	HWAccel string `json:"hwaccel,omitempty" yaml:"hwaccel,omitempty"`
+	// VPNRoutes chooses in VPN mode whether services share Gluetun's network
+	// or join the bridge network, keyed by service ID, category or "*"
+	VPNRoutes map[string]string `json:"vpn_routes,omitempty" yaml:"vpn_routes,omitempty"`
+	// Resources sets mem_limit, cpus and logging defaults for every service,
+	// with per-service overrides
+	Resources *services.ResourceSettings `json:"resources,omitempty" yaml:"resources,omitempty"`
+	// Secrets keeps the VPN credentials in files under secrets/ next to the
+	// generated files; they are never stored in the profile
+	Secrets bool `json:"secrets,omitempty" yaml:"secrets,omitempty"`
+	// Extends names the profiles this one builds on; see Resolve
+	Extends Extends `json:"extends,omitempty" yaml:"extends,omitempty"`
+
+	// passphrase re-encrypts the profile on save when it was loaded from an
+	// encrypted file
+	passphrase string
+	// fields records the top-level keys present in the loaded file
+	fields map[string]bool
 }
 
 // VPNConfig holds VPN-related configuration
 type VPNConfig struct {
-	Enabled  bool   `json:"enabled" yaml:"enabled"`
-	Provider string `json:"provider,omitempty" yaml:"provider,omitempty"`
-	Username string `json:"username,omitempty" yaml:"username,omitempty"`
-	Password string `json:"password,omitempty" yaml:"password,omitempty"`
-	Country  string `json:"country,omitempty" yaml:"country,omitempty"`
-	City     string `json:"city,omitempty" yaml:"city,omitempty"`
+	Enabled               bool   `json:"enabled" yaml:"enabled"`
+	Provider              string `json:"provider,omitempty" yaml:"provider,omitempty"`
+	Type                  string `json:"type,omitempty" yaml:"type,omitempty"`
+	Username              string `json:"username,omitempty" yaml:"username,omitempty"`
+	WireguardPrivateKey   string `json:"wireguard_private_key,omitempty" yaml:"wireguard_private_key,omitempty"`
+	WireguardPresharedKey string `json:"wireguard_preshared_key,omitempty" yaml:"wireguard_preshared_key,omitempty"`
+	WireguardPublicKey    string `json:"wireguard_public_key,omitempty" yaml:"wireguard_public_key,omitempty"`
+	WireguardAddresses    string `json:"wireguard_addresses,omitempty" yaml:"wireguard_addresses,omitempty"`
+	EndpointIP            string `json:"endpoint_ip,omitempty" yaml:"endpoint_ip,omitempty"`
+	EndpointPort          string `json:"endpoint_port,omitempty" yaml:"endpoint_port,omitempty"`
+	OpenVPNPassword       string `json:"openvpn_password,omitempty" yaml:"openvpn_password,omitempty"`
+	OpenVPNCustomConfig   string `json:"openvpn_custom_config,omitempty" yaml:"openvpn_custom_config,omitempty"`
+	Country               string `json:"country,omitempty" yaml:"country,omitempty"`
+	City                  string `json:"city,omitempty" yaml:"city,omitempty"`
+	Hostnames             string `json:"hostnames,omitempty" yaml:"hostnames,omitempty"`
+	Regions               string `json:"regions,omitempty" yaml:"regions,omitempty"`
+	PortForwarding        bool   `json:"port_forwarding,omitempty" yaml:"port_forwarding,omitempty"`
+	// OutboundSubnets are the LAN subnets reachable outside the tunnel:
+	// comma-separated CIDRs, auto to detect them (the default) or none
+	OutboundSubnets string `json:"outbound_subnets,omitempty" yaml:"outbound_subnets,omitempty"`
+}
+
+// HasSecrets reports whether the profile stores VPN credentials
+func (p *Profile) HasSecrets() bool {
+	return p.VPN.WireguardPrivateKey != "" || p.VPN.WireguardPresharedKey != "" || p.VPN.OpenVPNPassword != ""
+}
+
+// MaskSecrets returns a copy of the profile with VPN credentials replaced by
+// mask, for display
+func (p *Profile) MaskSecrets(mask string) *Profile {
+	masked := *p
+	if p.VPN.WireguardPrivateKey != "" {
+		masked.VPN.WireguardPrivateKey = mask
+	}
+	if p.VPN.WireguardPresharedKey != "" {
+		masked.VPN.WireguardPresharedKey = mask
+	}
+	if p.VPN.OpenVPNPassword != "" {
+		masked.VPN.OpenVPNPassword = mask
+	}
+	return &masked
+}
+
+// Encrypted reports whether the profile is stored encrypted
+func (p *Profile) Encrypted() bool {
+	return p.passphrase != ""
+}
+
+// KeepEncryption makes the profile re-encrypt on save with the passphrase of
+// existing, so overwriting an encrypted profile never writes it in plain text
+func (p *Profile) KeepEncryption(existing *Profile) {
+	p.passphrase = existing.passphrase
+}
+
+// WithoutSecrets returns a copy of the profile without VPN credentials
+func (p *Profile) WithoutSecrets() *Profile {
+	return p.MaskSecrets("")
 }
 
 // Metadata contains profile summary information
@@ -41,11 +115,22 @@ type Metadata struct {
 	UpdatedAt   time.Time `json:"updated_at" yaml:"updated_at"`
 	Version     string    `json:"version" yaml:"version"`
 	Services    []string  `json:"services" yaml:"services"`
+	// Encrypted profiles only expose their name until they are loaded
+	Encrypted bool `json:"encrypted,omitempty" yaml:"encrypted,omitempty"`
+}
+
+// ExportOptions controls what ExportProfile writes
+type ExportOptions struct {
+	// IncludeSecrets keeps the VPN password in the exported file
+	IncludeSecrets bool
+	// Passphrase encrypts the exported file when set
+	Passphrase string
 }
 
 const (
-	// ProfileVersion is the current profile format version
-	ProfileVersion = "1.0.0"
+	// ProfileVersion is the current profile format version. Older profiles
+	// are upgraded by the steps in migrations.
+	ProfileVersion = "1.1.0"
 	// DefaultProfileDir is the default directory for storing profiles
 	DefaultProfileDir = ".corsarr/profiles"
 )
@@ -112,14 +197,30 @@ func SaveProfile(profile *Profile) error {
 	if err != nil {
 		return fmt.Errorf("failed to marshal profile: %w", err)
 	}
+	if profile.passphrase != "" {
+		data, err = EncryptProfileData(data, profile.passphrase)
+		if err != nil {
+			return err
+		}
+	}
 
-	if err := os.WriteFile(profilePath, data, 0644); err != nil {
+	// Profiles can hold VPN credentials (0600 - owner read/write only)
+	if err := writePrivateFile(profilePath, data); err != nil {
 		return fmt.Errorf("failed to write profile file: %w", err)
 	}
 
 	return nil
 }
 
+// writePrivateFile writes data with 0600 permissions, also tightening files
+// created by older versions with 0644
+func writePrivateFile(path string, data []byte) error {
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return err
+	}
+	return os.Chmod(path, 0600)
+}
+
 // LoadProfile loads a profile from disk
 func LoadProfile(name string) (*Profile, error) {
 	profilePath, err := getProfilePath(name)
@@ -135,12 +236,53 @@ func LoadProfile(name string) (*Profile, error) {
 		return nil, fmt.Errorf("failed to read profile file: %w", err)
 	}
 
-	var profile Profile
-	if err := yaml.Unmarshal(data, &profile); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal profile: %w", err)
+	var passphrase string
+	if IsEncrypted(data) {
+		data, passphrase, err = decryptWithProvider(name, data)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	profile, version, upgraded, err := decodeProfile(data)
+	if err != nil {
+		return nil, fmt.Errorf("profile '%s': %w", name, err)
+	}
+	profile.passphrase = passphrase
+
+	// Upgrade the file in place, keeping the original next to it. The
+	// migrated document is written rather than the profile, so keys the file
+	// did not set stay absent and keep being inherited from its bases.
+	if upgraded != nil {
+		if err := backupProfile(profilePath, version); err != nil {
+			return nil, err
+		}
+		if passphrase != "" {
+			upgraded, err = EncryptProfileData(upgraded, passphrase)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if err := writePrivateFile(profilePath, upgraded); err != nil {
+			return nil, fmt.Errorf("failed to save migrated profile: %w", err)
+		}
 	}
 
-	return &profile, nil
+	return profile, nil
+}
+
+// backupProfile copies a profile file before it is migrated, as
+// <name>.yaml.<version>.backup, which ListProfiles ignores
+func backupProfile(profilePath, version string) error {
+	data, err := os.ReadFile(profilePath)
+	if err != nil {
+		return fmt.Errorf("failed to read profile file: %w", err)
+	}
+	backupPath := fmt.Sprintf("%s.%s.backup", profilePath, version)
+	if err := writePrivateFile(backupPath, data); err != nil {
+		return fmt.Errorf("failed to write profile backup: %w", err)
+	}
+	return nil
 }
 
 // ListProfiles returns a list of all saved profiles
@@ -167,8 +309,17 @@ func ListProfiles() ([]*Metadata, error) {
 		}
 
 		name := entry.Name()[:len(entry.Name())-5] // Remove .yaml extension
-		profile, err := LoadProfile(name)
+		data, err := os.ReadFile(filepath.Join(profileDir, entry.Name()))
 		if err != nil {
+			continue
+		}
+		// Listing never prompts for passphrases
+		if IsEncrypted(data) {
+			profiles = append(profiles, &Metadata{Name: name, Encrypted: true})
+			continue
+		}
+		var profile Profile
+		if err := yaml.Unmarshal(data, &profile); err != nil {
 			continue // Skip invalid profiles
 		}
 
@@ -202,49 +353,71 @@ func DeleteProfile(name string) error {
 	return nil
 }
 
-// ExportProfile exports a profile to a specific path in JSON format
-func ExportProfile(name, outputPath string) error {
+// ExportProfile exports a profile to a specific path in JSON format. VPN
+// credentials are left out unless options.IncludeSecrets is set, and the
+// file is encrypted when options.Passphrase is set.
+func ExportProfile(name, outputPath string, options ExportOptions) error {
 	profile, err := LoadProfile(name)
 	if err != nil {
 		return err
 	}
+	return profile.Export(outputPath, options)
+}
+
+// Export writes an already loaded profile the way ExportProfile does, so
+// callers that need the profile too only decrypt it once.
+func (p *Profile) Export(outputPath string, options ExportOptions) error {
+	profile := p
+	if !options.IncludeSecrets {
+		profile = profile.WithoutSecrets()
+	}
 
 	data, err := json.MarshalIndent(profile, "", "  ")
 	if err != nil {
 		return fmt.Errorf("failed to marshal profile: %w", err)
 	}
+	if options.Passphrase != "" {
+		data, err = EncryptProfileData(data, options.Passphrase)
+		if err != nil {
+			return err
+		}
+	}
 
-	if err := os.WriteFile(outputPath, data, 0644); err != nil {
+	if err := writePrivateFile(outputPath, data); err != nil {
 		return fmt.Errorf("failed to write export file: %w", err)
 	}
 
 	return nil
 }
 
-// ImportProfile imports a profile from a JSON or YAML file
+// ImportProfile imports a profile from a JSON or YAML file. Encrypted files
+// are decrypted with the passphrase provider and stay encrypted when saved.
 func ImportProfile(inputPath string) (*Profile, error) {
 	data, err := os.ReadFile(inputPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read import file: %w", err)
 	}
 
-	var profile Profile
-
-	// Try YAML first
-	if err := yaml.Unmarshal(data, &profile); err != nil {
-		// Try JSON
-		if jsonErr := json.Unmarshal(data, &profile); jsonErr != nil {
-			return nil, fmt.Errorf("failed to parse profile (tried YAML and JSON): %w", err)
+	var passphrase string
+	if IsEncrypted(data) {
+		data, passphrase, err = decryptWithProvider(inputPath, data)
+		if err != nil {
+			return nil, err
 		}
 	}
 
+	// YAML also reads JSON exports; older versions are migrated in memory
+	profile, _, _, err := decodeProfile(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse profile: %w", err)
+	}
+
+	profile.passphrase = passphrase
+
 	// Update metadata
 	profile.UpdatedAt = time.Now()
-	if profile.Version == "" {
-		profile.Version = ProfileVersion
-	}
 
-	return &profile, nil
+	return profile, nil
 }
 
 // ProfileExists checks if a profile exists
@@ -257,6 +430,17 @@ func ProfileExists(name string) bool {
 	return err == nil
 }
 
+// IsProfileEncrypted reports whether a stored profile is encrypted, without
+// asking for its passphrase
+func IsProfileEncrypted(name string) bool {
+	profilePath, err := getProfilePath(name)
+	if err != nil {
+		return false
+	}
+	data, err := os.ReadFile(profilePath)
+	return err == nil && IsEncrypted(data)
+}
+
 // GetMetadata returns metadata for a profile without loading the full profile
 func GetMetadata(name string) (*Metadata, error) {
 	profile, err := LoadProfile(name)
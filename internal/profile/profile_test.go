@@ -53,6 +53,12 @@ func TestSaveAndLoadProfile(t *testing.T) {
 		t.Fatalf("Failed to save profile: %v", err)
 	}
 
+	// Profiles can hold credentials and are only readable by the owner
+	profilePath, _ := getProfilePath("test-save-load")
+	if info, err := os.Stat(profilePath); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("Expected profile permissions 0600, got %v %v", info, err)
+	}
+
 	// Load profile
 	loaded, err := LoadProfile("test-save-load")
 	if err != nil {
@@ -198,7 +204,7 @@ func TestExportProfile(t *testing.T) {
 
 	// Export to file
 	exportPath := filepath.Join(tmpDir, "exported.json")
-	if err := ExportProfile("test-export", exportPath); err != nil {
+	if err := ExportProfile("test-export", exportPath, ExportOptions{}); err != nil {
 		t.Fatalf("Failed to export profile: %v", err)
 	}
 
@@ -217,6 +223,51 @@ func TestExportProfile(t *testing.T) {
 	}
 }
 
+func TestExportProfile_Secrets(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+
+	p := NewProfile("test-secrets")
+	p.VPN.Enabled = true
+	p.VPN.WireguardPrivateKey = "private-key"
+	if err := SaveProfile(p); err != nil {
+		t.Fatalf("Failed to save profile: %v", err)
+	}
+
+	tests := []struct {
+		name           string
+		includeSecrets bool
+		want           string
+	}{
+		{name: "omitted by default", includeSecrets: false, want: ""},
+		{name: "included with flag", includeSecrets: true, want: "private-key"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exportPath := filepath.Join(tmpDir, "secrets.json")
+			if err := ExportProfile("test-secrets", exportPath, ExportOptions{IncludeSecrets: tt.includeSecrets}); err != nil {
+				t.Fatalf("Failed to export profile: %v", err)
+			}
+			imported, err := ImportProfile(exportPath)
+			if err != nil {
+				t.Fatalf("Failed to import profile: %v", err)
+			}
+			if imported.VPN.WireguardPrivateKey != tt.want {
+				t.Errorf("VPN.WireguardPrivateKey = %q, want %q", imported.VPN.WireguardPrivateKey, tt.want)
+			}
+		})
+	}
+
+	// The stored profile keeps its password
+	loaded, err := LoadProfile("test-secrets")
+	if err != nil {
+		t.Fatalf("Failed to load profile: %v", err)
+	}
+	if !loaded.HasSecrets() {
+		t.Error("Export should not modify the saved profile")
+	}
+}
+
 func TestImportProfile(t *testing.T) {
 	tmpDir := t.TempDir()
 	t.Setenv("HOME", tmpDir)
@@ -229,7 +280,7 @@ func TestImportProfile(t *testing.T) {
 	_ = SaveProfile(p)
 
 	exportPath := filepath.Join(tmpDir, "import-test.json")
-	_ = ExportProfile("test-import", exportPath)
+	_ = ExportProfile("test-import", exportPath, ExportOptions{})
 
 	// Delete the original profile
 	_ = DeleteProfile("test-import")
@@ -0,0 +1,207 @@
+package profile
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/woliveiras/corsarr/internal/services"
+	"gopkg.in/yaml.v3"
+)
+
+// Extends lists the base profiles of a profile. It accepts a single name or a
+// list in YAML and JSON.
+type Extends []string
+
+// UnmarshalYAML accepts `extends: base` and `extends: [a, b]`
+func (e *Extends) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		*e = Extends{value.Value}
+		return nil
+	}
+	var names []string
+	if err := value.Decode(&names); err != nil {
+		return fmt.Errorf("extends must be a profile name or a list of names: %w", err)
+	}
+	*e = names
+	return nil
+}
+
+// MarshalYAML writes a single base as a plain name
+func (e Extends) MarshalYAML() (interface{}, error) {
+	if len(e) == 1 {
+		return e[0], nil
+	}
+	return []string(e), nil
+}
+
+// UnmarshalJSON accepts "base" and ["a", "b"]
+func (e *Extends) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err == nil {
+		*e = Extends{name}
+		return nil
+	}
+	var names []string
+	if err := json.Unmarshal(data, &names); err != nil {
+		return fmt.Errorf("extends must be a profile name or a list of names: %w", err)
+	}
+	*e = names
+	return nil
+}
+
+// ResolveProfile loads a profile and merges the profiles it extends
+func ResolveProfile(name string) (*Profile, error) {
+	p, err := LoadProfile(name)
+	if err != nil {
+		return nil, err
+	}
+	return resolve(p, []string{name})
+}
+
+// Resolve merges the profiles p extends into a copy of p. Bases are applied
+// in order, each one resolved first, and p is applied last:
+//   - services are appended without duplicates; "-<id>" removes a service
+//     selected by a base
+//   - environment, ports and per-service resources are merged key by key
+//   - vpn and secrets replace the inherited settings when p sets them; a
+//     zero vpn block is not saved, so saved profiles keep inheriting it
+//   - output_dir and hwaccel replace the inherited value when not empty
+func Resolve(p *Profile) (*Profile, error) {
+	return resolve(p, []string{p.Name})
+}
+
+func resolve(p *Profile, chain []string) (*Profile, error) {
+	if len(p.Extends) == 0 {
+		resolved := *p
+		resolved.Services = mergeServices(nil, p.Services)
+		return &resolved, nil
+	}
+
+	var merged *Profile
+	for _, baseName := range p.Extends {
+		for _, seen := range chain {
+			if seen == baseName {
+				return nil, fmt.Errorf("profile inheritance cycle: %s -> %s", strings.Join(chain, " -> "), baseName)
+			}
+		}
+		base, err := LoadProfile(baseName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load base profile %s of %s: %w", baseName, p.Name, err)
+		}
+		resolvedBase, err := resolve(base, append(append([]string(nil), chain...), baseName))
+		if err != nil {
+			return nil, err
+		}
+		if merged == nil {
+			merged = resolvedBase
+		} else {
+			merged = mergeProfile(merged, resolvedBase)
+		}
+	}
+
+	resolved := mergeProfile(merged, p)
+	resolved.Name = p.Name
+	resolved.Description = p.Description
+	resolved.CreatedAt = p.CreatedAt
+	resolved.UpdatedAt = p.UpdatedAt
+	resolved.Version = p.Version
+	resolved.Extends = nil
+	resolved.fields = nil
+	resolved.passphrase = p.passphrase
+	return resolved, nil
+}
+
+// mergeProfile applies override on top of base
+func mergeProfile(base, override *Profile) *Profile {
+	merged := *base
+	merged.Services = mergeServices(base.Services, override.Services)
+	merged.Environment = mergeStrings(base.Environment, override.Environment)
+	merged.Ports = mergeStrings(base.Ports, override.Ports)
+	merged.VPNRoutes = mergeStrings(base.VPNRoutes, override.VPNRoutes)
+	merged.Resources = mergeResources(base.Resources, override.Resources)
+	if override.sets("vpn", override.VPN == VPNConfig{}) {
+		merged.VPN = override.VPN
+	}
+	if override.sets("secrets", !override.Secrets) {
+		merged.Secrets = override.Secrets
+	}
+	if override.OutputDir != "" {
+		merged.OutputDir = override.OutputDir
+	}
+	if override.HWAccel != "" {
+		merged.HWAccel = override.HWAccel
+	}
+	return &merged
+}
+
+// sets reports whether the profile file contains a top-level key. Profiles
+// built in code fall back to whether the value is the zero value.
+func (p *Profile) sets(key string, zero bool) bool {
+	if p.fields != nil {
+		return p.fields[key]
+	}
+	return !zero
+}
+
+// mergeServices appends the services of override to base in order, skipping
+// duplicates and removing the IDs prefixed with "-"
+func mergeServices(base, override []string) []string {
+	result := make([]string, 0, len(base)+len(override))
+	seen := make(map[string]bool)
+	for _, id := range append(append([]string(nil), base...), override...) {
+		id = strings.TrimSpace(id)
+		if removed, ok := strings.CutPrefix(id, "-"); ok {
+			for i, selected := range result {
+				if selected == removed {
+					result = append(result[:i], result[i+1:]...)
+					break
+				}
+			}
+			delete(seen, removed)
+			continue
+		}
+		if id == "" || seen[id] {
+			continue
+		}
+		seen[id] = true
+		result = append(result, id)
+	}
+	return result
+}
+
+// mergeStrings returns base with the keys of override replacing its own
+func mergeStrings(base, override map[string]string) map[string]string {
+	if len(base) == 0 && len(override) == 0 {
+		return base
+	}
+	merged := make(map[string]string, len(base)+len(override))
+	for key, value := range base {
+		merged[key] = value
+	}
+	for key, value := range override {
+		merged[key] = value
+	}
+	return merged
+}
+
+// mergeResources merges the defaults and per-service resource overrides
+func mergeResources(base, override *services.ResourceSettings) *services.ResourceSettings {
+	if base == nil {
+		return override
+	}
+	if override == nil {
+		return base
+	}
+	merged := &services.ResourceSettings{
+		Defaults: base.Defaults.Merge(override.Defaults),
+		Services: make(map[string]services.ResourceConfig, len(base.Services)+len(override.Services)),
+	}
+	for id, config := range base.Services {
+		merged.Services[id] = config
+	}
+	for id, config := range override.Services {
+		merged.Services[id] = merged.Services[id].Merge(config)
+	}
+	return merged
+}
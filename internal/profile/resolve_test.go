@@ -0,0 +1,163 @@
+package profile
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+// writeProfileFile stores a profile document as written by hand
+func writeProfileFile(t *testing.T, name, content string) {
+	t.Helper()
+	profileDir, err := GetProfileDir()
+	if err != nil {
+		t.Fatalf("Failed to get profile dir: %v", err)
+	}
+	if err := os.MkdirAll(profileDir, 0755); err != nil {
+		t.Fatalf("Failed to create profile dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(profileDir, name+".yaml"), []byte(content), 0600); err != nil {
+		t.Fatalf("Failed to write profile: %v", err)
+	}
+}
+
+func TestResolveProfile(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	writeProfileFile(t, "base", `name: base
+services: [prowlarr, radarr, sonarr, jellyfin]
+environment:
+  TZ: Europe/Madrid
+  PUID: "1000"
+  ARRPATH: /srv/media/
+vpn:
+  enabled: true
+  provider: mullvad
+ports:
+  radarr: "17878"
+output_dir: /srv/stack
+`)
+	writeProfileFile(t, "downloads", `name: downloads
+services: [qbittorrent]
+environment:
+  PGID: "1000"
+`)
+	writeProfileFile(t, "relatives", `name: relatives
+description: Lighter stack
+extends: [base, downloads]
+services: [-jellyfin, bazarr]
+environment:
+  TZ: America/Sao_Paulo
+vpn:
+  enabled: false
+`)
+
+	resolved, err := ResolveProfile("relatives")
+	if err != nil {
+		t.Fatalf("Failed to resolve profile: %v", err)
+	}
+
+	if got := strings.Join(resolved.Services, ","); got != "prowlarr,radarr,sonarr,qbittorrent,bazarr" {
+		t.Errorf("Services = %s", got)
+	}
+	wantEnv := map[string]string{"TZ": "America/Sao_Paulo", "PUID": "1000", "PGID": "1000", "ARRPATH": "/srv/media/"}
+	for key, value := range wantEnv {
+		if resolved.Environment[key] != value {
+			t.Errorf("Environment[%s] = %q, want %q", key, resolved.Environment[key], value)
+		}
+	}
+	if resolved.VPN.Enabled || resolved.VPN.Provider != "" {
+		t.Errorf("Expected the VPN block to be replaced, got %+v", resolved.VPN)
+	}
+	if resolved.Ports["radarr"] != "17878" || resolved.OutputDir != "/srv/stack" {
+		t.Errorf("Expected inherited ports and output dir, got %v %q", resolved.Ports, resolved.OutputDir)
+	}
+	if resolved.Name != "relatives" || resolved.Description != "Lighter stack" || len(resolved.Extends) != 0 {
+		t.Errorf("Expected the metadata of the extending profile, got %+v", resolved)
+	}
+
+	// The stored profile is left as written
+	raw, err := LoadProfile("relatives")
+	if err != nil {
+		t.Fatalf("Failed to load profile: %v", err)
+	}
+	if len(raw.Extends) != 2 || len(raw.Services) != 2 {
+		t.Errorf("Expected the raw profile, got %+v", raw)
+	}
+}
+
+func TestResolveProfile_KeepsInheritedVPN(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	writeProfileFile(t, "base", "name: base\nvpn:\n  enabled: true\n  provider: mullvad\nservices: [qbittorrent]\n")
+	writeProfileFile(t, "test", "name: test\nextends: base\nservices: [radarr]\n")
+
+	resolved, err := ResolveProfile("test")
+	if err != nil {
+		t.Fatalf("Failed to resolve profile: %v", err)
+	}
+	if !resolved.VPN.Enabled || resolved.VPN.Provider != "mullvad" {
+		t.Errorf("Expected the base VPN settings, got %+v", resolved.VPN)
+	}
+}
+
+func TestResolveProfile_SavedChildKeepsInheritedVPN(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	writeProfileFile(t, "home", "name: home\nvpn:\n  enabled: true\n  provider: mullvad\nservices: [qbittorrent]\n")
+	child := NewProfile("child")
+	child.Extends = Extends{"home"}
+	if err := SaveProfile(child); err != nil {
+		t.Fatalf("Failed to save profile: %v", err)
+	}
+	if _, err := LoadProfile("child"); err != nil {
+		t.Fatalf("Failed to load profile: %v", err)
+	}
+
+	resolved, err := ResolveProfile("child")
+	if err != nil {
+		t.Fatalf("Failed to resolve profile: %v", err)
+	}
+	if !resolved.VPN.Enabled || resolved.VPN.Provider != "mullvad" {
+		t.Errorf("Expected the saved child to inherit the VPN settings, got %+v", resolved.VPN)
+	}
+}
+
+func TestResolveProfile_Errors(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	writeProfileFile(t, "a", "name: a\nextends: b\n")
+	writeProfileFile(t, "b", "name: b\nextends: a\n")
+	writeProfileFile(t, "orphan", "name: orphan\nextends: missing\n")
+
+	if _, err := ResolveProfile("a"); err == nil || !strings.Contains(err.Error(), "cycle: a -> b -> a") {
+		t.Errorf("Expected an inheritance cycle error, got %v", err)
+	}
+	if _, err := ResolveProfile("orphan"); err == nil || !strings.Contains(err.Error(), "missing") {
+		t.Errorf("Expected a missing base error, got %v", err)
+	}
+}
+
+func TestExtends_Marshal(t *testing.T) {
+	tests := []struct {
+		extends Extends
+		want    string
+	}{
+		{Extends{"base"}, "extends: base\n"},
+		{Extends{"a", "b"}, "extends:\n    - a\n    - b\n"},
+	}
+	for _, tt := range tests {
+		data, err := yaml.Marshal(struct {
+			Extends Extends `yaml:"extends"`
+		}{tt.extends})
+		if err != nil {
+			t.Fatalf("Failed to marshal: %v", err)
+		}
+		if string(data) != tt.want {
+			t.Errorf("Marshal(%v) = %q, want %q", tt.extends, data, tt.want)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package prompts
 import (
 	"errors"
 	"regexp"
+	"strings"
 
 	"github.com/charmbracelet/huh"
 	"github.com/woliveiras/corsarr/internal/generator"
@@ -14,25 +15,53 @@ var (
 	errInvalidProjectName     = errors.New("invalid Docker Compose project name")
 )
 
-// ConfigureVPN prompts for VPN configuration if VPN is enabled
+// vpnVariablePrompts are the prompt titles of the Gluetun variables
+var vpnVariablePrompts = map[string]string{
+	"WIREGUARD_PRIVATE_KEY":   "prompts.vpn_wireguard_private_key",
+	"WIREGUARD_PUBLIC_KEY":    "prompts.vpn_wireguard_public_key",
+	"WIREGUARD_PRESHARED_KEY": "prompts.vpn_wireguard_preshared_key",
+	"WIREGUARD_ADDRESSES":     "prompts.vpn_wireguard_addresses",
+	"WIREGUARD_ENDPOINT_IP":   "prompts.vpn_wireguard_endpoint_ip",
+	"WIREGUARD_ENDPOINT_PORT": "prompts.vpn_wireguard_endpoint_port",
+	"OPENVPN_USER":            "prompts.vpn_openvpn_user",
+	"OPENVPN_PASSWORD":        "prompts.vpn_openvpn_password",
+	"OPENVPN_CUSTOM_CONFIG":   "prompts.vpn_openvpn_custom_config",
+	"SERVER_COUNTRIES":        "prompts.vpn_server_countries",
+	"SERVER_CITIES":           "prompts.vpn_server_cities",
+	"SERVER_HOSTNAMES":        "prompts.vpn_server_hostnames",
+	"SERVER_REGIONS":          "prompts.vpn_server_regions",
+}
+
+// vpnVariablePlaceholders are example values shown in empty inputs
+var vpnVariablePlaceholders = map[string]string{
+	"WIREGUARD_ADDRESSES":     "10.64.222.21/32",
+	"WIREGUARD_ENDPOINT_PORT": "51820",
+	"OPENVPN_CUSTOM_CONFIG":   "/gluetun/custom.conf",
+}
+
+// ConfigureVPN prompts for VPN configuration if VPN is enabled. The provider
+// catalog decides which tunnel types are offered and which Gluetun variables
+// are asked for.
 func ConfigureVPN(t *i18n.I18n) (*generator.VPNConfig, error) {
 	config := &generator.VPNConfig{
-		ServiceProvider: "custom",
-		Type:            "wireguard",
-		PortForwarding:  "off",
-		DNSAddress:      "1.1.1.1",
+		Type:           generator.VPNTypeWireGuard,
+		PortForwarding: "off",
+		DNSAddress:     "1.1.1.1",
 	}
 
-	// VPN type selection
+	// Provider selection
+	providerOptions := []huh.Option[string]{}
+	for _, provider := range generator.VPNProviders() {
+		providerOptions = append(providerOptions, huh.NewOption(provider.Name, provider.ID))
+	}
+	providerOptions = append(providerOptions, huh.NewOption(t.T("prompts.vpn_provider_other"), ""))
+	var providerID string
 	form1 := huh.NewForm(
 		huh.NewGroup(
 			huh.NewSelect[string]().
-				Title(t.T("prompts.vpn_type")).
-				Options(
-					huh.NewOption("WireGuard", "wireguard"),
-					huh.NewOption("OpenVPN", "openvpn"),
-				).
-				Value(&config.Type),
+				Title(t.T("prompts.vpn_provider")).
+				Options(providerOptions...).
+				Value(&providerID),
 		),
 	)
 
@@ -40,62 +69,90 @@ func ConfigureVPN(t *i18n.I18n) (*generator.VPNConfig, error) {
 		return nil, err
 	}
 
-	// Provider and WireGuard config
-	if config.Type == "wireguard" {
-		form2 := huh.NewForm(
+	config.ServiceProvider = providerID
+	if providerID == "" {
+		form := huh.NewForm(
 			huh.NewGroup(
 				huh.NewInput().
-				Title(t.T("prompts.vpn_provider")).
-				Value(&config.ServiceProvider).
-				Placeholder("custom"),
-			huh.NewInput().
-				Title(t.T("prompts.vpn_wireguard_private_key")).
-				Value(&config.WireguardPrivateKey).
-				EchoMode(huh.EchoModePassword),
-				huh.NewInput().
-					Title(t.T("prompts.vpn_wireguard_addresses")).
-					Value(&config.WireguardAddresses).
-					Placeholder("10.0.0.2/32"),
-				huh.NewInput().
-					Title(t.T("prompts.vpn_wireguard_public_key")).
-					Value(&config.WireguardPublicKey).
-					EchoMode(huh.EchoModePassword).
-					Placeholder("server public key"),
+					Title(t.T("prompts.vpn_provider_name")).
+					Description(t.T("prompts.vpn_provider_help")).
+					Value(&config.ServiceProvider).
+					Validate(requiredValue(t)),
 			),
 		)
-
-		if err := form2.Run(); err != nil {
+		if err := form.Run(); err != nil {
 			return nil, err
 		}
-	} else {
-		// OpenVPN provider only
-		form2 := huh.NewForm(
+	}
+	provider, _ := generator.LookupVPNProvider(config.ServiceProvider)
+
+	// VPN type selection, only when the provider offers both
+	types := provider.Types()
+	config.Type = types[0]
+	if len(types) > 1 {
+		typeNames := map[string]string{generator.VPNTypeWireGuard: "WireGuard", generator.VPNTypeOpenVPN: "OpenVPN"}
+		typeOptions := []huh.Option[string]{}
+		for _, vpnType := range types {
+			typeOptions = append(typeOptions, huh.NewOption(typeNames[vpnType], vpnType))
+		}
+		form := huh.NewForm(
 			huh.NewGroup(
-				huh.NewInput().
-					Title(t.T("prompts.vpn_provider")).
-					Value(&config.ServiceProvider).
-					Placeholder("custom"),
+				huh.NewSelect[string]().
+					Title(t.T("prompts.vpn_type")).
+					Options(typeOptions...).
+					Value(&config.Type),
 			),
 		)
-
-		if err := form2.Run(); err != nil {
+		if err := form.Run(); err != nil {
 			return nil, err
 		}
 	}
 
+	// Credentials, addresses and server filters of the provider
+	values := make(map[string]*string)
+	fields := []huh.Field{}
+	addInput := func(name string, required bool) {
+		value := new(string)
+		values[name] = value
+		input := huh.NewInput().
+			Title(t.T(vpnVariablePrompts[name])).
+			Value(value).
+			Placeholder(vpnVariablePlaceholders[name]).
+			Validate(vpnValueValidator(t, name, required))
+		if generator.IsSecretEnvVar(name) {
+			input = input.EchoMode(huh.EchoModePassword)
+		}
+		fields = append(fields, input)
+	}
+	for _, variable := range provider.Variables[config.Type] {
+		addInput(variable.Name, variable.Required)
+	}
+	for _, filter := range provider.Filters {
+		addInput(filter, false)
+	}
+
+	form2 := huh.NewForm(huh.NewGroup(fields...))
+	if err := form2.Run(); err != nil {
+		return nil, err
+	}
+	for name, value := range values {
+		config.SetValue(name, *value)
+	}
+
 	// Port forwarding and DNS
 	var enablePortForwarding bool
-	form3 := huh.NewForm(
-		huh.NewGroup(
-			huh.NewConfirm().
-				Title(t.T("prompts.vpn_port_forwarding")).
-				Value(&enablePortForwarding),
-			huh.NewInput().
-				Title(t.T("prompts.vpn_dns")).
-				Value(&config.DNSAddress).
-				Placeholder("1.1.1.1"),
-		),
-	)
+	fields = []huh.Field{}
+	if provider.PortForwarding {
+		fields = append(fields, huh.NewConfirm().
+			Title(t.T("prompts.vpn_port_forwarding")).
+			Value(&enablePortForwarding))
+	}
+	fields = append(fields, huh.NewInput().
+		Title(t.T("prompts.vpn_dns")).
+		Description(t.T("prompts.vpn_dns_help")).
+		Value(&config.DNSAddress).
+		Placeholder("1.1.1.1"))
+	form3 := huh.NewForm(huh.NewGroup(fields...))
 
 	if err := form3.Run(); err != nil {
 		return nil, err
@@ -110,6 +167,30 @@ func ConfigureVPN(t *i18n.I18n) (*generator.VPNConfig, error) {
 	return config, nil
 }
 
+// requiredValue rejects empty input
+func requiredValue(t *i18n.I18n) func(string) error {
+	return func(value string) error {
+		if strings.TrimSpace(value) == "" {
+			return errors.New(t.T("prompts.vpn_value_required"))
+		}
+		return nil
+	}
+}
+
+// vpnValueValidator checks a Gluetun variable as it is typed, so malformed
+// keys and addresses are caught before generation
+func vpnValueValidator(t *i18n.I18n, name string, required bool) func(string) error {
+	return func(value string) error {
+		if value == "" {
+			if required {
+				return errors.New(t.T("prompts.vpn_value_required"))
+			}
+			return nil
+		}
+		return generator.ValidateVPNValue(name, value)
+	}
+}
+
 // ConfigureEnvironment prompts for all environment variables
 func ConfigureEnvironment(translator *i18n.I18n, vpnEnabled bool) (*generator.EnvConfig, error) {
 	config := generator.NewDefaultEnvConfig()
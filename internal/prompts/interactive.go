@@ -28,6 +28,66 @@ func AskVPN(t *i18n.I18n) (bool, error) {
 	return useVPN, nil
 }
 
+// AskHWAccel prompts for the hardware transcoding backend used by Jellyfin
+// and FileFlows
+func AskHWAccel(t *i18n.I18n) (services.HWAccel, error) {
+	hwaccel := services.HWAccelNone
+
+	form := huh.NewForm(
+		huh.NewGroup(
+			huh.NewSelect[services.HWAccel]().
+				Title(t.T("prompts.hwaccel_question")).
+				Description(t.T("prompts.hwaccel_description")).
+				Options(
+					huh.NewOption(t.T("prompts.hwaccel_none"), services.HWAccelNone),
+					huh.NewOption("VA-API (Intel/AMD)", services.HWAccelVAAPI),
+					huh.NewOption("Intel Quick Sync (QSV)", services.HWAccelQSV),
+					huh.NewOption("NVIDIA (NVENC)", services.HWAccelNVIDIA),
+				).
+				Value(&hwaccel),
+		),
+	)
+
+	if err := form.Run(); err != nil {
+		return services.HWAccelNone, err
+	}
+
+	return hwaccel, nil
+}
+
+// AskPassphrase prompts for the passphrase of an encrypted profile. With
+// confirm set the passphrase is entered twice and must not be empty.
+func AskPassphrase(t *i18n.I18n, title string, confirm bool) (string, error) {
+	var passphrase, confirmation string
+
+	fields := []huh.Field{
+		huh.NewInput().
+			Title(title).
+			EchoMode(huh.EchoModePassword).
+			Value(&passphrase),
+	}
+	if confirm {
+		fields = append(fields, huh.NewInput().
+			Title(t.T("prompts.passphrase_confirm")).
+			EchoMode(huh.EchoModePassword).
+			Value(&confirmation).
+			Validate(func(value string) error {
+				if value != passphrase {
+					return fmt.Errorf("%s", t.T("prompts.passphrase_mismatch"))
+				}
+				return nil
+			}))
+	}
+
+	if err := huh.NewForm(huh.NewGroup(fields...)).Run(); err != nil {
+		return "", err
+	}
+	if confirm && passphrase == "" {
+		return "", fmt.Errorf("%s", t.T("prompts.passphrase_empty"))
+	}
+	return passphrase, nil
+}
+
 // SelectServices prompts the user to select which services to use
 func SelectServices(t *i18n.I18n, registry *services.Registry, vpnEnabled bool) ([]string, error) {
 	// Filter services by VPN compatibility
@@ -51,8 +111,11 @@ func SelectServices(t *i18n.I18n, registry *services.Registry, vpnEnabled bool)
 
 		// Add services in this category
 		for _, service := range servicesInCategory {
-			// Get translated description
-			description := t.T(service.GetDescriptionKey())
+			// Get translated description, falling back to the definition for user-defined services
+			description := service.Description
+			if translated := t.T(service.GetDescriptionKey()); translated != service.GetDescriptionKey() {
+				description = translated
+			}
 			displayName := fmt.Sprintf("%s (%s)", service.Name, description)
 			
 			if service.RequiresVPN {
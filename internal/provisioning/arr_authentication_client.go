@@ -23,6 +23,7 @@ var arrAuthenticationAPIVersions = map[string]string{
 	"lidarr":   "v1",
 	"prowlarr": "v1",
 	"radarr":   "v3",
+	"readarr":  "v1",
 	"sonarr":   "v3",
 }
 
@@ -17,14 +17,16 @@ const maxARRResponseSize = 1024 * 1024
 
 var (
 	approvedARRRootFolders = map[string]string{
-		"lidarr": "/data/library/music",
-		"radarr": "/data/library/movies",
-		"sonarr": "/data/library/tv",
+		"lidarr":  "/data/library/music",
+		"radarr":  "/data/library/movies",
+		"readarr": "/data/library/books",
+		"sonarr":  "/data/library/tv",
 	}
 	arrAPIVersions = map[string]string{
-		"lidarr": "v1",
-		"radarr": "v3",
-		"sonarr": "v3",
+		"lidarr":  "v1",
+		"radarr":  "v3",
+		"readarr": "v1",
+		"sonarr":  "v3",
 	}
 )
 
@@ -19,6 +19,7 @@ var (
 		"lidarr":   {},
 		"prowlarr": {},
 		"radarr":   {},
+		"readarr":  {},
 		"sonarr":   {},
 	}
 )
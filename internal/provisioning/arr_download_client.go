@@ -18,9 +18,10 @@ const (
 )
 
 var arrCategoryFields = map[string]string{
-	"lidarr": "musicCategory",
-	"radarr": "movieCategory",
-	"sonarr": "tvCategory",
+	"lidarr":  "musicCategory",
+	"radarr":  "movieCategory",
+	"readarr": "musicCategory",
+	"sonarr":  "tvCategory",
 }
 
 func (c *ARRClient) EnsureQBittorrentDownloadClient(
@@ -23,9 +23,10 @@ var prowlarrTargets = map[string]prowlarrTarget{
 		Name: "LazyLibrarian", BaseURL: "http://lazylibrarian:5299",
 		SyncCategories: []int{3030, 7000, 7010, 7020, 7030, 7040, 7050, 7060},
 	},
-	"lidarr": {Name: "Lidarr", BaseURL: "http://lidarr:8686"},
-	"radarr": {Name: "Radarr", BaseURL: "http://radarr:7878"},
-	"sonarr": {Name: "Sonarr", BaseURL: "http://sonarr:8989"},
+	"lidarr":  {Name: "Lidarr", BaseURL: "http://lidarr:8686"},
+	"radarr":  {Name: "Radarr", BaseURL: "http://radarr:7878"},
+	"readarr": {Name: "Readarr", BaseURL: "http://readarr:8787"},
+	"sonarr":  {Name: "Sonarr", BaseURL: "http://sonarr:8989"},
 }
 
 type ProwlarrClient struct {
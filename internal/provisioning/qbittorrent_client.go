@@ -20,6 +20,7 @@ var qbittorrentCategories = map[string]string{
 	"lazylibrarian": "/data/downloads/complete/lazylibrarian",
 	"lidarr":        "/data/downloads/complete/lidarr",
 	"radarr":        "/data/downloads/complete/radarr",
+	"readarr":       "/data/downloads/complete/readarr",
 	"sonarr":        "/data/downloads/complete/sonarr",
 }
 
@@ -98,6 +99,23 @@ func (c *QBittorrentClient) EnsureDownloadPaths(
 	})
 }
 
+// SetListenPort makes qBittorrent accept peers on the port forwarded by the
+// VPN provider. Random ports and UPnP would move it away again.
+func (c *QBittorrentClient) SetListenPort(
+	ctx context.Context,
+	session *QBittorrentSession,
+	port int,
+) error {
+	if port < 1 || port > 65535 {
+		return fmt.Errorf("set qBittorrent listen port: invalid port %d", port)
+	}
+	return c.setPreferences(ctx, session, map[string]any{
+		"listen_port": port,
+		"random_port": false,
+		"upnp":        false,
+	})
+}
+
 func (c *QBittorrentClient) setPreferences(
 	ctx context.Context,
 	session *QBittorrentSession,
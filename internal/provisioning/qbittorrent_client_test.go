@@ -150,6 +150,7 @@ func TestQBittorrentClientReconcilesApprovedCategories(t *testing.T) {
 		"/api/v2/torrents/createCategory:lazylibrarian:/data/downloads/complete/lazylibrarian",
 		"/api/v2/torrents/createCategory:lidarr:/data/downloads/complete/lidarr",
 		"/api/v2/torrents/editCategory:radarr:/data/downloads/complete/radarr",
+		"/api/v2/torrents/createCategory:readarr:/data/downloads/complete/readarr",
 	}
 	if !reflect.DeepEqual(operations, want) {
 		t.Fatalf("unexpected category operations\nwant: %v\n got: %v", want, operations)
@@ -191,6 +192,44 @@ func TestQBittorrentClientSetsApprovedDownloadPaths(t *testing.T) {
 	}
 }
 
+func TestQBittorrentClientSetsForwardedListenPort(t *testing.T) {
+	var preferences map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+		switch request.URL.Path {
+		case "/api/v2/auth/login":
+			http.SetCookie(response, &http.Cookie{Name: "SID", Value: "session", Path: "/"})
+			_, _ = response.Write([]byte("Ok."))
+		case "/api/v2/app/setPreferences":
+			if err := request.ParseForm(); err != nil {
+				t.Errorf("parse preferences form: %v", err)
+			}
+			if err := json.Unmarshal([]byte(request.Form.Get("json")), &preferences); err != nil {
+				t.Errorf("decode preferences: %v", err)
+			}
+		default:
+			t.Errorf("unexpected endpoint %s", request.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	client := NewQBittorrentClient(readinessResolver{url: server.URL})
+	session, err := client.Login(context.Background(), "corsarr", credentials.NewSecret("password"))
+	if err != nil {
+		t.Fatalf("login qBittorrent: %v", err)
+	}
+	if err := client.SetListenPort(context.Background(), session, 51413); err != nil {
+		t.Fatalf("set listen port: %v", err)
+	}
+	if preferences["listen_port"] != float64(51413) ||
+		preferences["random_port"] != false ||
+		preferences["upnp"] != false {
+		t.Fatalf("unexpected listen port preferences %#v", preferences)
+	}
+	if err := client.SetListenPort(context.Background(), session, 0); err == nil {
+		t.Fatal("expected an invalid listen port to be rejected")
+	}
+}
+
 func TestQBittorrentClientDoesNotRedirectCredential(t *testing.T) {
 	targetReceivedCredential := false
 	target := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, request *http.Request) {
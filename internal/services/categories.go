@@ -11,6 +11,7 @@ const (
 	CategoryStreaming ServiceCategory = "streaming"
 	CategoryRequest   ServiceCategory = "request"
 	CategoryTranscode ServiceCategory = "transcode"
+	CategoryProxy     ServiceCategory = "proxy"
 	CategoryVPN       ServiceCategory = "vpn"
 )
 
@@ -29,6 +30,17 @@ func AllCategories() []ServiceCategory {
 		CategoryStreaming,
 		CategoryRequest,
 		CategoryTranscode,
+		CategoryProxy,
 		CategoryVPN,
 	}
 }
+
+// IsValid reports whether the category is one of the known categories
+func (c ServiceCategory) IsValid() bool {
+	for _, category := range AllCategories() {
+		if c == category {
+			return true
+		}
+	}
+	return false
+}
@@ -32,7 +32,7 @@ func TestAllCategories(t *testing.T) {
 		t.Fatal("Expected categories, got 0")
 	}
 
-	expectedCount := 8 // download, indexer, media, subtitles, streaming, request, transcode, vpn
+	expectedCount := 9 // download, indexer, media, subtitles, streaming, request, transcode, proxy, vpn
 	if len(categories) != expectedCount {
 		t.Errorf("Expected %d categories, got %d", expectedCount, len(categories))
 	}
@@ -46,6 +46,7 @@ func TestAllCategories(t *testing.T) {
 		CategoryStreaming: false,
 		CategoryRequest:   false,
 		CategoryTranscode: false,
+		CategoryProxy:     false,
 		CategoryVPN:       false,
 	}
 
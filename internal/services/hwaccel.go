@@ -0,0 +1,135 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+)
+
+// HWAccel selects the hardware transcoding backend given to services that
+// declare hardware_acceleration
+type HWAccel string
+
+// Hardware transcoding backends
+const (
+	HWAccelNone   HWAccel = "none"
+	HWAccelVAAPI  HWAccel = "vaapi"
+	HWAccelQSV    HWAccel = "qsv"
+	HWAccelNVIDIA HWAccel = "nvidia"
+)
+
+// RenderDevicesDir holds the DRM render nodes used by VA-API and Quick Sync
+const RenderDevicesDir = "/dev/dri"
+
+// GPUConfig reserves GPUs through the container runtime, as the NVIDIA
+// Container Toolkit expects
+type GPUConfig struct {
+	Driver       string   `yaml:"driver"`
+	Count        string   `yaml:"count"`
+	Capabilities []string `yaml:"capabilities"`
+}
+
+// HWAccelModes lists the accepted --hwaccel values
+func HWAccelModes() []HWAccel {
+	return []HWAccel{HWAccelNone, HWAccelVAAPI, HWAccelQSV, HWAccelNVIDIA}
+}
+
+// ParseHWAccel parses a --hwaccel flag or profile value. An empty value
+// leaves the devices the service definitions declare themselves in place.
+func ParseHWAccel(value string) (HWAccel, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if value == "" {
+		return "", nil
+	}
+	for _, mode := range HWAccelModes() {
+		if HWAccel(value) == mode {
+			return mode, nil
+		}
+	}
+	return "", fmt.Errorf("unsupported hardware acceleration %q (use vaapi, qsv, nvidia or none)", value)
+}
+
+// Enabled reports whether a hardware backend is selected
+func (h HWAccel) Enabled() bool {
+	return h != "" && h != HWAccelNone
+}
+
+// UsesRenderDevices reports whether the backend needs /dev/dri in the container
+func (h HWAccel) UsesRenderDevices() bool {
+	return h == HWAccelVAAPI || h == HWAccelQSV
+}
+
+// Apply returns the service with the devices, groups, GPU reservation and
+// environment the backend needs. groups are the host group IDs owning the
+// render nodes, so the container user can open them. none and nvidia drop the
+// render nodes a definition passes by default. Services without
+// hardware_acceleration, or an unset backend, are returned unchanged.
+func (h HWAccel) Apply(svc *Service, groups []string) *Service {
+	if h == "" || svc == nil || !svc.HardwareAcceleration {
+		return svc
+	}
+
+	clone := svc.Clone()
+	if !h.UsesRenderDevices() {
+		clone.Devices = nil
+		for _, device := range svc.Devices {
+			if !strings.HasPrefix(device, RenderDevicesDir) {
+				clone.Devices = append(clone.Devices, device)
+			}
+		}
+	}
+	switch h {
+	case HWAccelVAAPI, HWAccelQSV:
+		device := RenderDevicesDir + ":" + RenderDevicesDir
+		if !containsString(clone.Devices, device) {
+			clone.Devices = append(clone.Devices, device)
+		}
+		for _, group := range groups {
+			if !containsString(clone.GroupAdd, group) {
+				clone.GroupAdd = append(clone.GroupAdd, group)
+			}
+		}
+		if h == HWAccelQSV {
+			// Quick Sync only works through Intel's iHD VA-API driver
+			clone.Environment = append(clone.Environment, "LIBVA_DRIVER_NAME=iHD")
+		}
+	case HWAccelNVIDIA:
+		clone.GPU = &GPUConfig{Driver: "nvidia", Count: "all", Capabilities: []string{"gpu"}}
+		clone.Environment = append(clone.Environment,
+			"NVIDIA_VISIBLE_DEVICES=all",
+			"NVIDIA_DRIVER_CAPABILITIES=compute,video,utility",
+		)
+	}
+	return clone
+}
+
+// ApplyAll applies the backend to every service
+func (h HWAccel) ApplyAll(selected []*Service, groups []string) []*Service {
+	if h == "" {
+		return selected
+	}
+	adjusted := make([]*Service, len(selected))
+	for i, svc := range selected {
+		adjusted[i] = h.Apply(svc, groups)
+	}
+	return adjusted
+}
+
+// UsesHardwareAcceleration reports whether any selected service transcodes
+// with the GPU
+func UsesHardwareAcceleration(selected []*Service) bool {
+	for _, svc := range selected {
+		if svc.HardwareAcceleration {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+	return false
+}
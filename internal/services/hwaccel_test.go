@@ -0,0 +1,95 @@
+package services
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseHWAccel(t *testing.T) {
+	tests := []struct {
+		value       string
+		expected    HWAccel
+		expectError bool
+	}{
+		{value: "", expected: ""},
+		{value: "none", expected: HWAccelNone},
+		{value: "VAAPI", expected: HWAccelVAAPI},
+		{value: " qsv ", expected: HWAccelQSV},
+		{value: "nvidia", expected: HWAccelNVIDIA},
+		{value: "cuda", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			mode, err := ParseHWAccel(tt.value)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("Expected an error for %q", tt.value)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if mode != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, mode)
+			}
+		})
+	}
+}
+
+func TestHWAccel_Apply(t *testing.T) {
+	registry, err := NewRegistry()
+	if err != nil {
+		t.Fatalf("Failed to create registry: %v", err)
+	}
+	jellyfin, _ := registry.GetService("jellyfin")
+	sonarr, _ := registry.GetService("sonarr")
+
+	vaapi := HWAccelVAAPI.Apply(jellyfin, []string{"44", "105"})
+	if !containsString(vaapi.Devices, "/dev/dri:/dev/dri") {
+		t.Errorf("Expected /dev/dri to be passed through, got %v", vaapi.Devices)
+	}
+	if strings.Join(vaapi.GroupAdd, ",") != "44,105" {
+		t.Errorf("Expected render groups, got %v", vaapi.GroupAdd)
+	}
+	if vaapi.GPU != nil {
+		t.Error("Did not expect a GPU reservation for VA-API")
+	}
+
+	qsv := HWAccelQSV.Apply(jellyfin, nil)
+	if !containsString(qsv.Environment, "LIBVA_DRIVER_NAME=iHD") {
+		t.Errorf("Expected the iHD driver for Quick Sync, got %v", qsv.Environment)
+	}
+
+	nvidia := HWAccelNVIDIA.Apply(jellyfin, nil)
+	if nvidia.GPU == nil || nvidia.GPU.Driver != "nvidia" || nvidia.GPU.Count != "all" {
+		t.Errorf("Expected an NVIDIA GPU reservation, got %+v", nvidia.GPU)
+	}
+	if !containsString(nvidia.Environment, "NVIDIA_VISIBLE_DEVICES=all") || len(nvidia.Devices) != 0 {
+		t.Errorf("Expected NVIDIA environment without /dev/dri, got %v %v", nvidia.Environment, nvidia.Devices)
+	}
+
+	if HWAccelVAAPI.Apply(sonarr, nil) != sonarr {
+		t.Error("Expected services without hardware_acceleration to be unchanged")
+	}
+	if none := HWAccelNone.Apply(jellyfin, nil); len(none.Devices) != 0 || none.GPU != nil {
+		t.Errorf("Expected none to add nothing, got %v %+v", none.Devices, none.GPU)
+	}
+
+	fileflows, _ := registry.GetService("fileflows")
+	if HWAccel("").Apply(fileflows, nil) != fileflows || !containsString(fileflows.Devices, "/dev/dri:/dev/dri") {
+		t.Error("Expected FileFlows to keep its default /dev/dri devices without --hwaccel")
+	}
+	for _, mode := range []HWAccel{HWAccelNone, HWAccelNVIDIA} {
+		if devices := mode.Apply(fileflows, nil).Devices; len(devices) != 0 {
+			t.Errorf("Expected %s to drop the default render devices, got %v", mode, devices)
+		}
+	}
+	if devices := HWAccelVAAPI.Apply(fileflows, nil).Devices; len(devices) != 2 {
+		t.Errorf("Expected VA-API to keep the default render devices once, got %v", devices)
+	}
+	if len(jellyfin.Devices) != 0 || len(jellyfin.GroupAdd) != 0 {
+		t.Error("Expected the registry definition to stay unchanged")
+	}
+}
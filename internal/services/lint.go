@@ -0,0 +1,570 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// LintIssue describes a problem found in a service definition
+type LintIssue struct {
+	File    string
+	Line    int
+	Field   string
+	Message string
+}
+
+// String formats the issue as file:line: field: message
+func (i LintIssue) String() string {
+	location := i.File
+	if i.Line > 0 {
+		location = fmt.Sprintf("%s:%d", i.File, i.Line)
+	}
+	if i.Field == "" {
+		return fmt.Sprintf("%s: %s", location, i.Message)
+	}
+	return fmt.Sprintf("%s: %s: %s", location, i.Field, i.Message)
+}
+
+// lintedService pairs a definition with the file it came from
+type lintedService struct {
+	service *Service
+	file    string
+}
+
+// Validate runs the lint checks that do not need the original YAML source
+// against every service loaded in the registry
+func (r *Registry) Validate() []LintIssue {
+	entries := make([]lintedService, 0, len(r.services))
+	for _, service := range r.services {
+		entries = append(entries, lintedService{service: service, file: r.sourceName(service.ID)})
+	}
+	sortLintedServices(entries)
+
+	var issues []LintIssue
+	for _, entry := range entries {
+		issues = append(issues, lintDefinition(entry.file, entry.service)...)
+	}
+	issues = append(issues, lintGraph(entries)...)
+	return issues
+}
+
+// validateUserServices runs the load-time checks on user-defined services.
+// Host ports are only compared among user definitions: a collision with a
+// built-in service matters only when both are selected and can be resolved
+// with a port override, so it is left to services lint.
+func (r *Registry) validateUserServices() []LintIssue {
+	entries := make([]lintedService, 0, len(r.services))
+	var userEntries []lintedService
+	for _, service := range r.services {
+		entry := lintedService{service: service, file: r.sourceName(service.ID)}
+		entries = append(entries, entry)
+		if r.sources[service.ID] != "" {
+			userEntries = append(userEntries, entry)
+		}
+	}
+	sortLintedServices(entries)
+	sortLintedServices(userEntries)
+
+	var issues []LintIssue
+	for _, entry := range userEntries {
+		issues = append(issues, lintDefinition(entry.file, entry.service)...)
+	}
+	issues = append(issues, lintDependencies(entries)...)
+	issues = append(issues, lintHostPorts(userEntries)...)
+	return issues
+}
+
+// LintBuiltinServices lints the embedded service definitions
+func LintBuiltinServices() ([]LintIssue, error) {
+	entries, err := servicesFS.ReadDir("templates/services")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read built-in services: %w", err)
+	}
+
+	var issues []LintIssue
+	var linted []lintedService
+	for _, entry := range entries {
+		if entry.IsDir() || !IsServiceDefinitionFile(entry.Name()) {
+			continue
+		}
+		file := path.Join("templates/services", entry.Name())
+		data, err := servicesFS.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read built-in service %s: %w", entry.Name(), err)
+		}
+		service, documentIssues := lintDocument(file, data)
+		issues = append(issues, documentIssues...)
+		if service != nil {
+			linted = append(linted, lintedService{service: service, file: file})
+		}
+	}
+
+	return append(issues, lintGraph(linted)...), nil
+}
+
+// LintPath lints a service definition file, or every definition in a directory.
+// Dependencies are resolved against base, whose services are replaced by
+// linted definitions with the same ID.
+func LintPath(target string, base *Registry) ([]LintIssue, error) {
+	info, err := os.Stat(target)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", target, err)
+	}
+
+	files := []string{target}
+	if info.IsDir() {
+		entries, err := os.ReadDir(target)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", target, err)
+		}
+		files = files[:0]
+		for _, entry := range entries {
+			if !entry.IsDir() && IsServiceDefinitionFile(entry.Name()) {
+				files = append(files, filepath.Join(target, entry.Name()))
+			}
+		}
+	}
+
+	var issues []LintIssue
+	linted := make(map[string]lintedService)
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", file, err)
+		}
+		service, documentIssues := lintDocument(file, data)
+		issues = append(issues, documentIssues...)
+		if service == nil || service.ID == "" {
+			continue
+		}
+		if previous, exists := linted[service.ID]; exists {
+			issues = append(issues, LintIssue{File: file, Field: "id", Message: fmt.Sprintf("service %q is already defined in %s", service.ID, previous.file)})
+			continue
+		}
+		linted[service.ID] = lintedService{service: service, file: file}
+	}
+
+	entries := make([]lintedService, 0, len(linted))
+	for _, entry := range linted {
+		entries = append(entries, entry)
+	}
+	if base != nil {
+		for _, service := range base.services {
+			if _, overridden := linted[service.ID]; !overridden {
+				entries = append(entries, lintedService{service: service, file: base.sourceName(service.ID)})
+			}
+		}
+	}
+	sortLintedServices(entries)
+
+	// Only report graph issues that involve the linted files
+	for _, issue := range lintGraph(entries) {
+		for _, file := range files {
+			if issue.File == file {
+				issues = append(issues, issue)
+				break
+			}
+		}
+	}
+
+	return issues, nil
+}
+
+// sourceName returns a display name for the file a service was loaded from
+func (r *Registry) sourceName(id string) string {
+	if source := r.sources[id]; source != "" {
+		return source
+	}
+	return path.Join("templates/services", id+".yaml")
+}
+
+// lintDocument checks a single YAML document, including keys the schema does not know
+func lintDocument(file string, data []byte) (*Service, []LintIssue) {
+	var document yaml.Node
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, []LintIssue{{File: file, Message: fmt.Sprintf("invalid YAML: %v", err)}}
+	}
+	if len(document.Content) == 0 {
+		return nil, []LintIssue{{File: file, Message: "file is empty"}}
+	}
+
+	root := document.Content[0]
+	issues := lintKeys(file, "", root, reflect.TypeOf(Service{}))
+
+	var service Service
+	if err := root.Decode(&service); err != nil {
+		return nil, append(issues, LintIssue{File: file, Line: root.Line, Message: fmt.Sprintf("invalid service definition: %v", err)})
+	}
+
+	definitionIssues := lintDefinition(file, &service)
+	for i := range definitionIssues {
+		definitionIssues[i].Line = fieldLine(root, definitionIssues[i].Field)
+	}
+
+	return &service, append(issues, definitionIssues...)
+}
+
+// lintKeys walks a YAML node and reports mapping keys that have no matching yaml tag
+func lintKeys(file, prefix string, node *yaml.Node, target reflect.Type) []LintIssue {
+	for target.Kind() == reflect.Pointer {
+		target = target.Elem()
+	}
+
+	var issues []LintIssue
+	switch {
+	case target.Kind() == reflect.Struct && node.Kind == yaml.MappingNode:
+		fields := yamlFields(target)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			field := joinField(prefix, key.Value)
+			fieldType, known := fields[key.Value]
+			if !known {
+				issues = append(issues, LintIssue{File: file, Line: key.Line, Field: field, Message: "unknown key"})
+				continue
+			}
+			issues = append(issues, lintKeys(file, field, value, fieldType)...)
+		}
+	case target.Kind() == reflect.Slice && node.Kind == yaml.SequenceNode:
+		for index, item := range node.Content {
+			issues = append(issues, lintKeys(file, fmt.Sprintf("%s[%d]", prefix, index), item, target.Elem())...)
+		}
+	}
+	return issues
+}
+
+// yamlFields maps the yaml tag names of a struct to their field types
+func yamlFields(target reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type, target.NumField())
+	for i := 0; i < target.NumField(); i++ {
+		field := target.Field(i)
+		tag := strings.Split(field.Tag.Get("yaml"), ",")
+		name := tag[0]
+		if name == "-" || !field.IsExported() {
+			continue
+		}
+		if len(tag) > 1 && tag[1] == "inline" {
+			for inlineName, inlineType := range yamlFields(field.Type) {
+				fields[inlineName] = inlineType
+			}
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		fields[name] = field.Type
+	}
+	return fields
+}
+
+// lintDefinition checks the values of a decoded service definition
+func lintDefinition(file string, service *Service) []LintIssue {
+	var issues []LintIssue
+	report := func(field, format string, args ...interface{}) {
+		issues = append(issues, LintIssue{File: file, Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if !serviceIDPattern.MatchString(service.ID) {
+		report("id", "must be lowercase letters, digits, '-' or '_' (got %q)", service.ID)
+	}
+	for field, value := range map[string]string{"name": service.Name, "image": service.Image, "container_name": service.ContainerName} {
+		if strings.TrimSpace(value) == "" {
+			report(field, "is required")
+		}
+	}
+	if !service.Category.IsValid() {
+		report("category", "unknown category %q (expected one of %s)", service.Category, categoryList())
+	}
+
+	hostPorts := make(map[string]bool)
+	for index, port := range service.Ports {
+		field := fmt.Sprintf("ports[%d]", index)
+		if !validPortSpec(port.Host) {
+			report(field+".host", "invalid port %q", port.Host)
+		}
+		if !validPortSpec(port.Container) {
+			report(field+".container", "invalid port %q", port.Container)
+		}
+		protocol := port.Protocol
+		if protocol == "" {
+			protocol = "tcp"
+		}
+		if protocol != "tcp" && protocol != "udp" {
+			report(field+".protocol", "must be tcp or udp (got %q)", port.Protocol)
+		}
+		key := port.Host + "/" + protocol
+		if hostPorts[key] {
+			report(field+".host", "host port %s is declared more than once", key)
+		}
+		hostPorts[key] = true
+	}
+
+	for index, volume := range service.Volumes {
+		field := fmt.Sprintf("volumes[%d]", index)
+		if strings.TrimSpace(volume.Host) == "" {
+			report(field+".host", "is required")
+		}
+		if !strings.HasPrefix(volume.Container, "/") {
+			report(field+".container", "must be an absolute container path (got %q)", volume.Container)
+		}
+	}
+
+	if service.WebUI != nil && !hostPorts[service.WebUI.Port+"/tcp"] {
+		report("web_ui.port", "port %q does not match any declared tcp host port", service.WebUI.Port)
+	}
+
+	if service.Healthcheck != nil {
+		lintHealthcheck(service, report)
+	}
+	service.Resources.check(report)
+	if service.GPU != nil && (service.GPU.Driver == "" || service.GPU.Count == "") {
+		report("gpu", "driver and count are required")
+	}
+
+	if len(service.Network.BridgeMode.Networks) == 0 {
+		report("network.bridge_mode.networks", "at least one network is required")
+	}
+
+	for index, dependency := range service.Dependencies {
+		if dependency == service.ID {
+			report(fmt.Sprintf("dependencies[%d]", index), "service cannot depend on itself")
+		}
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool { return issues[i].Field < issues[j].Field })
+	return issues
+}
+
+// lintHealthcheck checks that the probe can be built and that the timings
+// are valid Go durations, as expected by Docker and Podman
+func lintHealthcheck(service *Service, report func(field, format string, args ...interface{})) {
+	healthcheck := service.Healthcheck
+	switch {
+	case len(healthcheck.Test) == 0 && healthcheck.Path == "":
+		report("healthcheck", "path or test is required")
+	case len(healthcheck.Test) > 0 && healthcheck.Path != "":
+		report("healthcheck", "path and test are mutually exclusive")
+	case healthcheck.Path != "" && !strings.HasPrefix(healthcheck.Path, "/"):
+		report("healthcheck.path", "must start with '/' (got %q)", healthcheck.Path)
+	case healthcheck.Path != "" && healthcheck.Port == "" && service.mainContainerPort() == "":
+		report("healthcheck.port", "is required for services without published ports")
+	}
+	if healthcheck.Port != "" && !validPortSpec(healthcheck.Port) {
+		report("healthcheck.port", "invalid port %q", healthcheck.Port)
+	}
+	for field, value := range map[string]string{
+		"healthcheck.interval":     healthcheck.Interval,
+		"healthcheck.timeout":      healthcheck.Timeout,
+		"healthcheck.start_period": healthcheck.StartPeriod,
+	} {
+		if value == "" {
+			continue
+		}
+		if _, err := time.ParseDuration(value); err != nil {
+			report(field, "invalid duration %q", value)
+		}
+	}
+	if healthcheck.Retries < 0 {
+		report("healthcheck.retries", "must not be negative (got %d)", healthcheck.Retries)
+	}
+}
+
+// lintGraph checks relationships between services: unknown dependencies,
+// dependency cycles and host ports published by more than one service
+func lintGraph(entries []lintedService) []LintIssue {
+	return append(lintDependencies(entries), lintHostPorts(entries)...)
+}
+
+// lintDependencies reports unknown dependencies and dependency cycles
+func lintDependencies(entries []lintedService) []LintIssue {
+	byID := make(map[string]lintedService, len(entries))
+	for _, entry := range entries {
+		byID[entry.service.ID] = entry
+	}
+
+	var issues []LintIssue
+	for _, entry := range entries {
+		for index, dependency := range entry.service.Dependencies {
+			if _, exists := byID[dependency]; !exists {
+				issues = append(issues, LintIssue{
+					File:    entry.file,
+					Field:   fmt.Sprintf("dependencies[%d]", index),
+					Message: fmt.Sprintf("unknown dependency %q", dependency),
+				})
+			}
+		}
+	}
+
+	// Depth-first search for cycles; each cycle is reported once on its first member
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make(map[string]int, len(entries))
+	var stack []string
+	var visit func(id string)
+	visit = func(id string) {
+		state[id] = visiting
+		stack = append(stack, id)
+		for _, dependency := range byID[id].service.Dependencies {
+			if _, exists := byID[dependency]; !exists || dependency == id {
+				continue
+			}
+			switch state[dependency] {
+			case unvisited:
+				visit(dependency)
+			case visiting:
+				start := 0
+				for stack[start] != dependency {
+					start++
+				}
+				cycle := append(append([]string{}, stack[start:]...), dependency)
+				issues = append(issues, LintIssue{
+					File:    byID[dependency].file,
+					Field:   "dependencies",
+					Message: "dependency cycle: " + strings.Join(cycle, " -> "),
+				})
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[id] = done
+	}
+	for _, entry := range entries {
+		if state[entry.service.ID] == unvisited {
+			visit(entry.service.ID)
+		}
+	}
+
+	return issues
+}
+
+// lintHostPorts reports host ports published by more than one service
+func lintHostPorts(entries []lintedService) []LintIssue {
+	// The VPN service publishes the ports of the services sharing its network,
+	// so it is excluded from the duplicate host port check
+	var issues []LintIssue
+	type portOwner struct {
+		entry lintedService
+		index int
+	}
+	owners := make(map[string]portOwner)
+	for _, entry := range entries {
+		if entry.service.Category == CategoryVPN {
+			continue
+		}
+		for index, port := range entry.service.Ports {
+			protocol := port.Protocol
+			if protocol == "" {
+				protocol = "tcp"
+			}
+			key := port.Host + "/" + protocol
+			owner, exists := owners[key]
+			if !exists {
+				owners[key] = portOwner{entry: entry, index: index}
+				continue
+			}
+			if owner.entry.service.ID == entry.service.ID {
+				continue
+			}
+			// Report both sides so the conflict shows up whichever file is linted
+			issues = append(issues,
+				LintIssue{
+					File:    owner.entry.file,
+					Field:   fmt.Sprintf("ports[%d].host", owner.index),
+					Message: fmt.Sprintf("host port %s is also published by %s", key, entry.service.ID),
+				},
+				LintIssue{
+					File:    entry.file,
+					Field:   fmt.Sprintf("ports[%d].host", index),
+					Message: fmt.Sprintf("host port %s is also published by %s", key, owner.entry.service.ID),
+				},
+			)
+		}
+	}
+
+	return issues
+}
+
+// validPortSpec accepts a port number or a range such as 6881-6889
+func validPortSpec(value string) bool {
+	parts := strings.Split(value, "-")
+	if len(parts) > 2 {
+		return false
+	}
+	previous := 0
+	for _, part := range parts {
+		port, err := strconv.Atoi(part)
+		if err != nil || port < 1 || port > 65535 || port < previous {
+			return false
+		}
+		previous = port
+	}
+	return true
+}
+
+// fieldLine finds the line of a dotted field path such as ports[0].host
+func fieldLine(root *yaml.Node, field string) int {
+	node := root
+	for _, segment := range strings.Split(field, ".") {
+		name, index := segment, -1
+		if open := strings.Index(segment, "["); open >= 0 {
+			name = segment[:open]
+			index, _ = strconv.Atoi(strings.TrimSuffix(segment[open+1:], "]"))
+		}
+
+		next := mappingValue(node, name)
+		if next == nil {
+			return node.Line
+		}
+		if index >= 0 {
+			if next.Kind != yaml.SequenceNode || index >= len(next.Content) {
+				return next.Line
+			}
+			next = next.Content[index]
+		}
+		node = next
+	}
+	return node.Line
+}
+
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+func joinField(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
+
+func categoryList() string {
+	names := make([]string, 0, len(AllCategories()))
+	for _, category := range AllCategories() {
+		names = append(names, string(category))
+	}
+	return strings.Join(names, ", ")
+}
+
+func sortLintedServices(entries []lintedService) {
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].service.ID < entries[j].service.ID
+	})
+}
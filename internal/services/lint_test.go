@@ -0,0 +1,181 @@
+package services
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLintBuiltinServices(t *testing.T) {
+	issues, err := LintBuiltinServices()
+	if err != nil {
+		t.Fatalf("Failed to lint built-in services: %v", err)
+	}
+	for _, issue := range issues {
+		t.Errorf("Unexpected issue: %s", issue)
+	}
+}
+
+func TestRegistryValidate(t *testing.T) {
+	registry, err := NewRegistry()
+	if err != nil {
+		t.Fatalf("Failed to create registry: %v", err)
+	}
+	for _, issue := range registry.Validate() {
+		t.Errorf("Unexpected issue: %s", issue)
+	}
+}
+
+func TestLintPath(t *testing.T) {
+	base, err := NewRegistry()
+	if err != nil {
+		t.Fatalf("Failed to create registry: %v", err)
+	}
+	valid := fmt.Sprintf(userServiceYAML, "custom", "custom")
+
+	tests := []struct {
+		name          string
+		files         map[string]string
+		expectedField string
+		expectedText  string
+	}{
+		{
+			name:          "Unknown key",
+			files:         map[string]string{"custom.yaml": strings.Replace(valid, "    protocol: tcp", "    protocol: tcp\n    mode: host", 1)},
+			expectedField: "ports[0].mode",
+			expectedText:  "unknown key",
+		},
+		{
+			name:          "Invalid category",
+			files:         map[string]string{"custom.yaml": strings.Replace(valid, "category: media", "category: medias", 1)},
+			expectedField: "category",
+			expectedText:  "unknown category",
+		},
+		{
+			name:          "Unknown dependency",
+			files:         map[string]string{"custom.yaml": strings.Replace(valid, "  - qbittorrent", "  - qbitorrent", 1)},
+			expectedField: "dependencies[0]",
+			expectedText:  "unknown dependency",
+		},
+		{
+			name: "Dependency cycle",
+			files: map[string]string{
+				"custom.yaml": strings.Replace(valid, "  - qbittorrent", "  - other", 1),
+				"other.yaml": strings.Replace(strings.Replace(fmt.Sprintf(userServiceYAML, "other", "other"),
+					"  - qbittorrent", "  - custom", 1), `"9999"`, `"9998"`, 3),
+			},
+			expectedField: "dependencies",
+			expectedText:  "dependency cycle",
+		},
+		{
+			name:          "Malformed port",
+			files:         map[string]string{"custom.yaml": strings.Replace(valid, `container: "9999"`, `container: "http"`, 1)},
+			expectedField: "ports[0].container",
+			expectedText:  "invalid port",
+		},
+		{
+			name:          "Invalid protocol",
+			files:         map[string]string{"custom.yaml": strings.Replace(valid, "protocol: tcp", "protocol: sctp", 1)},
+			expectedField: "ports[0].protocol",
+			expectedText:  "must be tcp or udp",
+		},
+		{
+			name:          "Relative container volume",
+			files:         map[string]string{"custom.yaml": strings.Replace(valid, `container: "/config"`, `container: "config"`, 1)},
+			expectedField: "volumes[0].container",
+			expectedText:  "absolute container path",
+		},
+		{
+			name:          "Web UI port without mapping",
+			files:         map[string]string{"custom.yaml": strings.Replace(valid, "web_ui:\n  port: \"9999\"", "web_ui:\n  port: \"9000\"", 1)},
+			expectedField: "web_ui.port",
+			expectedText:  "does not match",
+		},
+		{
+			name:          "Missing bridge networks",
+			files:         map[string]string{"custom.yaml": strings.Replace(valid, "    networks:\n      - media\n", "", 1)},
+			expectedField: "network.bridge_mode.networks",
+			expectedText:  "at least one network",
+		},
+		{
+			name:          "Healthcheck without probe",
+			files:         map[string]string{"custom.yaml": strings.Replace(valid, "restart: unless-stopped", "restart: unless-stopped\nhealthcheck:\n  interval: 30s", 1)},
+			expectedField: "healthcheck",
+			expectedText:  "path or test is required",
+		},
+		{
+			name:          "Healthcheck relative path",
+			files:         map[string]string{"custom.yaml": strings.Replace(valid, "restart: unless-stopped", "restart: unless-stopped\nhealthcheck:\n  path: ping", 1)},
+			expectedField: "healthcheck.path",
+			expectedText:  "must start with '/'",
+		},
+		{
+			name:          "Healthcheck invalid duration",
+			files:         map[string]string{"custom.yaml": strings.Replace(valid, "restart: unless-stopped", "restart: unless-stopped\nhealthcheck:\n  path: /ping\n  interval: 30", 1)},
+			expectedField: "healthcheck.interval",
+			expectedText:  "invalid duration",
+		},
+		{
+			name:          "Invalid memory limit",
+			files:         map[string]string{"custom.yaml": strings.Replace(valid, "restart: unless-stopped", "restart: unless-stopped\nmem_limit: plenty", 1)},
+			expectedField: "mem_limit",
+			expectedText:  "must be a size",
+		},
+		{
+			name:          "Host port published by a built-in service",
+			files:         map[string]string{"custom.yaml": strings.ReplaceAll(valid, `"9999"`, `"8989"`)},
+			expectedField: "ports[0].host",
+			expectedText:  "also published by sonarr",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for filename, content := range tt.files {
+				writeUserService(t, dir, filename, content)
+			}
+
+			issues, err := LintPath(dir, base)
+			if err != nil {
+				t.Fatalf("Lint failed: %v", err)
+			}
+
+			for _, issue := range issues {
+				if issue.Field == tt.expectedField && strings.Contains(issue.Message, tt.expectedText) {
+					if !strings.HasPrefix(issue.File, dir) {
+						t.Errorf("Expected issue to point at a linted file, got %s", issue.File)
+					}
+					return
+				}
+			}
+			t.Errorf("Expected %s issue containing %q, got %v", tt.expectedField, tt.expectedText, issues)
+		})
+	}
+}
+
+func TestLintPath_ValidFile(t *testing.T) {
+	base, err := NewRegistry()
+	if err != nil {
+		t.Fatalf("Failed to create registry: %v", err)
+	}
+
+	dir := t.TempDir()
+	writeUserService(t, dir, "custom.yaml", fmt.Sprintf(userServiceYAML, "custom", "custom"))
+
+	issues, err := LintPath(filepath.Join(dir, "custom.yaml"), base)
+	if err != nil {
+		t.Fatalf("Lint failed: %v", err)
+	}
+	if len(issues) != 0 {
+		t.Errorf("Expected no issues, got %v", issues)
+	}
+}
+
+func TestLintIssueString(t *testing.T) {
+	issue := LintIssue{File: "custom.yaml", Line: 3, Field: "category", Message: "unknown category"}
+	if got := issue.String(); got != "custom.yaml:3: category: unknown category" {
+		t.Errorf("Unexpected format: %s", got)
+	}
+}
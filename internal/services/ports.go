@@ -0,0 +1,150 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// PortOverrides remaps published host ports without editing service
+// definitions. Keys are a service ID, which remaps the service's main port
+// (its web interface, or the first port), or "<id>:<container port>" for a
+// specific port. Values are the host ports to publish instead.
+type PortOverrides map[string]string
+
+// ParsePortOverride parses a "sonarr=18989" or "qbittorrent:6881=16881" flag
+// value into an override key and host port
+func ParsePortOverride(spec string) (string, string, error) {
+	key, port, found := strings.Cut(strings.TrimSpace(spec), "=")
+	key = strings.TrimSpace(key)
+	port = strings.TrimSpace(port)
+	if !found || key == "" || port == "" {
+		return "", "", fmt.Errorf("invalid port override %q (use service=port or service:container=port)", spec)
+	}
+	if err := validateOverridePort(port); err != nil {
+		return "", "", fmt.Errorf("invalid port override %q: %w", spec, err)
+	}
+	return key, port, nil
+}
+
+// ParsePortOverrides parses repeated --port flag values
+func ParsePortOverrides(specs []string) (PortOverrides, error) {
+	overrides := make(PortOverrides, len(specs))
+	for _, spec := range specs {
+		key, port, err := ParsePortOverride(spec)
+		if err != nil {
+			return nil, err
+		}
+		overrides[key] = port
+	}
+	return overrides, nil
+}
+
+// Validate checks that every override targets a known service port
+func (o PortOverrides) Validate(registry *Registry) error {
+	keys := make([]string, 0, len(o))
+	for key := range o {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if err := validateOverridePort(o[key]); err != nil {
+			return fmt.Errorf("port override %s: %w", key, err)
+		}
+		serviceID, containerPort, _ := strings.Cut(key, ":")
+		svc, err := registry.GetService(serviceID)
+		if err != nil {
+			return fmt.Errorf("port override %s: %w", key, err)
+		}
+		if containerPort == "" {
+			containerPort = svc.mainContainerPort()
+		}
+		if containerPort == "" || !svc.hasContainerPort(containerPort) {
+			return fmt.Errorf("port override %s: service %s does not publish that port", key, serviceID)
+		}
+	}
+	return nil
+}
+
+// Apply returns the service with its host ports remapped. The registry
+// definition is never modified; a copy is returned when an override matches.
+func (o PortOverrides) Apply(svc *Service) *Service {
+	if len(o) == 0 || svc == nil {
+		return svc
+	}
+
+	remap := make(map[string]string)
+	if port, ok := o[svc.ID]; ok {
+		if containerPort := svc.mainContainerPort(); containerPort != "" {
+			remap[containerPort] = port
+		}
+	}
+	for _, mapping := range svc.Ports {
+		if port, ok := o[svc.ID+":"+mapping.Container]; ok {
+			remap[mapping.Container] = port
+		}
+	}
+	if len(remap) == 0 {
+		return svc
+	}
+
+	clone := svc.Clone()
+	for i, mapping := range clone.Ports {
+		port, ok := remap[mapping.Container]
+		if !ok {
+			continue
+		}
+		if clone.WebUI != nil && clone.WebUI.Port == mapping.Host {
+			clone.WebUI.Port = port
+		}
+		clone.Ports[i].Host = port
+	}
+	return clone
+}
+
+// ApplyAll applies the overrides to every service
+func (o PortOverrides) ApplyAll(selected []*Service) []*Service {
+	if len(o) == 0 {
+		return selected
+	}
+	adjusted := make([]*Service, len(selected))
+	for i, svc := range selected {
+		adjusted[i] = o.Apply(svc)
+	}
+	return adjusted
+}
+
+// mainContainerPort returns the container port behind the web interface, or
+// the first published port for services without one
+func (s *Service) mainContainerPort() string {
+	if s.WebUI != nil {
+		for _, mapping := range s.Ports {
+			if mapping.Host == s.WebUI.Port {
+				return mapping.Container
+			}
+		}
+	}
+	if len(s.Ports) > 0 {
+		return s.Ports[0].Container
+	}
+	return ""
+}
+
+func (s *Service) hasContainerPort(port string) bool {
+	for _, mapping := range s.Ports {
+		if mapping.Container == port {
+			return true
+		}
+	}
+	return false
+}
+
+func validateOverridePort(port string) error {
+	value, err := strconv.Atoi(port)
+	if err != nil || value < 1 || value > 65535 {
+		return fmt.Errorf("host port %q must be a number between 1 and 65535", port)
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package services
+
+import "testing"
+
+func TestParsePortOverride(t *testing.T) {
+	key, port, err := ParsePortOverride("sonarr=18989")
+	if err != nil || key != "sonarr" || port != "18989" {
+		t.Errorf("Unexpected override %q=%q (%v)", key, port, err)
+	}
+	key, port, err = ParsePortOverride(" qbittorrent:6881 = 16881 ")
+	if err != nil || key != "qbittorrent:6881" || port != "16881" {
+		t.Errorf("Unexpected override %q=%q (%v)", key, port, err)
+	}
+
+	for _, spec := range []string{"sonarr", "sonarr=", "=8989", "sonarr=http", "sonarr=70000"} {
+		if _, _, err := ParsePortOverride(spec); err == nil {
+			t.Errorf("Expected %q to be rejected", spec)
+		}
+	}
+}
+
+func TestPortOverrides_Apply(t *testing.T) {
+	registry, err := NewRegistry()
+	if err != nil {
+		t.Fatalf("Failed to create registry: %v", err)
+	}
+	sonarr, _ := registry.GetService("sonarr")
+	qbittorrent, _ := registry.GetService("qbittorrent")
+
+	overrides := PortOverrides{"sonarr": "18989", "qbittorrent:6881": "16881"}
+
+	remapped := overrides.Apply(sonarr)
+	if remapped.Ports[0].Host != "18989" || remapped.Ports[0].Container != "8989" {
+		t.Errorf("Expected Sonarr 18989:8989, got %+v", remapped.Ports[0])
+	}
+	if remapped.WebUI.Port != "18989" {
+		t.Errorf("Expected the web interface port to follow the override, got %s", remapped.WebUI.Port)
+	}
+	if sonarr.Ports[0].Host != "8989" || sonarr.WebUI.Port != "8989" {
+		t.Error("Expected the registry definition to stay untouched")
+	}
+
+	remapped = overrides.Apply(qbittorrent)
+	for _, port := range remapped.Ports {
+		want := port.Container
+		if port.Container == "6881" {
+			want = "16881"
+		}
+		if port.Host != want {
+			t.Errorf("Expected %s/%s published on %s, got %s", port.Container, port.Protocol, want, port.Host)
+		}
+	}
+
+	radarr, _ := registry.GetService("radarr")
+	if overrides.Apply(radarr) != radarr {
+		t.Error("Expected services without overrides to be returned as is")
+	}
+}
+
+func TestPortOverrides_Validate(t *testing.T) {
+	registry, err := NewRegistry()
+	if err != nil {
+		t.Fatalf("Failed to create registry: %v", err)
+	}
+
+	if err := (PortOverrides{"sonarr": "18989", "qbittorrent:6881": "16881"}).Validate(registry); err != nil {
+		t.Errorf("Expected valid overrides: %v", err)
+	}
+	for _, overrides := range []PortOverrides{
+		{"unknown": "8000"},
+		{"sonarr:1234": "8000"},
+		{"sonarr": "0"},
+	} {
+		if err := overrides.Validate(registry); err == nil {
+			t.Errorf("Expected %v to be rejected", overrides)
+		}
+	}
+}
@@ -1,51 +1,106 @@
 package services
 
 import (
+	"bytes"
 	"embed"
 	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
 	"sort"
+	"strings"
 
 	"gopkg.in/yaml.v3"
 )
 
+const (
+	// DefaultUserServicesDir is the directory, relative to the user's home, that holds custom service definitions
+	DefaultUserServicesDir = ".corsarr/services.d"
+)
+
+// serviceIDPattern restricts IDs to names that are safe as compose service and container names
+var serviceIDPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
 //go:embed templates/services/*.yaml
 var servicesFS embed.FS
 
 // Registry manages all available services
 type Registry struct {
-	services map[string]*Service
+	services   map[string]*Service
 	byCategory map[ServiceCategory][]*Service
+	sources    map[string]string
+}
+
+// RegistryOptions controls how user-defined service definitions are loaded
+type RegistryOptions struct {
+	// UserServicesDir is scanned for *.yaml and *.yml definitions; empty disables user services
+	UserServicesDir string
+	// AllowOverrides lets a user definition replace a built-in service with the same ID
+	AllowOverrides bool
 }
 
 // NewRegistry creates a new service registry
 func NewRegistry() (*Registry, error) {
+	return NewRegistryWithOptions(RegistryOptions{})
+}
+
+// NewRegistryWithOptions creates a registry with the built-in services plus
+// any user-defined services found in opts.UserServicesDir. The combined
+// registry is linted so broken dependencies, protocols or host ports shared
+// between user definitions fail here instead of in the generated stack.
+func NewRegistryWithOptions(opts RegistryOptions) (*Registry, error) {
 	registry := &Registry{
-		services: make(map[string]*Service),
+		services:   make(map[string]*Service),
 		byCategory: make(map[ServiceCategory][]*Service),
+		sources:    make(map[string]string),
 	}
 
 	if err := registry.loadServices(); err != nil {
 		return nil, err
 	}
 
+	if opts.UserServicesDir != "" {
+		if err := registry.loadUserServices(opts.UserServicesDir, opts.AllowOverrides); err != nil {
+			return nil, err
+		}
+		if issues := registry.validateUserServices(); len(issues) > 0 {
+			return nil, invalidServicesError(issues)
+		}
+	}
+
+	registry.sortCategories()
+
 	return registry, nil
 }
 
+// GetUserServicesDir returns the default directory for user-defined services
+func GetUserServicesDir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(homeDir, DefaultUserServicesDir), nil
+}
+
 // loadServices loads all service definitions from embedded YAML files
 func (r *Registry) loadServices() error {
 	// List of service definition files
 	serviceFiles := []string{
 		"qbittorrent.yaml",
+		"sabnzbd.yaml",
+		"nzbget.yaml",
 		"prowlarr.yaml",
 		"flaresolverr.yaml",
 		"sonarr.yaml",
 		"radarr.yaml",
 		"lidarr.yaml",
+		"readarr.yaml",
 		"lazylibrarian.yaml",
 		"bazarr.yaml",
 		"jellyfin.yaml",
 		"jellyseerr.yaml",
 		"fileflows.yaml",
+		"caddy.yaml",
 		"gluetun.yaml",
 	}
 
@@ -61,18 +116,147 @@ func (r *Registry) loadServices() error {
 			return fmt.Errorf("failed to parse service file %s: %w", filename, err)
 		}
 
-		r.services[service.ID] = &service
-		r.byCategory[service.Category] = append(r.byCategory[service.Category], &service)
+		r.addService(&service, "")
+	}
+
+	return nil
+}
+
+// invalidServicesError folds lint issues into a single load error.
+func invalidServicesError(issues []LintIssue) error {
+	lines := make([]string, 0, len(issues))
+	for _, issue := range issues {
+		lines = append(lines, issue.String())
+	}
+	return fmt.Errorf("invalid service definitions:\n  %s", strings.Join(lines, "\n  "))
+}
+
+// loadUserServices loads service definitions from a user directory.
+// A missing directory is not an error: user services are optional.
+func (r *Registry) loadUserServices(dir string, allowOverrides bool) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read user services directory %s: %w", dir, err)
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || !IsServiceDefinitionFile(entry.Name()) {
+			continue
+		}
+
+		path := filepath.Join(dir, entry.Name())
+		service, err := LoadServiceFile(path)
+		if err != nil {
+			return err
+		}
+
+		if existing, exists := r.services[service.ID]; exists {
+			if source := r.sources[service.ID]; source != "" {
+				return fmt.Errorf("service %q in %s is already defined in %s", service.ID, path, source)
+			}
+			if !allowOverrides {
+				return fmt.Errorf("service %q in %s collides with a built-in service; enable overrides to replace it", service.ID, path)
+			}
+			r.removeService(existing)
+		}
+
+		r.addService(service, path)
+	}
+
+	return nil
+}
+
+// LoadServiceFile reads and validates a single service definition file
+func LoadServiceFile(path string) (*Service, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read service file %s: %w", path, err)
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+
+	var service Service
+	if err := decoder.Decode(&service); err != nil {
+		return nil, fmt.Errorf("failed to parse service file %s: %w", path, err)
+	}
+
+	if err := validateDefinition(&service); err != nil {
+		return nil, fmt.Errorf("invalid service file %s: %w", path, err)
+	}
+
+	return &service, nil
+}
+
+// IsServiceDefinitionFile reports whether a file name looks like a service definition
+func IsServiceDefinitionFile(name string) bool {
+	ext := strings.ToLower(filepath.Ext(name))
+	return ext == ".yaml" || ext == ".yml"
+}
+
+// validateDefinition checks the fields every service definition must provide
+func validateDefinition(service *Service) error {
+	if !serviceIDPattern.MatchString(service.ID) {
+		return fmt.Errorf("id %q must be lowercase letters, digits, '-' or '_'", service.ID)
 	}
+	if strings.TrimSpace(service.Name) == "" {
+		return fmt.Errorf("name is required")
+	}
+	if !service.Category.IsValid() {
+		return fmt.Errorf("unknown category %q", service.Category)
+	}
+	if strings.TrimSpace(service.Image) == "" {
+		return fmt.Errorf("image is required")
+	}
+	if strings.TrimSpace(service.ContainerName) == "" {
+		return fmt.Errorf("container_name is required")
+	}
+	return nil
+}
+
+func (r *Registry) addService(service *Service, source string) {
+	r.services[service.ID] = service
+	r.byCategory[service.Category] = append(r.byCategory[service.Category], service)
+	if source != "" {
+		r.sources[service.ID] = source
+	}
+}
 
-	// Sort services by name within each category
+func (r *Registry) removeService(service *Service) {
+	delete(r.services, service.ID)
+	delete(r.sources, service.ID)
+
+	inCategory := r.byCategory[service.Category]
+	for i, candidate := range inCategory {
+		if candidate == service {
+			r.byCategory[service.Category] = append(inCategory[:i:i], inCategory[i+1:]...)
+			break
+		}
+	}
+}
+
+// sortCategories sorts services by name within each category
+func (r *Registry) sortCategories() {
 	for category := range r.byCategory {
 		sort.Slice(r.byCategory[category], func(i, j int) bool {
 			return r.byCategory[category][i].Name < r.byCategory[category][j].Name
 		})
 	}
+}
 
-	return nil
+// GetServiceSource returns the file a user-defined service was loaded from,
+// or an empty string for built-in services
+func (r *Registry) GetServiceSource(id string) string {
+	return r.sources[id]
+}
+
+// IsBuiltin reports whether a service comes from the embedded definitions
+func (r *Registry) IsBuiltin(id string) bool {
+	_, exists := r.services[id]
+	return exists && r.sources[id] == ""
 }
 
 // GetService returns a service by ID
@@ -1,6 +1,10 @@
 package services
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -209,3 +213,216 @@ func TestGetAllServices(t *testing.T) {
 
 	t.Logf("✅ All %d services are valid", len(services))
 }
+
+const userServiceYAML = `id: %s
+name: Custom Service
+category: media
+description: A user-defined service
+image: example/custom:latest
+container_name: %s
+ports:
+  - host: "9999"
+    container: "9999"
+    protocol: tcp
+volumes:
+  - host: "${ARRPATH}config/custom"
+    container: "/config"
+network:
+  vpn_mode:
+    network_mode: "service:gluetun"
+  bridge_mode:
+    hostname: custom
+    networks:
+      - media
+restart: unless-stopped
+supports_vpn: true
+requires_vpn: false
+dependencies:
+  - qbittorrent
+optional: true
+web_ui:
+  port: "9999"
+`
+
+func writeUserService(t *testing.T, dir, filename, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, filename), []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write service file: %v", err)
+	}
+}
+
+func TestNewRegistryWithOptions_UserServices(t *testing.T) {
+	dir := t.TempDir()
+	writeUserService(t, dir, "custom.yaml", fmt.Sprintf(userServiceYAML, "custom", "custom"))
+	writeUserService(t, dir, "README.md", "not a service")
+
+	registry, err := NewRegistryWithOptions(RegistryOptions{UserServicesDir: dir})
+	if err != nil {
+		t.Fatalf("Failed to create registry: %v", err)
+	}
+
+	service, err := registry.GetService("custom")
+	if err != nil {
+		t.Fatalf("Expected user service to be loaded: %v", err)
+	}
+	if service.Name != "Custom Service" {
+		t.Errorf("Expected name 'Custom Service', got %s", service.Name)
+	}
+	if registry.IsBuiltin("custom") {
+		t.Error("User service should not be reported as built-in")
+	}
+	if source := registry.GetServiceSource("custom"); source != filepath.Join(dir, "custom.yaml") {
+		t.Errorf("Unexpected source %q", source)
+	}
+
+	found := false
+	for _, candidate := range registry.GetServicesByCategory(CategoryMedia) {
+		if candidate.ID == "custom" {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("User service missing from its category")
+	}
+
+	if err := registry.ValidateDependencies([]string{"custom"}); err == nil {
+		t.Error("Expected missing qbittorrent dependency to be reported")
+	}
+	if err := registry.ValidateDependencies([]string{"qbittorrent", "custom"}); err != nil {
+		t.Errorf("Expected dependencies to be satisfied: %v", err)
+	}
+}
+
+func TestNewRegistryWithOptions_MissingDirectory(t *testing.T) {
+	builtins, err := NewRegistry()
+	if err != nil {
+		t.Fatalf("Failed to create registry: %v", err)
+	}
+
+	registry, err := NewRegistryWithOptions(RegistryOptions{UserServicesDir: filepath.Join(t.TempDir(), "missing")})
+	if err != nil {
+		t.Fatalf("Missing user directory should not fail: %v", err)
+	}
+	if registry.GetServiceCount() != builtins.GetServiceCount() {
+		t.Errorf("Expected only built-in services, got %d", registry.GetServiceCount())
+	}
+}
+
+func TestNewRegistryWithOptions_BuiltinCollision(t *testing.T) {
+	dir := t.TempDir()
+	writeUserService(t, dir, "sonarr.yaml", fmt.Sprintf(userServiceYAML, "sonarr", "sonarr-custom"))
+
+	if _, err := NewRegistryWithOptions(RegistryOptions{UserServicesDir: dir}); err == nil {
+		t.Fatal("Expected collision with built-in service to fail")
+	}
+
+	registry, err := NewRegistryWithOptions(RegistryOptions{UserServicesDir: dir, AllowOverrides: true})
+	if err != nil {
+		t.Fatalf("Expected override to be allowed: %v", err)
+	}
+
+	service, err := registry.GetService("sonarr")
+	if err != nil {
+		t.Fatalf("Expected sonarr to exist: %v", err)
+	}
+	if service.ContainerName != "sonarr-custom" {
+		t.Errorf("Expected overridden sonarr, got container %s", service.ContainerName)
+	}
+	builtins, err := NewRegistry()
+	if err != nil {
+		t.Fatalf("Failed to create registry: %v", err)
+	}
+	if registry.GetServiceCount() != builtins.GetServiceCount() {
+		t.Errorf("Override should replace, not add; got %d services", registry.GetServiceCount())
+	}
+
+	count := 0
+	for _, candidate := range registry.GetServicesByCategory(CategoryMedia) {
+		if candidate.ID == "sonarr" {
+			count++
+		}
+	}
+	if count != 1 {
+		t.Errorf("Expected sonarr once in its category, got %d", count)
+	}
+}
+
+func TestNewRegistryWithOptions_InvalidDefinitions(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"Unknown key", fmt.Sprintf(userServiceYAML, "custom", "custom") + "unknown_key: true\n"},
+		{"Invalid category", strings.Replace(fmt.Sprintf(userServiceYAML, "custom", "custom"), "category: media", "category: games", 1)},
+		{"Missing image", strings.Replace(fmt.Sprintf(userServiceYAML, "custom", "custom"), "image: example/custom:latest\n", "", 1)},
+		{"Invalid ID", fmt.Sprintf(userServiceYAML, "Custom Service", "custom")},
+		{"Malformed YAML", "id: [custom"},
+		{"Unknown dependency", strings.Replace(fmt.Sprintf(userServiceYAML, "custom", "custom"), "  - qbittorrent", "  - qbitorrent", 1)},
+		{"Invalid protocol", strings.Replace(fmt.Sprintf(userServiceYAML, "custom", "custom"), "protocol: tcp", "protocol: sctp", 1)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeUserService(t, dir, "custom.yml", tt.content)
+
+			if _, err := NewRegistryWithOptions(RegistryOptions{UserServicesDir: dir}); err == nil {
+				t.Error("Expected invalid definition to be rejected")
+			}
+		})
+	}
+}
+
+func TestNewRegistryWithOptions_DependencyCycle(t *testing.T) {
+	dir := t.TempDir()
+	writeUserService(t, dir, "custom.yaml", strings.Replace(fmt.Sprintf(userServiceYAML, "custom", "custom"), "  - qbittorrent", "  - other", 1))
+	writeUserService(t, dir, "other.yaml", strings.Replace(strings.Replace(fmt.Sprintf(userServiceYAML, "other", "other"),
+		"  - qbittorrent", "  - custom", 1), `"9999"`, `"9998"`, 3))
+
+	_, err := NewRegistryWithOptions(RegistryOptions{UserServicesDir: dir})
+	if err == nil {
+		t.Fatal("Expected dependency cycle to be rejected")
+	}
+	if !strings.Contains(err.Error(), "dependency cycle") {
+		t.Errorf("Expected lint issue in error, got %v", err)
+	}
+}
+
+func TestNewRegistryWithOptions_HostPorts(t *testing.T) {
+	t.Run("Shared with a built-in service", func(t *testing.T) {
+		dir := t.TempDir()
+		writeUserService(t, dir, "custom.yaml", strings.ReplaceAll(fmt.Sprintf(userServiceYAML, "custom", "custom"), `"9999"`, `"8989"`))
+
+		registry, err := NewRegistryWithOptions(RegistryOptions{UserServicesDir: dir})
+		if err != nil {
+			t.Fatalf("Expected a port shared with a built-in service to load, got %v", err)
+		}
+		if issues := registry.Validate(); len(issues) == 0 {
+			t.Error("Expected Validate to still report the shared host port")
+		}
+	})
+
+	t.Run("Shared between user services", func(t *testing.T) {
+		dir := t.TempDir()
+		writeUserService(t, dir, "custom.yaml", fmt.Sprintf(userServiceYAML, "custom", "custom"))
+		writeUserService(t, dir, "other.yaml", fmt.Sprintf(userServiceYAML, "other", "other"))
+
+		_, err := NewRegistryWithOptions(RegistryOptions{UserServicesDir: dir})
+		if err == nil {
+			t.Fatal("Expected a host port shared between user services to be rejected")
+		}
+		if !strings.Contains(err.Error(), "host port 9999/tcp") {
+			t.Errorf("Expected lint issue in error, got %v", err)
+		}
+	})
+}
+
+func TestNewRegistryWithOptions_DuplicateUserServices(t *testing.T) {
+	dir := t.TempDir()
+	writeUserService(t, dir, "a.yaml", fmt.Sprintf(userServiceYAML, "custom", "custom"))
+	writeUserService(t, dir, "b.yaml", fmt.Sprintf(userServiceYAML, "custom", "custom"))
+
+	if _, err := NewRegistryWithOptions(RegistryOptions{UserServicesDir: dir, AllowOverrides: true}); err == nil {
+		t.Error("Expected duplicate user service IDs to be rejected")
+	}
+}
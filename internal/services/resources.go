@@ -0,0 +1,154 @@
+package services
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+// byteSizePattern matches Docker byte sizes such as 512m, 1.5g or 10mb
+var byteSizePattern = regexp.MustCompile(`(?i)^[0-9]+(\.[0-9]+)?([bkmg]|[kmg]b)?$`)
+
+// LoggingConfig selects the container log driver and its rotation
+type LoggingConfig struct {
+	Driver  string `json:"driver,omitempty" yaml:"driver,omitempty"`
+	MaxSize string `json:"max_size,omitempty" yaml:"max_size,omitempty"`
+	MaxFile string `json:"max_file,omitempty" yaml:"max_file,omitempty"`
+}
+
+// ResourceConfig limits the memory and CPU of a container and configures its
+// logs. Empty fields leave the runtime defaults in place.
+type ResourceConfig struct {
+	MemLimit string         `json:"mem_limit,omitempty" yaml:"mem_limit,omitempty"`
+	CPUs     string         `json:"cpus,omitempty" yaml:"cpus,omitempty"`
+	Logging  *LoggingConfig `json:"logging,omitempty" yaml:"logging,omitempty"`
+}
+
+// ResourceSettings holds the resource configuration of a profile: Defaults
+// fill the fields a service definition leaves unset, and Services overrides
+// them per service ID.
+type ResourceSettings struct {
+	Defaults ResourceConfig            `json:"defaults,omitempty" yaml:"defaults,omitempty"`
+	Services map[string]ResourceConfig `json:"services,omitempty" yaml:"services,omitempty"`
+}
+
+// IsZero reports whether no field is set
+func (c ResourceConfig) IsZero() bool {
+	return c.MemLimit == "" && c.CPUs == "" && (c.Logging == nil || *c.Logging == LoggingConfig{})
+}
+
+// Merge returns c with the fields set in override replacing its own
+func (c ResourceConfig) Merge(override ResourceConfig) ResourceConfig {
+	merged := c
+	if override.MemLimit != "" {
+		merged.MemLimit = override.MemLimit
+	}
+	if override.CPUs != "" {
+		merged.CPUs = override.CPUs
+	}
+	if override.Logging != nil {
+		logging := LoggingConfig{}
+		if c.Logging != nil {
+			logging = *c.Logging
+		}
+		if override.Logging.Driver != "" {
+			logging.Driver = override.Logging.Driver
+		}
+		if override.Logging.MaxSize != "" {
+			logging.MaxSize = override.Logging.MaxSize
+		}
+		if override.Logging.MaxFile != "" {
+			logging.MaxFile = override.Logging.MaxFile
+		}
+		merged.Logging = &logging
+	}
+	return merged
+}
+
+// Validate checks the value formats accepted by Docker and Podman
+func (c ResourceConfig) Validate() error {
+	var first error
+	c.check(func(field, format string, args ...interface{}) {
+		if first == nil {
+			first = fmt.Errorf("%s %s", field, fmt.Sprintf(format, args...))
+		}
+	})
+	return first
+}
+
+// check reports every invalid field, as used by the service linter
+func (c ResourceConfig) check(report func(field, format string, args ...interface{})) {
+	if c.MemLimit != "" && !byteSizePattern.MatchString(c.MemLimit) {
+		report("mem_limit", "must be a size such as 512m or 2g (got %q)", c.MemLimit)
+	}
+	if c.CPUs != "" {
+		cpus, err := strconv.ParseFloat(c.CPUs, 64)
+		if err != nil || cpus <= 0 {
+			report("cpus", "must be a positive number such as 1.5 (got %q)", c.CPUs)
+		}
+	}
+	if c.Logging == nil {
+		return
+	}
+	if c.Logging.MaxSize != "" && !byteSizePattern.MatchString(c.Logging.MaxSize) {
+		report("logging.max_size", "must be a size such as 10m (got %q)", c.Logging.MaxSize)
+	}
+	if c.Logging.MaxFile != "" {
+		files, err := strconv.Atoi(c.Logging.MaxFile)
+		if err != nil || files < 1 {
+			report("logging.max_file", "must be a positive number (got %q)", c.Logging.MaxFile)
+		}
+	}
+}
+
+// Validate checks the defaults and that every override targets a known service
+func (s *ResourceSettings) Validate(registry *Registry) error {
+	if s == nil {
+		return nil
+	}
+	if err := s.Defaults.Validate(); err != nil {
+		return fmt.Errorf("resource defaults: %w", err)
+	}
+	ids := make([]string, 0, len(s.Services))
+	for id := range s.Services {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		if _, err := registry.GetService(id); err != nil {
+			return fmt.Errorf("resources for %s: %w", id, err)
+		}
+		if err := s.Services[id].Validate(); err != nil {
+			return fmt.Errorf("resources for %s: %w", id, err)
+		}
+	}
+	return nil
+}
+
+// Apply returns the service with the profile resources merged into its
+// definition. The registry definition is never modified.
+func (s *ResourceSettings) Apply(svc *Service) *Service {
+	if s == nil || svc == nil {
+		return svc
+	}
+	override := s.Services[svc.ID]
+	if s.Defaults.IsZero() && override.IsZero() {
+		return svc
+	}
+	clone := svc.Clone()
+	clone.Resources = s.Defaults.Merge(svc.Resources).Merge(override)
+	return clone
+}
+
+// ApplyAll applies the profile resources to every service
+func (s *ResourceSettings) ApplyAll(selected []*Service) []*Service {
+	if s == nil {
+		return selected
+	}
+	adjusted := make([]*Service, len(selected))
+	for i, svc := range selected {
+		adjusted[i] = s.Apply(svc)
+	}
+	return adjusted
+}
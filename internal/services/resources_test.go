@@ -0,0 +1,79 @@
+package services
+
+import "testing"
+
+func TestResourceSettings_Apply(t *testing.T) {
+	jellyfin := &Service{
+		ID:        "jellyfin",
+		Resources: ResourceConfig{MemLimit: "2g", Logging: &LoggingConfig{MaxSize: "50m"}},
+	}
+	sonarr := &Service{ID: "sonarr"}
+	settings := &ResourceSettings{
+		Defaults: ResourceConfig{
+			MemLimit: "512m",
+			Logging:  &LoggingConfig{Driver: "json-file", MaxSize: "10m", MaxFile: "3"},
+		},
+		Services: map[string]ResourceConfig{
+			"jellyfin": {CPUs: "2"},
+			"sonarr":   {MemLimit: "1g", Logging: &LoggingConfig{MaxFile: "5"}},
+		},
+	}
+
+	// Definition values win over profile defaults, overrides win over both
+	adjusted := settings.Apply(jellyfin)
+	if adjusted.Resources.MemLimit != "2g" || adjusted.Resources.CPUs != "2" {
+		t.Errorf("Expected definition memory and override CPUs, got %+v", adjusted.Resources)
+	}
+	if logging := adjusted.Resources.Logging; logging.Driver != "json-file" || logging.MaxSize != "50m" || logging.MaxFile != "3" {
+		t.Errorf("Expected logging merged field by field, got %+v", logging)
+	}
+
+	adjusted = settings.Apply(sonarr)
+	if adjusted.Resources.MemLimit != "1g" || adjusted.Resources.Logging.MaxFile != "5" || adjusted.Resources.Logging.MaxSize != "10m" {
+		t.Errorf("Expected the Sonarr override on top of the defaults, got %+v %+v", adjusted.Resources, adjusted.Resources.Logging)
+	}
+
+	if jellyfin.Resources.CPUs != "" || jellyfin.Resources.Logging.Driver != "" {
+		t.Error("Expected the definition to stay unchanged")
+	}
+	var none *ResourceSettings
+	if none.Apply(sonarr) != sonarr {
+		t.Error("Expected nil settings to leave the service unchanged")
+	}
+}
+
+func TestResourceConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      ResourceConfig
+		expectError bool
+	}{
+		{name: "Empty", config: ResourceConfig{}},
+		{name: "Valid", config: ResourceConfig{MemLimit: "1.5g", CPUs: "0.5", Logging: &LoggingConfig{Driver: "json-file", MaxSize: "10mb", MaxFile: "3"}}},
+		{name: "Memory without number", config: ResourceConfig{MemLimit: "lots"}, expectError: true},
+		{name: "Memory with unknown unit", config: ResourceConfig{MemLimit: "2t"}, expectError: true},
+		{name: "Zero CPUs", config: ResourceConfig{CPUs: "0"}, expectError: true},
+		{name: "Invalid max size", config: ResourceConfig{Logging: &LoggingConfig{MaxSize: "ten"}}, expectError: true},
+		{name: "Invalid max file", config: ResourceConfig{Logging: &LoggingConfig{MaxFile: "0"}}, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate()
+			if (err != nil) != tt.expectError {
+				t.Errorf("Expected error=%v, got %v", tt.expectError, err)
+			}
+		})
+	}
+}
+
+func TestResourceSettings_ValidateUnknownService(t *testing.T) {
+	registry, err := NewRegistry()
+	if err != nil {
+		t.Fatalf("Failed to create registry: %v", err)
+	}
+	settings := &ResourceSettings{Services: map[string]ResourceConfig{"jellyfn": {MemLimit: "1g"}}}
+	if err := settings.Validate(registry); err == nil {
+		t.Error("Expected an unknown service to be rejected")
+	}
+}
@@ -1,5 +1,10 @@
 package services
 
+import (
+	"fmt"
+	"strings"
+)
+
 // PortMapping represents a port mapping between host and container
 type PortMapping struct {
 	Host      string `yaml:"host"`
@@ -23,6 +28,22 @@ type NetworkConfig struct {
 // WebUIConfig describes the local administration interface exposed by a service.
 type WebUIConfig struct {
 	Port string `yaml:"port"`
+	// URLBaseEnv names the environment variable that moves the interface
+	// below a path prefix, used by path-based reverse proxy routes.
+	URLBaseEnv string `yaml:"url_base_env,omitempty"`
+}
+
+// HealthcheckConfig describes how a container reports that it is healthy.
+// Path probes http://localhost:<port><path> with curl, where the port defaults
+// to the web interface; Test replaces the probe for images without curl.
+type HealthcheckConfig struct {
+	Path        string   `yaml:"path,omitempty"`
+	Port        string   `yaml:"port,omitempty"`
+	Test        []string `yaml:"test,omitempty"`
+	Interval    string   `yaml:"interval,omitempty"`
+	Timeout     string   `yaml:"timeout,omitempty"`
+	Retries     int      `yaml:"retries,omitempty"`
+	StartPeriod string   `yaml:"start_period,omitempty"`
 }
 
 // VPNModeConfig represents network configuration for VPN mode
@@ -38,25 +59,36 @@ type BridgeModeConfig struct {
 
 // Service represents a Docker service configuration
 type Service struct {
-	ID            string          `yaml:"id"`
-	Name          string          `yaml:"name"`
-	Category      ServiceCategory `yaml:"category"`
-	Description   string          `yaml:"description"`
-	Image         string          `yaml:"image"`
-	ContainerName string          `yaml:"container_name"`
-	Init          bool            `yaml:"init,omitempty"`
-	Ports         []PortMapping   `yaml:"ports,omitempty"`
-	Volumes       []VolumeMapping `yaml:"volumes"`
-	Environment   []string        `yaml:"environment,omitempty"`
-	Devices       []string        `yaml:"devices,omitempty"`
-	CapAdd        []string        `yaml:"cap_add,omitempty"`
-	Network       NetworkConfig   `yaml:"network"`
-	Restart       string          `yaml:"restart"`
-	SupportsVPN   bool            `yaml:"supports_vpn"`
-	RequiresVPN   bool            `yaml:"requires_vpn"`
-	Dependencies  []string        `yaml:"dependencies,omitempty"`
-	Optional      bool            `yaml:"optional"`
-	WebUI         *WebUIConfig    `yaml:"web_ui,omitempty"`
+	ID            string             `yaml:"id"`
+	Name          string             `yaml:"name"`
+	Category      ServiceCategory    `yaml:"category"`
+	Description   string             `yaml:"description"`
+	Image         string             `yaml:"image"`
+	ContainerName string             `yaml:"container_name"`
+	Init          bool               `yaml:"init,omitempty"`
+	Ports         []PortMapping      `yaml:"ports,omitempty"`
+	Volumes       []VolumeMapping    `yaml:"volumes"`
+	Environment   []string           `yaml:"environment,omitempty"`
+	Devices       []string           `yaml:"devices,omitempty"`
+	GroupAdd      []string           `yaml:"group_add,omitempty"`
+	GPU           *GPUConfig         `yaml:"gpu,omitempty"`
+	CapAdd        []string           `yaml:"cap_add,omitempty"`
+	Network       NetworkConfig      `yaml:"network"`
+	Restart       string             `yaml:"restart"`
+	Healthcheck   *HealthcheckConfig `yaml:"healthcheck,omitempty"`
+	// Resources sets mem_limit, cpus and logging for the container
+	Resources    ResourceConfig `yaml:",inline"`
+	SupportsVPN  bool           `yaml:"supports_vpn"`
+	RequiresVPN  bool           `yaml:"requires_vpn"`
+	Dependencies []string       `yaml:"dependencies,omitempty"`
+	Optional     bool           `yaml:"optional"`
+	WebUI        *WebUIConfig   `yaml:"web_ui,omitempty"`
+	// HardwareAcceleration marks services that transcode and receive the
+	// devices selected with --hwaccel
+	HardwareAcceleration bool `yaml:"hardware_acceleration,omitempty"`
+	// Secrets are the Compose secrets mounted by the generator in secrets
+	// mode; definitions reference .env variables instead
+	Secrets []string `yaml:"-"`
 }
 
 // GetTranslationKey returns the i18n key for the service
@@ -86,3 +118,66 @@ func (s *Service) IsCompatibleWithVPN(vpnEnabled bool) bool {
 func (s *Service) HasDependencies() bool {
 	return len(s.Dependencies) > 0
 }
+
+// Clone returns a deep copy that generators can adjust without changing the
+// definition held by the registry
+func (s *Service) Clone() *Service {
+	clone := *s
+	clone.Ports = append([]PortMapping(nil), s.Ports...)
+	clone.Volumes = append([]VolumeMapping(nil), s.Volumes...)
+	clone.Environment = append([]string(nil), s.Environment...)
+	clone.Devices = append([]string(nil), s.Devices...)
+	clone.GroupAdd = append([]string(nil), s.GroupAdd...)
+	clone.CapAdd = append([]string(nil), s.CapAdd...)
+	clone.Network.BridgeMode.Networks = append([]string(nil), s.Network.BridgeMode.Networks...)
+	clone.Dependencies = append([]string(nil), s.Dependencies...)
+	clone.Secrets = append([]string(nil), s.Secrets...)
+	if s.WebUI != nil {
+		webUI := *s.WebUI
+		clone.WebUI = &webUI
+	}
+	if s.Resources.Logging != nil {
+		logging := *s.Resources.Logging
+		clone.Resources.Logging = &logging
+	}
+	if s.GPU != nil {
+		gpu := *s.GPU
+		gpu.Capabilities = append([]string(nil), s.GPU.Capabilities...)
+		clone.GPU = &gpu
+	}
+	if s.Healthcheck != nil {
+		healthcheck := *s.Healthcheck
+		healthcheck.Test = append([]string(nil), s.Healthcheck.Test...)
+		clone.Healthcheck = &healthcheck
+	}
+	return &clone
+}
+
+// HealthcheckTest returns the container health command in compose test
+// syntax, or nil when the service has no healthcheck. HTTP probes follow the
+// URL base set through the web interface's URLBaseEnv.
+func (s *Service) HealthcheckTest() []string {
+	if s.Healthcheck == nil {
+		return nil
+	}
+	if len(s.Healthcheck.Test) > 0 {
+		return append([]string(nil), s.Healthcheck.Test...)
+	}
+	if s.Healthcheck.Path == "" {
+		return nil
+	}
+
+	port := s.Healthcheck.Port
+	if port == "" {
+		port = s.mainContainerPort()
+	}
+	path := s.Healthcheck.Path
+	if s.WebUI != nil && s.WebUI.URLBaseEnv != "" {
+		for _, entry := range s.Environment {
+			if base, found := strings.CutPrefix(entry, s.WebUI.URLBaseEnv+"="); found && base != "" {
+				path = strings.TrimSuffix(base, "/") + path
+			}
+		}
+	}
+	return []string{"CMD-SHELL", fmt.Sprintf("curl -fsS -o /dev/null http://localhost:%s%s || exit 1", port, path)}
+}
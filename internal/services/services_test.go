@@ -1,6 +1,7 @@
 package services
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -144,3 +145,63 @@ func TestService_HasDependencies(t *testing.T) {
 		})
 	}
 }
+
+func TestService_HealthcheckTest(t *testing.T) {
+	tests := []struct {
+		name     string
+		service  *Service
+		expected []string
+	}{
+		{
+			name:     "No healthcheck",
+			service:  &Service{ID: "custom"},
+			expected: nil,
+		},
+		{
+			name: "Path probe on the web interface port",
+			service: &Service{
+				ID:          "sonarr",
+				Ports:       []PortMapping{{Host: "18989", Container: "8989"}},
+				WebUI:       &WebUIConfig{Port: "18989", URLBaseEnv: "SONARR__SERVER__URLBASE"},
+				Healthcheck: &HealthcheckConfig{Path: "/ping"},
+			},
+			expected: []string{"CMD-SHELL", "curl -fsS -o /dev/null http://localhost:8989/ping || exit 1"},
+		},
+		{
+			name: "Path probe behind a URL base",
+			service: &Service{
+				ID:          "sonarr",
+				Ports:       []PortMapping{{Host: "8989", Container: "8989"}},
+				Environment: []string{"SONARR__SERVER__URLBASE=/sonarr"},
+				WebUI:       &WebUIConfig{Port: "8989", URLBaseEnv: "SONARR__SERVER__URLBASE"},
+				Healthcheck: &HealthcheckConfig{Path: "/ping"},
+			},
+			expected: []string{"CMD-SHELL", "curl -fsS -o /dev/null http://localhost:8989/sonarr/ping || exit 1"},
+		},
+		{
+			name: "Explicit port",
+			service: &Service{
+				ID:          "caddy",
+				Healthcheck: &HealthcheckConfig{Path: "/config/", Port: "2019"},
+			},
+			expected: []string{"CMD-SHELL", "curl -fsS -o /dev/null http://localhost:2019/config/ || exit 1"},
+		},
+		{
+			name: "Explicit test",
+			service: &Service{
+				ID:          "gluetun",
+				Healthcheck: &HealthcheckConfig{Test: []string{"CMD", "/gluetun-entrypoint", "healthcheck"}},
+			},
+			expected: []string{"CMD", "/gluetun-entrypoint", "healthcheck"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := tt.service.HealthcheckTest()
+			if strings.Join(result, "|") != strings.Join(tt.expected, "|") {
+				t.Errorf("Expected %q, got %q", tt.expected, result)
+			}
+		})
+	}
+}
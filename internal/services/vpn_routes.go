@@ -0,0 +1,163 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// How a service is connected in VPN mode
+const (
+	// RouteTunnel shares Gluetun's network namespace
+	RouteTunnel = "tunnel"
+	// RouteBridge joins the bridge networks of the service definition
+	RouteBridge = "bridge"
+)
+
+// vpnRouteDefault is the VPNRoutes key for services no other key matches
+const vpnRouteDefault = "*"
+
+// VPNRoutes chooses in VPN mode whether services share Gluetun's network
+// namespace or join their bridge networks, without editing service
+// definitions. Keys are a service ID, a category such as "media", or "*" for
+// every other service; a service ID wins over its category. Services nothing
+// matches follow their definition.
+type VPNRoutes map[string]string
+
+// ParseVPNRoute parses a "media=bridge" or "qbittorrent=tunnel" flag value
+// into a route key and route
+func ParseVPNRoute(spec string) (string, string, error) {
+	key, route, found := strings.Cut(strings.TrimSpace(spec), "=")
+	key = strings.TrimSpace(key)
+	route = strings.ToLower(strings.TrimSpace(route))
+	if !found || key == "" || route == "" {
+		return "", "", fmt.Errorf("invalid VPN route %q (use service=%s or category=%s)", spec, RouteTunnel, RouteBridge)
+	}
+	if err := validateRoute(route); err != nil {
+		return "", "", fmt.Errorf("invalid VPN route %q: %w", spec, err)
+	}
+	return key, route, nil
+}
+
+// ParseVPNRoutes parses repeated --vpn-route flag values. Each value may list
+// several comma-separated routes.
+func ParseVPNRoutes(specs []string) (VPNRoutes, error) {
+	routes := make(VPNRoutes, len(specs))
+	for _, spec := range specs {
+		for _, part := range strings.Split(spec, ",") {
+			if strings.TrimSpace(part) == "" {
+				continue
+			}
+			key, route, err := ParseVPNRoute(part)
+			if err != nil {
+				return nil, err
+			}
+			routes[key] = route
+		}
+	}
+	return routes, nil
+}
+
+// Validate checks that every key names a known service or category and that
+// no service requiring the VPN is sent around it
+func (r VPNRoutes) Validate(registry *Registry) error {
+	keys := make([]string, 0, len(r))
+	for key := range r {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		route := r[key]
+		if err := validateRoute(route); err != nil {
+			return fmt.Errorf("VPN route %s: %w", key, err)
+		}
+		if key == vpnRouteDefault || ServiceCategory(key).IsValid() {
+			if ServiceCategory(key) == CategoryVPN {
+				return fmt.Errorf("VPN route %s: Gluetun itself cannot be routed", key)
+			}
+			continue
+		}
+		svc, err := registry.GetService(key)
+		if err != nil {
+			return fmt.Errorf("VPN route %s: not a service or category: %w", key, err)
+		}
+		if svc.Category == CategoryVPN {
+			return fmt.Errorf("VPN route %s: Gluetun itself cannot be routed", key)
+		}
+		if route == RouteBridge && svc.RequiresVPN {
+			return fmt.Errorf("VPN route %s: service %s requires the VPN", key, svc.ID)
+		}
+		if route == RouteBridge && len(svc.Network.BridgeMode.Networks) == 0 {
+			return fmt.Errorf("VPN route %s: service %s has no bridge network configuration", key, svc.ID)
+		}
+	}
+	return nil
+}
+
+// Route returns the route of a service: the route of its ID, of its
+// category or the default route, and otherwise its definition. Services that
+// require the VPN or have no bridge network always use the tunnel.
+func (r VPNRoutes) Route(svc *Service) string {
+	definition := RouteTunnel
+	if svc.Network.VPNMode.NetworkMode == RouteBridge {
+		definition = RouteBridge
+	}
+	if svc.Category == CategoryVPN {
+		return definition
+	}
+	route, ok := r[svc.ID]
+	if !ok {
+		route, ok = r[string(svc.Category)]
+	}
+	if !ok {
+		route, ok = r[vpnRouteDefault]
+	}
+	if !ok {
+		return definition
+	}
+	if route == RouteBridge && (svc.RequiresVPN || len(svc.Network.BridgeMode.Networks) == 0) {
+		return RouteTunnel
+	}
+	return route
+}
+
+// Apply returns the service with its VPN mode network set to the chosen
+// route. The registry definition is never modified; a copy is returned when
+// the route changes.
+func (r VPNRoutes) Apply(svc *Service) *Service {
+	if len(r) == 0 || svc == nil {
+		return svc
+	}
+	route := r.Route(svc)
+	bridged := svc.Network.VPNMode.NetworkMode == RouteBridge
+	if (route == RouteBridge) == bridged {
+		return svc
+	}
+	clone := svc.Clone()
+	if route == RouteBridge {
+		clone.Network.VPNMode.NetworkMode = RouteBridge
+	} else {
+		clone.Network.VPNMode.NetworkMode = "service:gluetun"
+	}
+	return clone
+}
+
+// ApplyAll applies the routes to every service
+func (r VPNRoutes) ApplyAll(selected []*Service) []*Service {
+	if len(r) == 0 {
+		return selected
+	}
+	adjusted := make([]*Service, len(selected))
+	for i, svc := range selected {
+		adjusted[i] = r.Apply(svc)
+	}
+	return adjusted
+}
+
+func validateRoute(route string) error {
+	if route != RouteTunnel && route != RouteBridge {
+		return fmt.Errorf("route must be %s or %s, got %q", RouteTunnel, RouteBridge, route)
+	}
+	return nil
+}
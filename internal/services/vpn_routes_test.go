@@ -0,0 +1,102 @@
+package services
+
+import "testing"
+
+func TestParseVPNRoutes(t *testing.T) {
+	routes, err := ParseVPNRoutes([]string{"streaming=bridge", " jellyseerr = Bridge ,qbittorrent=tunnel"})
+	if err != nil {
+		t.Fatalf("Failed to parse routes: %v", err)
+	}
+	want := VPNRoutes{"streaming": RouteBridge, "jellyseerr": RouteBridge, "qbittorrent": RouteTunnel}
+	if len(routes) != len(want) {
+		t.Fatalf("Routes = %v, want %v", routes, want)
+	}
+	for key, route := range want {
+		if routes[key] != route {
+			t.Errorf("Routes[%s] = %q, want %q", key, routes[key], route)
+		}
+	}
+
+	for _, spec := range []string{"jellyfin", "jellyfin=", "=bridge", "jellyfin=lan"} {
+		if _, _, err := ParseVPNRoute(spec); err == nil {
+			t.Errorf("Expected %q to be rejected", spec)
+		}
+	}
+}
+
+func TestVPNRoutes_Route(t *testing.T) {
+	registry, err := NewRegistry()
+	if err != nil {
+		t.Fatalf("Failed to create registry: %v", err)
+	}
+	jellyfin, _ := registry.GetService("jellyfin")
+	jellyseerr, _ := registry.GetService("jellyseerr")
+	qbittorrent, _ := registry.GetService("qbittorrent")
+	sonarr, _ := registry.GetService("sonarr")
+	gluetun, _ := registry.GetService("gluetun")
+
+	routes := VPNRoutes{"*": RouteBridge, "streaming": RouteBridge, "download": RouteTunnel, "radarr": RouteTunnel}
+	tests := []struct {
+		service *Service
+		want    string
+	}{
+		{jellyfin, RouteBridge},
+		{jellyseerr, RouteBridge},
+		{qbittorrent, RouteTunnel},
+		{sonarr, RouteBridge},
+		{gluetun, RouteBridge},
+	}
+	for _, tt := range tests {
+		if got := routes.Route(tt.service); got != tt.want {
+			t.Errorf("Route(%s) = %q, want %q", tt.service.ID, got, tt.want)
+		}
+	}
+	if got := (VPNRoutes{"streaming": RouteBridge, "jellyfin": RouteTunnel}).Route(jellyfin); got != RouteTunnel {
+		t.Errorf("Expected the service ID to win over its category, got %q", got)
+	}
+	if got := VPNRoutes(nil).Route(sonarr); got != RouteTunnel {
+		t.Errorf("Expected services without routes to follow their definition, got %q", got)
+	}
+}
+
+func TestVPNRoutes_Apply(t *testing.T) {
+	registry, err := NewRegistry()
+	if err != nil {
+		t.Fatalf("Failed to create registry: %v", err)
+	}
+	jellyfin, _ := registry.GetService("jellyfin")
+	sonarr, _ := registry.GetService("sonarr")
+
+	routes := VPNRoutes{"streaming": RouteBridge}
+	routed := routes.Apply(jellyfin)
+	if routed.Network.VPNMode.NetworkMode != RouteBridge {
+		t.Errorf("Expected Jellyfin on the bridge network, got %q", routed.Network.VPNMode.NetworkMode)
+	}
+	if jellyfin.Network.VPNMode.NetworkMode != "service:gluetun" {
+		t.Error("Expected the registry definition to stay untouched")
+	}
+	if routes.Apply(sonarr) != sonarr {
+		t.Error("Expected services keeping their route to be returned as is")
+	}
+}
+
+func TestVPNRoutes_Validate(t *testing.T) {
+	registry, err := NewRegistry()
+	if err != nil {
+		t.Fatalf("Failed to create registry: %v", err)
+	}
+
+	if err := (VPNRoutes{"*": RouteTunnel, "streaming": RouteBridge, "jellyseerr": RouteBridge}).Validate(registry); err != nil {
+		t.Errorf("Expected valid routes: %v", err)
+	}
+	for _, routes := range []VPNRoutes{
+		{"unknown": RouteBridge},
+		{"jellyfin": "lan"},
+		{"gluetun": RouteBridge},
+		{"vpn": RouteTunnel},
+	} {
+		if err := routes.Validate(registry); err == nil {
+			t.Errorf("Expected %v to be rejected", routes)
+		}
+	}
+}
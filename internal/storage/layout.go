@@ -57,6 +57,7 @@ func (p *LayoutPreparer) Prepare(basePath string, applicationIDs []string) (Layo
 		filepath.Join("media", "downloads", "complete", "lidarr"),
 		filepath.Join("media", "downloads", "complete", "lazylibrarian"),
 		filepath.Join("media", "downloads", "complete", "radarr"),
+		filepath.Join("media", "downloads", "complete", "readarr"),
 		filepath.Join("media", "downloads", "complete", "sonarr"),
 		filepath.Join("media", "library", "movies"),
 		filepath.Join("media", "library", "tv"),
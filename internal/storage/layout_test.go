@@ -35,6 +35,7 @@ func TestLayoutPreparerCreatesIdempotentCorsarrTree(t *testing.T) {
 		"media/downloads/complete/lidarr",
 		"media/downloads/complete/lazylibrarian",
 		"media/downloads/complete/radarr",
+		"media/downloads/complete/readarr",
 		"media/downloads/complete/sonarr",
 		"media/library/movies",
 		"media/library/tv",
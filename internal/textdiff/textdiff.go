@@ -0,0 +1,139 @@
+// Package textdiff renders line-based unified diffs for files Corsarr rewrites.
+package textdiff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// contextLines is the number of unchanged lines shown around each change
+const contextLines = 3
+
+type opKind int
+
+const (
+	opEqual opKind = iota
+	opDelete
+	opInsert
+)
+
+type op struct {
+	kind    opKind
+	oldLine int
+	newLine int
+	text    string
+}
+
+// Unified returns a unified diff between before and after, or an empty string
+// when they are equal. Names label the --- and +++ header lines.
+func Unified(oldName, newName, before, after string) string {
+	if before == after {
+		return ""
+	}
+	ops := diffLines(splitLines(before), splitLines(after))
+
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "--- %s\n+++ %s\n", oldName, newName)
+	for _, hunk := range hunks(ops) {
+		writeHunk(&builder, ops[hunk[0]:hunk[1]])
+	}
+	return builder.String()
+}
+
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+// diffLines computes a line edit script from the longest common subsequence.
+// Generated files are small, so the quadratic table is acceptable.
+func diffLines(before, after []string) []op {
+	lcs := make([][]int, len(before)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(after)+1)
+	}
+	for i := len(before) - 1; i >= 0; i-- {
+		for j := len(after) - 1; j >= 0; j-- {
+			if before[i] == after[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var ops []op
+	i, j := 0, 0
+	for i < len(before) || j < len(after) {
+		switch {
+		case i < len(before) && j < len(after) && before[i] == after[j]:
+			ops = append(ops, op{kind: opEqual, oldLine: i, newLine: j, text: before[i]})
+			i++
+			j++
+		case j < len(after) && (i == len(before) || lcs[i][j+1] > lcs[i+1][j]):
+			ops = append(ops, op{kind: opInsert, oldLine: i, newLine: j, text: after[j]})
+			j++
+		default:
+			ops = append(ops, op{kind: opDelete, oldLine: i, newLine: j, text: before[i]})
+			i++
+		}
+	}
+	return ops
+}
+
+// hunks groups changes with their surrounding context, returning index ranges
+// into ops
+func hunks(ops []op) [][2]int {
+	var ranges [][2]int
+	for i, current := range ops {
+		if current.kind == opEqual {
+			continue
+		}
+		start := max(i-contextLines, 0)
+		end := min(i+contextLines+1, len(ops))
+		if len(ranges) > 0 && start <= ranges[len(ranges)-1][1] {
+			ranges[len(ranges)-1][1] = end
+			continue
+		}
+		ranges = append(ranges, [2]int{start, end})
+	}
+	return ranges
+}
+
+func writeHunk(builder *strings.Builder, ops []op) {
+	oldCount, newCount := 0, 0
+	for _, current := range ops {
+		if current.kind != opInsert {
+			oldCount++
+		}
+		if current.kind != opDelete {
+			newCount++
+		}
+	}
+	fmt.Fprintf(builder, "@@ -%s +%s @@\n",
+		hunkRange(ops[0].oldLine, oldCount), hunkRange(ops[0].newLine, newCount))
+
+	for _, current := range ops {
+		prefix := " "
+		switch current.kind {
+		case opDelete:
+			prefix = "-"
+		case opInsert:
+			prefix = "+"
+		}
+		builder.WriteString(prefix + current.text + "\n")
+	}
+}
+
+// hunkRange formats a 0-based start line and count the way diff -u does
+func hunkRange(start, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
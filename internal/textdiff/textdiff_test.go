@@ -0,0 +1,38 @@
+package textdiff
+
+import "testing"
+
+func TestUnifiedEqual(t *testing.T) {
+	if diff := Unified("a", "b", "same\n", "same\n"); diff != "" {
+		t.Fatalf("Unified() = %q, want empty diff", diff)
+	}
+}
+
+func TestUnifiedSingleChange(t *testing.T) {
+	before := "services:\n  sonarr:\n    ports:\n      - \"8989:8989\"\n    restart: unless-stopped\n"
+	after := "services:\n  sonarr:\n    ports:\n      - \"8990:8989\"\n    restart: unless-stopped\n"
+
+	want := "--- docker-compose.yml\n+++ docker-compose.yml\n" +
+		"@@ -1,5 +1,5 @@\n" +
+		" services:\n" +
+		"   sonarr:\n" +
+		"     ports:\n" +
+		"-      - \"8989:8989\"\n" +
+		"+      - \"8990:8989\"\n" +
+		"     restart: unless-stopped\n"
+	if got := Unified("docker-compose.yml", "docker-compose.yml", before, after); got != want {
+		t.Fatalf("Unified() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestUnifiedSeparateHunks(t *testing.T) {
+	before := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\n"
+	after := "A\nb\nc\nd\ne\nf\ng\nh\ni\nj\nk\nL\n"
+
+	want := "--- old\n+++ new\n" +
+		"@@ -1,4 +1,4 @@\n-a\n+A\n b\n c\n d\n" +
+		"@@ -9,4 +9,4 @@\n i\n j\n k\n-l\n+L\n"
+	if got := Unified("old", "new", before, after); got != want {
+		t.Fatalf("Unified() =\n%s\nwant\n%s", got, want)
+	}
+}
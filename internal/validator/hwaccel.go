@@ -0,0 +1,85 @@
+package validator
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/woliveiras/corsarr/internal/services"
+)
+
+// nvidiaDevice is created by the NVIDIA driver on hosts that can run CUDA
+// and NVENC workloads
+const nvidiaDevice = "/dev/nvidiactl"
+
+// HWAccelValidator checks that the host exposes the devices needed by the
+// selected hardware transcoding backend
+type HWAccelValidator struct {
+	config *Config
+	// renderDir and nvidiaDevice are overridden in tests
+	renderDir    string
+	nvidiaDevice string
+}
+
+// NewHWAccelValidator creates a new hardware acceleration validator
+func NewHWAccelValidator(config *Config) *HWAccelValidator {
+	return &HWAccelValidator{
+		config:       config,
+		renderDir:    services.RenderDevicesDir,
+		nvidiaDevice: nvidiaDevice,
+	}
+}
+
+// Validate warns when the selected backend has no matching host device
+func (hv *HWAccelValidator) Validate() *ValidationResult {
+	result := &ValidationResult{Valid: true}
+
+	hwaccel := hv.config.HWAccel
+	if !hwaccel.Enabled() || !services.UsesHardwareAcceleration(hv.config.Services) {
+		return result
+	}
+
+	switch {
+	case hwaccel.UsesRenderDevices() && !pathExists(hv.renderDir):
+		result.AddError(
+			"hwaccel",
+			fmt.Sprintf("%s transcoding needs %s, which does not exist on this host", hwaccel, hv.renderDir),
+			SeverityWarning,
+		)
+	case hwaccel == services.HWAccelNVIDIA && !pathExists(hv.nvidiaDevice):
+		result.AddError(
+			"hwaccel",
+			fmt.Sprintf("nvidia transcoding needs the NVIDIA driver (%s not found)", hv.nvidiaDevice),
+			SeverityWarning,
+		)
+	}
+
+	return result
+}
+
+// RenderDeviceGroups returns the group IDs owning the render nodes under
+// /dev/dri, sorted, so containers can be given access with group_add. It
+// returns nil when the directory is missing or ownership is unavailable.
+func RenderDeviceGroups() []string {
+	return renderDeviceGroups(services.RenderDevicesDir)
+}
+
+func renderDeviceGroups(dir string) []string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	seen := make(map[string]bool)
+	var groups []string
+	for _, entry := range entries {
+		group, ok := deviceGroupID(filepath.Join(dir, entry.Name()))
+		if !ok || group == "0" || seen[group] {
+			continue
+		}
+		seen[group] = true
+		groups = append(groups, group)
+	}
+	sort.Strings(groups)
+	return groups
+}
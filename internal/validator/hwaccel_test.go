@@ -0,0 +1,61 @@
+package validator
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/woliveiras/corsarr/internal/services"
+)
+
+func TestHWAccelValidator(t *testing.T) {
+	registry, err := services.NewRegistry()
+	if err != nil {
+		t.Fatalf("Failed to create registry: %v", err)
+	}
+	existing := t.TempDir()
+	missing := filepath.Join(existing, "missing")
+
+	tests := []struct {
+		name          string
+		serviceIDs    []string
+		hwaccel       services.HWAccel
+		renderDir     string
+		nvidiaDevice  string
+		expectWarning bool
+	}{
+		{name: "VA-API without /dev/dri", serviceIDs: []string{"jellyfin"}, hwaccel: services.HWAccelVAAPI, renderDir: missing, expectWarning: true},
+		{name: "QSV with /dev/dri", serviceIDs: []string{"jellyfin"}, hwaccel: services.HWAccelQSV, renderDir: existing},
+		{name: "NVIDIA without driver", serviceIDs: []string{"jellyfin"}, hwaccel: services.HWAccelNVIDIA, nvidiaDevice: missing, expectWarning: true},
+		{name: "NVIDIA with driver", serviceIDs: []string{"jellyfin"}, hwaccel: services.HWAccelNVIDIA, nvidiaDevice: existing},
+		{name: "No transcoding service", serviceIDs: []string{"sonarr"}, hwaccel: services.HWAccelVAAPI, renderDir: missing},
+		{name: "Disabled", serviceIDs: []string{"jellyfin"}, hwaccel: services.HWAccelNone, renderDir: missing},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := NewConfig(registry, tt.serviceIDs, "/data", "/output", false)
+			if err != nil {
+				t.Fatalf("Failed to create config: %v", err)
+			}
+			config.HWAccel = tt.hwaccel
+
+			hv := NewHWAccelValidator(config)
+			hv.renderDir = tt.renderDir
+			hv.nvidiaDevice = tt.nvidiaDevice
+			result := hv.Validate()
+
+			if result.HasWarnings() != tt.expectWarning {
+				t.Errorf("Expected warning=%v, got %v", tt.expectWarning, result.Warnings)
+			}
+			if result.HasErrors() {
+				t.Errorf("Missing devices must only warn, got %v", result.Errors)
+			}
+		})
+	}
+}
+
+func TestRenderDeviceGroups_MissingDir(t *testing.T) {
+	if groups := renderDeviceGroups(filepath.Join(t.TempDir(), "dri")); groups != nil {
+		t.Errorf("Expected no groups without render devices, got %v", groups)
+	}
+}
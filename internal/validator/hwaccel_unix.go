@@ -0,0 +1,22 @@
+//go:build unix || darwin || linux
+
+package validator
+
+import (
+	"os"
+	"strconv"
+	"syscall"
+)
+
+// deviceGroupID returns the owning group ID of a device node
+func deviceGroupID(path string) (string, bool) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", false
+	}
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return "", false
+	}
+	return strconv.FormatUint(uint64(stat.Gid), 10), true
+}
@@ -0,0 +1,9 @@
+//go:build windows
+
+package validator
+
+// deviceGroupID is not available on Windows, where containers run in a
+// Linux VM that owns the devices
+func deviceGroupID(path string) (string, bool) {
+	return "", false
+}
@@ -22,8 +22,9 @@ func (pv *PortValidator) Validate() *ValidationResult {
 
 	// Collect all ports from selected services
 	portMap := make(map[string][]string) // "port/protocol" -> service names
+	selectedServices := pv.config.PortOverrides.ApplyAll(pv.config.Services)
 
-	for _, service := range pv.config.Services {
+	for _, service := range selectedServices {
 		// Get ports based on VPN mode
 		var portsWithOwner []struct {
 			port     string
@@ -35,7 +36,7 @@ func (pv *PortValidator) Validate() *ValidationResult {
 			// In VPN mode, only Gluetun exposes ports
 			if service.ID == "gluetun" {
 				// Gluetun will expose all other services' ports
-				for _, s := range pv.config.Services {
+				for _, s := range selectedServices {
 					if s.ID != "gluetun" && len(s.Ports) > 0 {
 						for _, portMapping := range s.Ports {
 							portsWithOwner = append(portsWithOwner, struct {
@@ -110,7 +111,7 @@ func GetPortConflicts(config *Config) map[string][]string {
 	conflicts := make(map[string][]string)
 	portMap := make(map[string][]string)
 
-	for _, service := range config.Services {
+	for _, service := range config.PortOverrides.ApplyAll(config.Services) {
 		var ports []string
 		if config.VPNEnabled && service.ID != "gluetun" {
 			// In VPN mode, services don't expose ports directly
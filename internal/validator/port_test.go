@@ -0,0 +1,22 @@
+package validator
+
+import (
+	"testing"
+
+	"github.com/woliveiras/corsarr/internal/services"
+)
+
+func TestGetPortConflicts_HonorsOverrides(t *testing.T) {
+	first := &services.Service{ID: "first", Name: "First", Ports: []services.PortMapping{{Host: "8080", Container: "8080", Protocol: "tcp"}}}
+	second := &services.Service{ID: "second", Name: "Second", Ports: []services.PortMapping{{Host: "8080", Container: "80", Protocol: "tcp"}}}
+
+	config := &Config{Services: []*services.Service{first, second}}
+	if conflicts := GetPortConflicts(config); len(conflicts["8080"]) != 2 {
+		t.Fatalf("Expected a conflict on 8080, got %v", conflicts)
+	}
+
+	config.PortOverrides = services.PortOverrides{"second": "8081"}
+	if conflicts := GetPortConflicts(config); len(conflicts) != 0 {
+		t.Errorf("Expected the override to resolve the conflict, got %v", conflicts)
+	}
+}
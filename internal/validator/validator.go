@@ -85,6 +85,10 @@ type Config struct {
 	OutputDir      string
 	VPNEnabled     bool
 	SkipDockerCheck bool
+	// PortOverrides remaps the host ports published by the selected services
+	PortOverrides services.PortOverrides
+	// HWAccel is the hardware transcoding backend requested with --hwaccel
+	HWAccel services.HWAccel
 }
 
 // NewConfig creates a new validation config
@@ -117,6 +121,7 @@ func ValidateAll(config *Config) *ValidationResult {
 		NewPortValidator(config),
 		NewDependencyValidator(config),
 		NewPathValidator(config),
+		NewHWAccelValidator(config),
 	}
 
 	// Add Docker validator if not skipped